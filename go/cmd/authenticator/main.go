@@ -1,7 +1,10 @@
 package main
 
 import (
+	"fmt"
+
 	"texas_real_foods/pkg/utils"
+	"texas_real_foods/pkg/migrations"
 	auth "texas_real_foods/pkg/authenticator"
 )
 
@@ -15,7 +18,14 @@ var (
 )
 
 func main() {
+	// create connection pool shared by all requests
+	pool, err := utils.NewPoolFromConfig(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("unable to create postgres connection pool: %+v", err))
+	}
+	defer pool.Close()
+	migrations.MustVerifySchema(pool)
 
-	service := auth.New(cfg.Get("postgres_url"), "0.0.0.0", 10101)
+	service := auth.New(auth.NewPersistenceWithPool(pool), "0.0.0.0", 10101)
 	service.Run()
 }
\ No newline at end of file
@@ -8,6 +8,8 @@ import (
 
     "texas_real_foods/pkg/connectors/google"
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/migrations"
+    "texas_real_foods/pkg/reconciler"
     updater "texas_real_foods/pkg/auto-updater"
 )
 
@@ -21,16 +23,26 @@ var (
             "google_api_key": "",
             "trf_api_host": "0.0.0.0",
             "trf_api_port": "10999",
+            "phone_default_region": "US",
+            "source_priority": "manual:5,google-api-connector:0.8,yelp-api-connector:0.7,web-scraper:0.6",
+            "recency_half_life_hours": "72",
         },
     )
 )
 
 func main() {
     log.SetLevel(log.DebugLevel)
+    // create connection pool shared by all jobs of the service
+    pool, err := utils.NewPoolFromConfig(cfg)
+    if err != nil {
+        panic(fmt.Sprintf("unable to create postgres connection pool: %+v", err))
+    }
+    defer pool.Close()
+    migrations.MustVerifySchema(pool)
 
     // generate new web connector and instance of notification engine
     connector := connectors.NewGoogleAPIConnector(cfg.Get("google_base_api"),
-        cfg.Get("google_api_key"))
+        cfg.Get("google_api_key"), cfg.Get("phone_default_region"))
 
     intervalString := cfg.Get("collection_interval_minutes")
     // convert given interval from string to integer
@@ -50,7 +62,15 @@ func main() {
         Host: cfg.Get("trf_api_host"),
         Port: &apiPort,
     }
+
+    // parse settings used to reconcile golden records
+    reconcilerConfig, err := reconciler.NewConfig(cfg.Get("source_priority"),
+        cfg.Get("recency_half_life_hours"))
+    if err != nil {
+        panic(fmt.Sprintf("received invalid reconciliation config: %+v", err))
+    }
+
     // create new updater with data connector and run
-    updater.New(connector, interval, cfg.Get("postgres_url"),
-        apiConfig).Run()
+    updater.New(connector, interval, pool,
+        apiConfig, reconcilerConfig).Run()
 }
\ No newline at end of file
@@ -8,6 +8,8 @@ import (
 
     "texas_real_foods/pkg/connectors/yelp"
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/migrations"
+    "texas_real_foods/pkg/reconciler"
     updater "texas_real_foods/pkg/auto-updater"
 )
 
@@ -21,6 +23,9 @@ var (
             "yelp_api_key": "",
             "trf_api_host": "0.0.0.0",
             "trf_api_port": "10999",
+            "phone_default_region": "US",
+            "source_priority": "manual:5,google-api-connector:0.8,yelp-api-connector:0.7,web-scraper:0.6",
+            "recency_half_life_hours": "72",
         },
     )
 )
@@ -41,10 +46,17 @@ func getTexasRealFoodsAPIConfig() utils.APIDependencyConfig {
 
 func main() {
     log.SetLevel(log.DebugLevel)
+    // create connection pool shared by all jobs of the service
+    pool, err := utils.NewPoolFromConfig(cfg)
+    if err != nil {
+        panic(fmt.Sprintf("unable to create postgres connection pool: %+v", err))
+    }
+    defer pool.Close()
+    migrations.MustVerifySchema(pool)
 
     // generate new web connector and instance of notification engine
     connector := connectors.NewYelpAPIConnector(cfg.Get("yelp_base_api"),
-        cfg.Get("yelp_api_key"))
+        cfg.Get("yelp_api_key"), cfg.Get("phone_default_region"))
 
     intervalString := cfg.Get("collection_interval_minutes")
     // convert given interval from string to integer
@@ -53,7 +65,14 @@ func main() {
         panic(fmt.Sprintf("received invalid collection interval '%s'", intervalString))
     }
 
+    // parse settings used to reconcile golden records
+    reconcilerConfig, err := reconciler.NewConfig(cfg.Get("source_priority"),
+        cfg.Get("recency_half_life_hours"))
+    if err != nil {
+        panic(fmt.Sprintf("received invalid reconciliation config: %+v", err))
+    }
+
     // create new updater with data connector and run
-    updater.New(connector, interval, cfg.Get("postgres_url"),
-        getTexasRealFoodsAPIConfig()).Run()
+    updater.New(connector, interval, pool,
+        getTexasRealFoodsAPIConfig(), reconcilerConfig).Run()
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "strconv"
+
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/api"
+    "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/memory"
+    "texas_real_foods/pkg/syncer"
+    "texas_real_foods/pkg/reconciler"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/notifications"
+    "texas_real_foods/pkg/timeseries-analyser"
+    auth "texas_real_foods/pkg/authenticator"
+    updater "texas_real_foods/pkg/auto-updater"
+)
+
+var (
+    // create map to house environment variables
+    cfg = utils.NewConfigMapWithValues(
+        map[string]string{
+            "trf_api_port": "10999",
+            "notify_api_port": "10756",
+            "authenticator_port": "10101",
+            "collection_interval_minutes": "1",
+            "analysis_interval_minutes": "1",
+            "phone_default_region": "US",
+            "connector_sources": "web-scraper,yelp-api-connector,google-api-connector",
+            "api_keys": "local-development-key",
+            "source_priority": "manual:5,google-api-connector:0.8,yelp-api-connector:0.7,web-scraper:0.6",
+            "recency_half_life_hours": "72",
+            "freshness_sla_hours": "web-scraper:24,yelp-api-connector:48,google-api-connector:48",
+        },
+    )
+)
+
+// function used to convert an integer setting from the config
+func getInt(key string) int {
+    value, err := strconv.Atoi(cfg.Get(key))
+    if err != nil || value < 1 {
+        panic(fmt.Sprintf("received invalid %s '%s'", key, cfg.Get(key)))
+    }
+    return value
+}
+
+// function used to split a comma separated setting from the config
+func getList(key string) []string {
+    values := []string{}
+    for _, value := range(strings.Split(cfg.Get(key), ",")) {
+        if value = strings.TrimSpace(value); len(value) > 0 {
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// runs the API, notification service, authenticator, syncer, auto-updaters
+// and timeseries analyser in a single process against an in-memory store.
+// auto-updaters use static connectors that report values from business
+// metadata so no external services or postgres instance are required
+func main() {
+    log.SetLevel(log.DebugLevel)
+
+    store := memory.New()
+    for _, key := range(getList("api_keys")) {
+        store.AddAPIKey(key)
+    }
+
+    trfPort, notifyPort := getInt("trf_api_port"), getInt("notify_api_port")
+    trfConfig := utils.APIDependencyConfig{Host: "localhost", Port: &trfPort, Protocol: "http"}
+    notifyConfig := utils.APIDependencyConfig{Host: "localhost", Port: &notifyPort, Protocol: "http"}
+
+    // parse settings used to reconcile golden records
+    reconcilerConfig, err := reconciler.NewConfig(cfg.Get("source_priority"),
+        cfg.Get("recency_half_life_hours"))
+    if err != nil {
+        panic(fmt.Sprintf("received invalid reconciliation config: %+v", err))
+    }
+
+    // parse per-source freshness SLAs
+    slas, err := connectors.ParseFreshnessSLAs(cfg.Get("freshness_sla_hours"))
+    if err != nil {
+        panic(fmt.Sprintf("received invalid freshness SLAs '%s'", cfg.Get("freshness_sla_hours")))
+    }
+
+    // start APIs used by the updaters and analyser
+    go api.NewWithRepository(store).Run(fmt.Sprintf(":%d", trfPort))
+    go notifications.NewNotificationService(store).Run(fmt.Sprintf(":%d", notifyPort))
+    go auth.New(store, "0.0.0.0", getInt("authenticator_port")).Run()
+
+    // start a static auto-updater for each configured source
+    interval := getInt("collection_interval_minutes")
+    for _, source := range(getList("connector_sources")) {
+        go updater.NewWithRepository(memory.NewStaticConnector(source), interval,
+            store, store, trfConfig, reconcilerConfig).Run()
+    }
+
+    notify := notifications.NewDefaultNotificationEngine(store)
+    go syncer.NewSyncer(store, interval, notify, cfg.Get("phone_default_region"),
+        500, true, slas).Run()
+
+    timeseries_analyser.NewAnalyser(trfConfig, notifyConfig, getInt("analysis_interval_minutes"),
+        cfg.Get("phone_default_region"), timeseries_analyser.AnalysisConfig{
+            WindowMinutes: 1440,
+            ConfirmationCount: 3,
+            FlapThreshold: 4,
+        }, nil).Run()
+}
@@ -5,6 +5,7 @@ import (
     "strconv"
 
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/migrations"
     relay "texas_real_foods/pkg/mail-relay"
 )
 
@@ -49,22 +50,22 @@ func getMailChimpConfig() relay.MailChimpConfig {
 
 func main() {
     cfg.ConfigureLogging()
-    // get listen port from environment variables and start new server
-    listenPort, err := strconv.Atoi(cfg.Get("listen_port"))
+    // create connection pool shared by all requests
+    pool, err := utils.NewPoolFromConfig(cfg)
     if err != nil {
-        panic(fmt.Sprintf("invalid listen port '%s'", cfg.Get("listen_port")))
+        panic(fmt.Sprintf("unable to create postgres connection pool: %+v", err))
     }
+    defer pool.Close()
+    migrations.MustVerifySchema(pool)
 
-    // generate new postgres persistence
-    persistence := relay.NewPersistence(cfg.Get("postgres_url"))
-    conn, err := persistence.Connect()
+    // get listen port from environment variables and start new server
+    listenPort, err := strconv.Atoi(cfg.Get("listen_port"))
     if err != nil {
-        panic(fmt.Errorf("unable to connect to postgres server: %+v", err))
+        panic(fmt.Sprintf("invalid listen port '%s'", cfg.Get("listen_port")))
     }
-    defer conn.Close()
 
     // generate new mail server and run
     mailServer := relay.NewMailRelay(getMailChimpConfig(), getUtilsAPIConfig(),
-        persistence)
+        relay.NewPersistenceWithPool(pool))
     mailServer.Run(fmt.Sprintf(":%d", listenPort))
 }
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/migrations"
     "texas_real_foods/pkg/notifications"
 )
 
@@ -23,13 +24,20 @@ var (
 
 func main() {
     log.SetLevel(log.DebugLevel)
-
     // get listen port from environment variables and start new server
     listenPort, err := strconv.Atoi(cfg.Get("listen_port"))
     if err != nil {
         panic(fmt.Sprintf("invalid listen port '%s'", cfg.Get("listen_port")))
     }
     // create new instance of mail relay with variables and run
-    service := notifications.NewNotificationService(cfg.Get("postgres_url"))
+    // create connection pool shared by all requests
+    pool, err := utils.NewPoolFromConfig(cfg)
+    if err != nil {
+        panic(fmt.Sprintf("unable to create postgres connection pool: %+v", err))
+    }
+    defer pool.Close()
+    migrations.MustVerifySchema(pool)
+
+    service := notifications.NewNotificationService(notifications.NewPersistenceWithPool(pool))
     service.Run(fmt.Sprintf("%s:%d", cfg.Get("listen_address"), listenPort))
 }
\ No newline at end of file
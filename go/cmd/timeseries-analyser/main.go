@@ -7,6 +7,7 @@ import (
     log "github.com/sirupsen/logrus"
 
 	"texas_real_foods/pkg/timeseries-analyser"
+    "texas_real_foods/pkg/alert-rules"
 	"texas_real_foods/pkg/utils"
 )
 
@@ -20,6 +21,11 @@ var (
             "trf_api_port": "10999",
             "notify_api_host": "0.0.0.0",
             "notify_api_port": "10756",
+            "phone_default_region": "US",
+            "analysis_window_minutes": "1440",
+            "confirmation_count": "3",
+            "flap_threshold": "4",
+            "alert_rules_file": "",
         },
     )
 )
@@ -52,6 +58,33 @@ func getTRFAPIConfig() utils.APIDependencyConfig {
     }
 }
 
+func getAnalysisConfig() timeseries_analyser.AnalysisConfig {
+    // convert analysis settings from strings to integers
+    values := map[string]int{}
+    for _, key := range([]string{"analysis_window_minutes", "confirmation_count", "flap_threshold"}) {
+        value, err := strconv.Atoi(cfg.Get(key))
+        if err != nil || value < 1 {
+            panic(fmt.Sprintf("received invalid %s '%s'", key, cfg.Get(key)))
+        }
+        values[key] = value
+    }
+    return timeseries_analyser.AnalysisConfig{
+        WindowMinutes: values["analysis_window_minutes"],
+        ConfirmationCount: values["confirmation_count"],
+        FlapThreshold: values["flap_threshold"],
+    }
+}
+
+func getAlertRules() []alert_rules.Rule {
+    // load alert rules from configured file. rules can also be
+    // managed through the notifications API
+    rules, err := alert_rules.LoadRulesFromFile(cfg.Get("alert_rules_file"))
+    if err != nil {
+        panic(fmt.Sprintf("received invalid alert rules file '%s': %+v", cfg.Get("alert_rules_file"), err))
+    }
+    return rules
+}
+
 func main() {
     log.SetLevel(log.DebugLevel)
 
@@ -64,5 +97,6 @@ func main() {
 
     // generate new timeseries analyser and run
 	timeseries_analyser.NewAnalyser(getTRFAPIConfig(),
-        getNotifyAPIConfig(), interval).Run()
+        getNotifyAPIConfig(), interval, cfg.Get("phone_default_region"),
+        getAnalysisConfig(), getAlertRules()).Run()
 }
\ No newline at end of file
@@ -1,6 +1,6 @@
 module texas_real_foods
 
-go 1.15
+go 1.16
 
 require (
 	github.com/PSauerborn/hermes v1.0.0
@@ -12,4 +12,5 @@ require (
 	github.com/google/uuid v1.1.5
 	github.com/jackc/pgx/v4 v4.10.1
 	github.com/sirupsen/logrus v1.7.0
+	golang.org/x/net v0.0.0-20200602114024-627f9648deb9
 )
@@ -0,0 +1,75 @@
+package alert_rules
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+var (
+    // define supported rule types
+    RuleConsecutive      = "consecutive"
+    RuleChangedInSources = "changed_in_sources"
+    RuleNoData           = "no_data"
+
+    // define supported rule severities
+    Severities = []string{"info", "warning", "critical"}
+
+    // define fields that can be evaluated by rules
+    Fields = []string{"website_live", "business_phones", "business_open"}
+)
+
+// struct used to define a single alert rule. the fields used by a
+// rule depend on its type:
+//  - consecutive: field has value for count consecutive observations
+//    from source (or from any single source if source is not set)
+//  - changed_in_sources: field changed in at least count sources
+//    within the last window_hours
+//  - no_data: no observations from source (or from any source if
+//    source is not set) within the last window_hours
+// the message is a text/template rendered with the fields of
+// TemplateData
+type Rule struct {
+    RuleId      string  `json:"rule_id"`
+    Type        string  `json:"type" binding:"required"`
+    Field       string  `json:"field,omitempty"`
+    Value       string  `json:"value,omitempty"`
+    Source      string  `json:"source,omitempty"`
+    Count       int     `json:"count,omitempty"`
+    WindowHours float64 `json:"window_hours,omitempty"`
+    Severity    string  `json:"severity" binding:"required"`
+    Message     string  `json:"message" binding:"required"`
+}
+
+// struct used to store a single observation of business data
+type Observation struct {
+    Timestamp time.Time
+    Data      connectors.BusinessData
+}
+
+// struct used to store a rule that has been triggered for a
+// business. the timestamp identifies the event that triggered
+// the rule and is used to ensure that each event is only
+// reported once
+type Match struct {
+    Rule      Rule
+    Source    string
+    Sources   []string
+    Timestamp time.Time
+}
+
+// struct used to store the values available to message templates
+type TemplateData struct {
+    RuleId       string
+    Severity     string
+    BusinessId   uuid.UUID
+    BusinessName string
+    Field        string
+    Value        string
+    Source       string
+    Sources      []string
+    Count        int
+    WindowHours  float64
+}
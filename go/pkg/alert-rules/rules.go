@@ -0,0 +1,298 @@
+package alert_rules
+
+import (
+    "fmt"
+    "sort"
+    "time"
+    "bytes"
+    "errors"
+    "strings"
+    "io/ioutil"
+    "text/template"
+    "encoding/json"
+
+    "texas_real_foods/pkg/phone"
+    "texas_real_foods/pkg/connectors"
+)
+
+var (
+    // define custom errors
+    ErrInvalidRuleId       = errors.New("Invalid rule ID")
+    ErrInvalidRuleType     = errors.New("Invalid rule type")
+    ErrInvalidRuleField    = errors.New("Invalid rule field")
+    ErrInvalidRuleCount    = errors.New("Invalid rule count")
+    ErrInvalidRuleWindow   = errors.New("Invalid rule window")
+    ErrInvalidRuleSeverity = errors.New("Invalid rule severity")
+    ErrInvalidRuleMessage  = errors.New("Invalid rule message template")
+    ErrDuplicateRuleId     = errors.New("Duplicate rule ID")
+)
+
+// function used to validate a rule. an error is returned if the
+// rule is missing any of the settings required by its type
+func(rule Rule) Validate() error {
+    if len(strings.TrimSpace(rule.RuleId)) == 0 {
+        return ErrInvalidRuleId
+    }
+    if !stringSliceContains(Severities, rule.Severity) {
+        return ErrInvalidRuleSeverity
+    }
+    if _, err := template.New(rule.RuleId).Parse(rule.Message); err != nil ||
+        len(strings.TrimSpace(rule.Message)) == 0 {
+        return ErrInvalidRuleMessage
+    }
+
+    switch rule.Type {
+    case RuleConsecutive:
+        if !stringSliceContains(Fields, rule.Field) {
+            return ErrInvalidRuleField
+        }
+        if rule.Count < 1 {
+            return ErrInvalidRuleCount
+        }
+    case RuleChangedInSources:
+        if !stringSliceContains(Fields, rule.Field) {
+            return ErrInvalidRuleField
+        }
+        if rule.Count < 1 {
+            return ErrInvalidRuleCount
+        }
+        if rule.WindowHours <= 0 {
+            return ErrInvalidRuleWindow
+        }
+    case RuleNoData:
+        if rule.WindowHours <= 0 {
+            return ErrInvalidRuleWindow
+        }
+    default:
+        return ErrInvalidRuleType
+    }
+    return nil
+}
+
+// function used to retrieve the window of a rule as a duration
+func(rule Rule) Window() time.Duration {
+    return time.Duration(rule.WindowHours * float64(time.Hour))
+}
+
+// function used to render the message template of a rule for a
+// given business and match
+func(rule Rule) Render(business connectors.BusinessMetadata, match Match) (string, error) {
+    tmpl, err := template.New(rule.RuleId).Parse(rule.Message)
+    if err != nil {
+        return "", err
+    }
+    data := TemplateData{
+        RuleId: rule.RuleId,
+        Severity: rule.Severity,
+        BusinessId: business.BusinessId,
+        BusinessName: business.BusinessName,
+        Field: rule.Field,
+        Value: rule.Value,
+        Source: match.Source,
+        Sources: match.Sources,
+        Count: rule.Count,
+        WindowHours: rule.WindowHours,
+    }
+    var message bytes.Buffer
+    if err := tmpl.Execute(&message, data); err != nil {
+        return "", err
+    }
+    return message.String(), nil
+}
+
+// function used to validate a list of rules. rule IDs must be unique
+func ValidateRules(rules []Rule) error {
+    seen := map[string]bool{}
+    for _, rule := range(rules) {
+        if err := rule.Validate(); err != nil {
+            return fmt.Errorf("invalid rule '%s': %w", rule.RuleId, err)
+        }
+        if seen[rule.RuleId] {
+            return fmt.Errorf("invalid rule '%s': %w", rule.RuleId, ErrDuplicateRuleId)
+        }
+        seen[rule.RuleId] = true
+    }
+    return nil
+}
+
+// function used to load rules from a JSON file containing a list of
+// rules. an empty path returns an empty list of rules
+func LoadRulesFromFile(path string) ([]Rule, error) {
+    rules := []Rule{}
+    if len(strings.TrimSpace(path)) == 0 {
+        return rules, nil
+    }
+    content, err := ioutil.ReadFile(path)
+    if err != nil {
+        return rules, err
+    }
+    if err := json.Unmarshal(content, &rules); err != nil {
+        return rules, err
+    }
+    return rules, ValidateRules(rules)
+}
+
+// function used to merge two lists of rules. rules in the overrides
+// replace rules in the base list with the same rule ID
+func MergeRules(base, overrides []Rule) []Rule {
+    merged := []Rule{}
+    replaced := map[string]bool{}
+    for _, rule := range(overrides) {
+        replaced[rule.RuleId] = true
+    }
+    for _, rule := range(base) {
+        if !replaced[rule.RuleId] {
+            merged = append(merged, rule)
+        }
+    }
+    return append(merged, overrides...)
+}
+
+// function used to determine the lookback period required to evaluate
+// a list of rules i.e. the longest window of any rule
+func Lookback(rules []Rule) time.Duration {
+    lookback := time.Duration(0)
+    for _, rule := range(rules) {
+        if rule.Window() > lookback {
+            lookback = rule.Window()
+        }
+    }
+    return lookback
+}
+
+// function used to evaluate a rule against the observations of each
+// source for a single business. observations must be sorted by
+// timestamp. the second return value is false if the rule is not
+// triggered
+func Evaluate(rule Rule, data map[string][]Observation, region string,
+    now time.Time) (Match, bool) {
+    switch rule.Type {
+    case RuleConsecutive:
+        return evaluateConsecutive(rule, data, region)
+    case RuleChangedInSources:
+        return evaluateChangedInSources(rule, data, region, now)
+    case RuleNoData:
+        return evaluateNoData(rule, data, now)
+    }
+    return Match{}, false
+}
+
+// function used to evaluate consecutive rules. the match timestamp is
+// the first observation of the current run of matching values, so
+// that a run is only reported once no matter how long it continues
+func evaluateConsecutive(rule Rule, data map[string][]Observation, region string) (Match, bool) {
+    for _, source := range(sortedSources(data)) {
+        if len(rule.Source) > 0 && source != rule.Source {
+            continue
+        }
+        values := data[source]
+        run := 0
+        for i := len(values) - 1; i >= 0; i-- {
+            if fieldValue(values[i].Data, rule.Field, region) != rule.Value {
+                break
+            }
+            run++
+        }
+        if run >= rule.Count {
+            return Match{
+                Rule: rule,
+                Source: source,
+                Sources: []string{source},
+                Timestamp: values[len(values) - run].Timestamp,
+            }, true
+        }
+    }
+    return Match{}, false
+}
+
+// function used to evaluate changed in sources rules. the match
+// timestamp is the most recent change found in any source
+func evaluateChangedInSources(rule Rule, data map[string][]Observation, region string,
+    now time.Time) (Match, bool) {
+    start := now.Add(-rule.Window())
+    changed := []string{}
+    var latest time.Time
+    for _, source := range(sortedSources(data)) {
+        if len(rule.Source) > 0 && source != rule.Source {
+            continue
+        }
+        previous, found := "", false
+        for _, observation := range(data[source]) {
+            value := fieldValue(observation.Data, rule.Field, region)
+            // observations before the window are only used as a baseline
+            if found && value != previous && !observation.Timestamp.Before(start) {
+                if !stringSliceContains(changed, source) {
+                    changed = append(changed, source)
+                }
+                if observation.Timestamp.After(latest) {
+                    latest = observation.Timestamp
+                }
+            }
+            previous, found = value, true
+        }
+    }
+    if len(changed) >= rule.Count {
+        return Match{Rule: rule, Sources: changed, Timestamp: latest}, true
+    }
+    return Match{}, false
+}
+
+// function used to evaluate no data rules. as there is no observation
+// to identify the event, the match timestamp is the start of the
+// current window period so that a rule is reported at most once
+// per window while data is missing
+func evaluateNoData(rule Rule, data map[string][]Observation, now time.Time) (Match, bool) {
+    start := now.Add(-rule.Window())
+    for source, values := range(data) {
+        if len(rule.Source) > 0 && source != rule.Source {
+            continue
+        }
+        for _, observation := range(values) {
+            if !observation.Timestamp.Before(start) {
+                return Match{}, false
+            }
+        }
+    }
+    match := Match{Rule: rule, Source: rule.Source, Sources: []string{}, Timestamp: now.Truncate(rule.Window())}
+    if len(rule.Source) > 0 {
+        match.Sources = []string{rule.Source}
+    }
+    return match, true
+}
+
+// function used to convert the value of a field to a string to
+// allow comparisons. phone numbers are normalized and sorted
+func fieldValue(data connectors.BusinessData, field, region string) string {
+    switch field {
+    case "website_live":
+        return fmt.Sprintf("%t", data.WebsiteLive)
+    case "business_open":
+        return fmt.Sprintf("%t", data.BusinessOpen)
+    case "business_phones":
+        phones := phone.NormalizeAll(data.BusinessPhones, region)
+        sort.Strings(phones)
+        return strings.Join(phones, ",")
+    }
+    return ""
+}
+
+// function used to retrieve the sources of a data map in a
+// deterministic order
+func sortedSources(data map[string][]Observation) []string {
+    sources := []string{}
+    for source := range(data) {
+        sources = append(sources, source)
+    }
+    sort.Strings(sources)
+    return sources
+}
+
+// helper function used to check if a string slice contains a value
+func stringSliceContains(slice []string, element string) bool {
+    for _, item := range(slice) {
+        if item == element {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,144 @@
+package alert_rules
+
+import (
+    "time"
+    "errors"
+    "strings"
+    "testing"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/phone"
+)
+
+// function used to generate hourly observations of a website from a
+// string of 'T' (live) and 'F' (down) states. the last observation is
+// made the given number of hours before now
+func testObservations(now time.Time, states string, hoursAgo int) []Observation {
+    observations := []Observation{}
+    for i, state := range(states) {
+        age := time.Duration(hoursAgo + len(states) - 1 - i) * time.Hour
+        observations = append(observations, Observation{Timestamp: now.Add(-age),
+            Data: connectors.BusinessData{WebsiteLive: state == 'T'}})
+    }
+    return observations
+}
+
+func TestEvaluate(t *testing.T) {
+    now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    consecutive := Rule{RuleId: "down", Type: RuleConsecutive, Field: "website_live", Value: "false", Count: 3}
+    consecutiveSource := consecutive
+    consecutiveSource.Source = "a"
+    changed := Rule{RuleId: "changed", Type: RuleChangedInSources, Field: "website_live", Count: 2,
+        WindowHours: 3}
+    noData := Rule{RuleId: "silent", Type: RuleNoData, WindowHours: 2}
+    noDataSource := noData
+    noDataSource.Source = "b"
+
+    tests := []struct {
+        name      string
+        rule      Rule
+        data      map[string][]Observation
+        matched   bool
+        sources   string
+        timestamp time.Time
+    }{
+        {"consecutive run", consecutive, map[string][]Observation{
+            "a": testObservations(now, "TFFF", 1),
+        }, true, "a", now.Add(-3 * time.Hour)},
+        {"consecutive run ended", consecutive, map[string][]Observation{
+            "a": testObservations(now, "TFFFT", 1),
+        }, false, "", time.Time{}},
+        {"consecutive run too short", consecutive, map[string][]Observation{
+            "a": testObservations(now, "TTFF", 1),
+        }, false, "", time.Time{}},
+        {"consecutive in second source", consecutive, map[string][]Observation{
+            "a": testObservations(now, "TFF", 1),
+            "b": testObservations(now, "FFFF", 1),
+        }, true, "b", now.Add(-4 * time.Hour)},
+        {"consecutive in other source", consecutiveSource, map[string][]Observation{
+            "a": testObservations(now, "TFF", 1),
+            "b": testObservations(now, "FFFF", 1),
+        }, false, "", time.Time{}},
+        {"changed in sources", changed, map[string][]Observation{
+            "a": testObservations(now, "TTTF", 1),
+            "b": testObservations(now, "TFFF", 1),
+        }, true, "a,b", now.Add(-time.Hour)},
+        {"changed before window", changed, map[string][]Observation{
+            "a": testObservations(now, "TTTF", 1),
+            "b": testObservations(now, "TFFFF", 1),
+        }, false, "", time.Time{}},
+        {"changed in single source", changed, map[string][]Observation{
+            "a": testObservations(now, "TFTF", 1),
+            "b": testObservations(now, "TTTT", 1),
+        }, false, "", time.Time{}},
+        {"unchanged baseline", changed, map[string][]Observation{
+            "a": testObservations(now, "F", 1),
+            "b": testObservations(now, "T", 1),
+        }, false, "", time.Time{}},
+        {"no data", noData, map[string][]Observation{
+            "a": testObservations(now, "TT", 3),
+        }, true, "", now.Truncate(2 * time.Hour)},
+        {"no data without sources", noData, map[string][]Observation{}, true, "",
+            now.Truncate(2 * time.Hour)},
+        {"recent data", noData, map[string][]Observation{
+            "a": testObservations(now, "TT", 3),
+            "b": testObservations(now, "T", 1),
+        }, false, "", time.Time{}},
+        {"no data from source", noDataSource, map[string][]Observation{
+            "a": testObservations(now, "T", 1),
+            "b": testObservations(now, "T", 3),
+        }, true, "b", now.Truncate(2 * time.Hour)},
+        {"recent data from source", noDataSource, map[string][]Observation{
+            "a": testObservations(now, "T", 3),
+            "b": testObservations(now, "T", 1),
+        }, false, "", time.Time{}},
+    }
+    for _, test := range(tests) {
+        match, ok := Evaluate(test.rule, test.data, phone.DefaultRegion, now)
+        if ok != test.matched {
+            t.Errorf("%s: expected match %v, got %+v", test.name, test.matched, match)
+            continue
+        }
+        if !ok {
+            continue
+        }
+        if strings.Join(match.Sources, ",") != test.sources || !match.Timestamp.Equal(test.timestamp) {
+            t.Errorf("%s: expected sources %q at %s, got %v at %s", test.name, test.sources,
+                test.timestamp, match.Sources, match.Timestamp)
+        }
+    }
+}
+
+func TestValidateRules(t *testing.T) {
+    valid := Rule{RuleId: "down", Type: RuleConsecutive, Field: "website_live", Value: "false", Count: 3,
+        Severity: "warning", Message: "{{.BusinessName}} is down"}
+    modify := func(change func(rule *Rule)) Rule {
+        rule := valid
+        change(&rule)
+        return rule
+    }
+
+    tests := []struct {
+        name  string
+        rules []Rule
+        err   error
+    }{
+        {"valid", []Rule{valid}, nil},
+        {"missing ID", []Rule{modify(func(rule *Rule) { rule.RuleId = " " })}, ErrInvalidRuleId},
+        {"unknown type", []Rule{modify(func(rule *Rule) { rule.Type = "threshold" })}, ErrInvalidRuleType},
+        {"unknown field", []Rule{modify(func(rule *Rule) { rule.Field = "rating" })}, ErrInvalidRuleField},
+        {"missing count", []Rule{modify(func(rule *Rule) { rule.Count = 0 })}, ErrInvalidRuleCount},
+        {"missing window", []Rule{modify(func(rule *Rule) { rule.Type = RuleNoData })}, ErrInvalidRuleWindow},
+        {"unknown severity", []Rule{modify(func(rule *Rule) { rule.Severity = "high" })},
+            ErrInvalidRuleSeverity},
+        {"invalid template", []Rule{modify(func(rule *Rule) { rule.Message = "{{.BusinessName" })},
+            ErrInvalidRuleMessage},
+        {"duplicate ID", []Rule{valid, valid}, ErrDuplicateRuleId},
+    }
+    for _, test := range(tests) {
+        err := ValidateRules(test.rules)
+        if (test.err == nil && err != nil) || (test.err != nil && !errors.Is(err, test.err)) {
+            t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+        }
+    }
+}
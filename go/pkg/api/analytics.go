@@ -0,0 +1,288 @@
+package api
+
+import (
+    "fmt"
+    "sort"
+    "time"
+    "strings"
+    "strconv"
+
+    "github.com/google/uuid"
+)
+
+var (
+    // define header used when exporting availability reports to CSV
+    AvailabilityCSVHeader = []string{
+        "business_id", "business_name", "source", "observations", "first_observed",
+        "last_observed", "website_up_percent", "website_state_changes", "website_outages",
+        "website_longest_outage_hours", "website_mean_time_between_outages_hours",
+        "website_live", "open_percent", "open_state_changes", "closures",
+        "longest_closure_hours", "business_open",
+    }
+)
+
+// struct used to store a single observation of a boolean state
+type statePoint struct {
+    timestamp time.Time
+    value     bool
+}
+
+// struct used to store the totals of the observations of a boolean
+// state over a period. totals are computed from the observations in
+// memory or aggregated by the database, and converted into a state
+// report with the same calculation
+type stateTotals struct {
+    upSeconds            float64
+    observedSeconds      float64
+    stateChanges         int
+    outages              int
+    longestOutageSeconds float64
+    current              bool
+}
+
+// function used to compute the totals of a state over a period. each
+// observation is assumed to hold until the next observation, with the
+// last observation holding until the end of the period. the period
+// before the first observation is not considered. points must be sorted
+// by timestamp
+func computeStateTotals(points []statePoint, end time.Time) stateTotals {
+    totals := stateTotals{}
+    if len(points) == 0 {
+        return totals
+    }
+
+    var (up, observed time.Duration; outageStart *time.Time)
+    for i, point := range(points) {
+        next := end
+        if i < len(points) - 1 {
+            next = points[i + 1].timestamp
+        }
+        duration := next.Sub(point.timestamp)
+        if duration < 0 {
+            duration = 0
+        }
+        observed += duration
+        if point.value {
+            up += duration
+        }
+
+        if i > 0 && point.value != points[i - 1].value {
+            totals.stateChanges++
+        }
+        // track start and end of outages to find longest outage
+        if !point.value && outageStart == nil {
+            start := point.timestamp
+            outageStart = &start
+            totals.outages++
+        }
+        if outageStart != nil && (point.value || i == len(points) - 1) {
+            outageEnd := point.timestamp
+            if !point.value {
+                outageEnd = end
+            }
+            if seconds := outageEnd.Sub(*outageStart).Seconds(); seconds > totals.longestOutageSeconds {
+                totals.longestOutageSeconds = seconds
+            }
+            if point.value {
+                outageStart = nil
+            }
+        }
+    }
+    totals.upSeconds, totals.observedSeconds = up.Seconds(), observed.Seconds()
+    totals.current = points[len(points) - 1].value
+    return totals
+}
+
+// function used to compute the availability of a state from its totals
+func(totals stateTotals) report() StateReport {
+    report := StateReport{
+        StateChanges: totals.stateChanges,
+        Outages: totals.outages,
+        LongestOutageHours: totals.longestOutageSeconds / 3600,
+        Current: totals.current,
+    }
+    if totals.observedSeconds > 0 {
+        report.UpPercent = 100 * totals.upSeconds / totals.observedSeconds
+    } else if totals.current {
+        report.UpPercent = 100
+    }
+    if totals.outages > 0 {
+        mean := totals.upSeconds / 3600 / float64(totals.outages)
+        report.MeanTimeBetweenOutagesHours = &mean
+    }
+    return report
+}
+
+// function used to cap the end of an availability period at the current
+// time so that observations do not hold into the future
+func availabilityEnd(end time.Time) time.Time {
+    if now := time.Now(); end.After(now) {
+        return now
+    }
+    return end
+}
+
+// function used to compute the availability of a business for a single
+// source. the end of the period is capped at the current time
+func computeSourceAvailability(source string, data []TimeSeriesData,
+    end time.Time) SourceAvailability {
+
+    sort.Slice(data, func(i, j int) bool {
+        return data[i].EventTimestamp.Before(data[j].EventTimestamp)
+    })
+    end = availabilityEnd(end)
+
+    availability := SourceAvailability{Source: source, Observations: len(data)}
+    if len(data) == 0 {
+        return availability
+    }
+    website, open := []statePoint{}, []statePoint{}
+    for _, entry := range(data) {
+        website = append(website, statePoint{entry.EventTimestamp, entry.WebsiteLive})
+        open = append(open, statePoint{entry.EventTimestamp, entry.BusinessOpen})
+    }
+    availability.FirstObserved = data[0].EventTimestamp
+    availability.LastObserved = data[len(data) - 1].EventTimestamp
+    availability.Website = computeStateTotals(website, end).report()
+    availability.Open = computeStateTotals(open, end).report()
+    return availability
+}
+
+// function used to compute the availability of a business for all
+// sources with data in the given period
+func ComputeBusinessAvailability(business BusinessInfo, data []TimeSeriesData,
+    end time.Time) BusinessAvailability {
+
+    availability := BusinessAvailability{
+        BusinessId: business.BusinessId,
+        BusinessName: business.BusinessName,
+        Sources: []SourceAvailability{},
+    }
+    grouped := GroupTimeseriesDataBySource(data)
+    sources := []string{}
+    for source := range(grouped) {
+        sources = append(sources, source)
+    }
+    sort.Strings(sources)
+    for _, source := range(sources) {
+        availability.Sources = append(availability.Sources,
+            computeSourceAvailability(source, grouped[source], end))
+    }
+    return availability
+}
+
+// function used to compute the availability of all businesses for a
+// single source from their timeseries data. businesses without data in
+// the period are reported without sources
+func ComputeDirectoryAvailability(businesses []BusinessInfo, data map[uuid.UUID][]TimeSeriesData,
+    source string, end time.Time) []BusinessAvailability {
+
+    results := []BusinessAvailability{}
+    for _, business := range(businesses) {
+        availability := BusinessAvailability{
+            BusinessId: business.BusinessId,
+            BusinessName: business.BusinessName,
+            Sources: []SourceAvailability{},
+        }
+        if values, ok := data[business.BusinessId]; ok && len(values) > 0 {
+            availability.Sources = append(availability.Sources,
+                computeSourceAvailability(source, values, end))
+        }
+        results = append(results, availability)
+    }
+    return results
+}
+
+// function used to compute the directory-wide rollup of the availability
+// of all businesses for a single source
+func SummariseDirectoryAvailability(reports []BusinessAvailability, source string,
+    start, end time.Time) DirectoryAvailability {
+
+    summary := DirectoryAvailability{
+        Start: start,
+        End: end,
+        Source: source,
+        Businesses: len(reports),
+    }
+    totalUptime := 0.0
+    for _, availability := range(reports) {
+        for _, report := range(availability.Sources) {
+            if report.Source != source || report.Observations == 0 {
+                continue
+            }
+            summary.BusinessesObserved++
+            totalUptime += report.Website.UpPercent
+            if report.Website.Current {
+                summary.BusinessesLive++
+            }
+            if report.Open.Current {
+                summary.BusinessesOpen++
+            }
+        }
+    }
+
+    if summary.BusinessesObserved > 0 {
+        observed := float64(summary.BusinessesObserved)
+        summary.PercentLive = 100 * float64(summary.BusinessesLive) / observed
+        summary.PercentOpen = 100 * float64(summary.BusinessesOpen) / observed
+        summary.MeanUptimePercent = totalUptime / observed
+    }
+    return summary
+}
+
+// function used to convert availability reports into CSV records.
+// a single record is generated for each business and source, and
+// businesses without observations are exported with empty values
+func AvailabilityCSVRecords(reports []BusinessAvailability) [][]string {
+    records := [][]string{AvailabilityCSVHeader}
+    for _, report := range(reports) {
+        if len(report.Sources) == 0 {
+            record := make([]string, len(AvailabilityCSVHeader))
+            record[0], record[1], record[3] = report.BusinessId.String(), report.BusinessName, "0"
+            records = append(records, record)
+            continue
+        }
+        for _, source := range(report.Sources) {
+            records = append(records, []string{
+                report.BusinessId.String(),
+                report.BusinessName,
+                source.Source,
+                strconv.Itoa(source.Observations),
+                source.FirstObserved.Format(time.RFC3339),
+                source.LastObserved.Format(time.RFC3339),
+                formatFloat(source.Website.UpPercent),
+                strconv.Itoa(source.Website.StateChanges),
+                strconv.Itoa(source.Website.Outages),
+                formatFloat(source.Website.LongestOutageHours),
+                formatOptionalFloat(source.Website.MeanTimeBetweenOutagesHours),
+                strconv.FormatBool(source.Website.Current),
+                formatFloat(source.Open.UpPercent),
+                strconv.Itoa(source.Open.StateChanges),
+                strconv.Itoa(source.Open.Outages),
+                formatFloat(source.Open.LongestOutageHours),
+                strconv.FormatBool(source.Open.Current),
+            })
+        }
+    }
+    return records
+}
+
+// function used to generate the filename of an availability CSV export
+func availabilityFilename(name string, start, end time.Time) string {
+    name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "-")
+    return fmt.Sprintf("availability-%s-%s-%s.csv", name, start.Format("20060102"),
+        end.Format("20060102"))
+}
+
+// helper function used to format floats in CSV exports
+func formatFloat(value float64) string {
+    return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// helper function used to format optional floats in CSV exports
+func formatOptionalFloat(value *float64) string {
+    if value == nil {
+        return ""
+    }
+    return formatFloat(*value)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+    "math"
+    "time"
+    "testing"
+
+    "github.com/google/uuid"
+)
+
+// helper function used to compare computed floats
+func floatsEqual(a, b float64) bool {
+    return math.Abs(a - b) < 1e-9
+}
+
+func TestComputeStateReport(t *testing.T) {
+    start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(10 * time.Hour)
+    // function used to generate points at the given hours after the start
+    points := func(values ...interface{}) []statePoint {
+        results := []statePoint{}
+        for i := 0; i < len(values); i += 2 {
+            results = append(results, statePoint{start.Add(time.Duration(values[i].(int)) * time.Hour),
+                values[i + 1].(bool)})
+        }
+        return results
+    }
+    hours := func(value float64) *float64 {
+        return &value
+    }
+
+    tests := []struct {
+        name     string
+        points   []statePoint
+        expected StateReport
+    }{
+        {"no observations", points(), StateReport{}},
+        {"always up", points(0, true), StateReport{UpPercent: 100, Current: true}},
+        {"single outage", points(0, true, 4, false, 6, true), StateReport{UpPercent: 80, StateChanges: 2,
+            Outages: 1, LongestOutageHours: 2, MeanTimeBetweenOutagesHours: hours(8), Current: true}},
+        {"ongoing outage", points(0, false, 2, true, 5, false), StateReport{UpPercent: 30, StateChanges: 2,
+            Outages: 2, LongestOutageHours: 5, MeanTimeBetweenOutagesHours: hours(1.5)}},
+        {"repeated down observations", points(0, false, 3, false), StateReport{Outages: 1,
+            LongestOutageHours: 10, MeanTimeBetweenOutagesHours: hours(0)}},
+        {"observed at end", points(10, true), StateReport{UpPercent: 100, Current: true}},
+        {"down at end", points(10, false), StateReport{Outages: 1,
+            MeanTimeBetweenOutagesHours: hours(0)}},
+    }
+    for _, test := range(tests) {
+        report := computeStateTotals(test.points, end).report()
+        expected := test.expected
+        mean, expectedMean := report.MeanTimeBetweenOutagesHours, expected.MeanTimeBetweenOutagesHours
+        meanMatches := (mean == nil) == (expectedMean == nil) &&
+            (mean == nil || floatsEqual(*mean, *expectedMean))
+        if !floatsEqual(report.UpPercent, expected.UpPercent) || report.StateChanges != expected.StateChanges ||
+            report.Outages != expected.Outages || report.Current != expected.Current ||
+            !floatsEqual(report.LongestOutageHours, expected.LongestOutageHours) || !meanMatches {
+            t.Errorf("%s: expected %+v, got %+v", test.name, expected, report)
+        }
+    }
+}
+
+func TestSummariseDirectoryAvailability(t *testing.T) {
+    start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(24 * time.Hour)
+    report := func(source string, uptime float64, live, open bool) BusinessAvailability {
+        return BusinessAvailability{BusinessId: uuid.New(), Sources: []SourceAvailability{{
+            Source: source, Observations: 1, Website: StateReport{UpPercent: uptime, Current: live},
+            Open: StateReport{Current: open}}}}
+    }
+
+    tests := []struct {
+        name     string
+        reports  []BusinessAvailability
+        expected DirectoryAvailability
+    }{
+        {"no businesses", []BusinessAvailability{}, DirectoryAvailability{}},
+        {"unobserved businesses", []BusinessAvailability{{BusinessId: uuid.New(),
+            Sources: []SourceAvailability{}}}, DirectoryAvailability{Businesses: 1}},
+        {"observed businesses", []BusinessAvailability{
+            report("web-scraper", 100, true, true),
+            report("web-scraper", 50, false, true),
+            report("web-scraper", 30, true, false),
+            {BusinessId: uuid.New(), Sources: []SourceAvailability{}},
+        }, DirectoryAvailability{Businesses: 4, BusinessesObserved: 3, BusinessesLive: 2,
+            PercentLive: 200.0 / 3, MeanUptimePercent: 60, BusinessesOpen: 2, PercentOpen: 200.0 / 3}},
+        {"other sources ignored", []BusinessAvailability{
+            report("web-scraper", 100, true, true),
+            report("yelp-api-connector", 0, false, false),
+        }, DirectoryAvailability{Businesses: 2, BusinessesObserved: 1, BusinessesLive: 1, PercentLive: 100,
+            MeanUptimePercent: 100, BusinessesOpen: 1, PercentOpen: 100}},
+    }
+    for _, test := range(tests) {
+        summary := SummariseDirectoryAvailability(test.reports, "web-scraper", start, end)
+        expected := test.expected
+        expected.Start, expected.End, expected.Source = start, end, "web-scraper"
+        if summary.Businesses != expected.Businesses ||
+            summary.BusinessesObserved != expected.BusinessesObserved ||
+            summary.BusinessesLive != expected.BusinessesLive ||
+            summary.BusinessesOpen != expected.BusinessesOpen ||
+            !floatsEqual(summary.PercentLive, expected.PercentLive) ||
+            !floatsEqual(summary.PercentOpen, expected.PercentOpen) ||
+            !floatsEqual(summary.MeanUptimePercent, expected.MeanUptimePercent) ||
+            summary.Source != expected.Source || !summary.Start.Equal(start) || !summary.End.Equal(end) {
+            t.Errorf("%s: expected %+v, got %+v", test.name, expected, summary)
+        }
+    }
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+    "time"
+    "bytes"
+    "errors"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+
+    "github.com/gin-gonic/gin"
+    "github.com/google/uuid"
+)
+
+var (
+    // define custom errors
+    ErrVersionNotFound = errors.New("Cannot find specified business version")
+
+    // define headers used to identify the actor of a change. the claimed
+    // user header is optional and is set by clients acting on behalf of a
+    // user. it is not authenticated and is recorded as asserted by the client
+    ApiKeyHeader = "X-ApiKey"
+    ClaimedUserHeader = "X-User"
+)
+
+const (
+    // define actions recorded in the audit log of a business
+    AuditActionCreate = "create"
+    AuditActionUpdateURI = "update_uri"
+    AuditActionUpdateMetadata = "update_metadata"
+    AuditActionDelete = "delete"
+    AuditActionRestore = "restore"
+    AuditActionRevert = "revert"
+)
+
+// struct used to identify who made a change to a business. API keys
+// are never stored; only a fingerprint of the key is recorded. the key is
+// the only authenticated part of the actor; the claimed user is whatever
+// the client sent and must not be trusted as the identity of the caller
+type Actor struct {
+    ApiKey      string `json:"api_key,omitempty"`
+    ClaimedUser string `json:"claimed_user,omitempty"`
+}
+
+// struct used to store the editable state of a business at a version
+type BusinessSnapshot struct {
+    BusinessName string                 `json:"business_name"`
+    BusinessURI  string                 `json:"business_uri"`
+    Metadata     map[string]interface{} `json:"metadata"`
+}
+
+// struct used to store a single change made to a business. the patch
+// is the JSON patch that transforms the snapshot before the change into
+// the snapshot after the change. versions are numbered from 1 for each
+// business, with the creation of the business as the first version
+type AuditEntry struct {
+    BusinessId      uuid.UUID                `json:"business_id"`
+    Version         int                      `json:"version"`
+    Action          string                   `json:"action"`
+    Actor           Actor                    `json:"actor"`
+    ChangedAt       time.Time                `json:"changed_at"`
+    Before          *BusinessSnapshot        `json:"before"`
+    After           BusinessSnapshot         `json:"after"`
+    Patch           []map[string]interface{} `json:"patch"`
+    RevertedVersion *int                     `json:"reverted_version,omitempty"`
+}
+
+// function used to identify the actor of a request from its headers
+func RequestActor(ctx *gin.Context) Actor {
+    actor := Actor{ClaimedUser: ctx.GetHeader(ClaimedUserHeader)}
+    if key := ctx.GetHeader(ApiKeyHeader); len(key) > 0 {
+        actor.ApiKey = KeyFingerprint(key)
+    }
+    return actor
+}
+
+// function used to generate a fingerprint of an API key that can be
+// stored and compared against known keys without storing the key
+func KeyFingerprint(key string) string {
+    hash := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(hash[:])[:12]
+}
+
+// function used to generate a snapshot of a business. missing metadata
+// is stored as an empty object to match the default of the database
+func NewBusinessSnapshot(name, uri string, metadata map[string]interface{}) BusinessSnapshot {
+    if metadata == nil {
+        metadata = map[string]interface{}{}
+    }
+    return BusinessSnapshot{BusinessName: name, BusinessURI: uri, Metadata: metadata}
+}
+
+// function used to generate a new audit entry. the version of the entry
+// is set by the repository when the entry is stored
+func NewAuditEntry(businessId uuid.UUID, action string, actor Actor, before *BusinessSnapshot,
+    after BusinessSnapshot, patch []map[string]interface{}) AuditEntry {
+    if patch == nil {
+        patch = DiffSnapshots(before, after)
+    }
+    return AuditEntry{
+        BusinessId: businessId,
+        Action: action,
+        Actor: actor,
+        ChangedAt: time.Now(),
+        Before: before,
+        After: after,
+        Patch: patch,
+    }
+}
+
+// function used to generate a JSON patch that transforms one snapshot
+// into another. changed fields are replaced as a whole, and all fields
+// are added to an empty object if there is no previous snapshot
+func DiffSnapshots(before *BusinessSnapshot, after BusinessSnapshot) []map[string]interface{} {
+    if before == nil {
+        return []map[string]interface{}{
+            map[string]interface{}{"op": "add", "path": "/business_name", "value": after.BusinessName},
+            map[string]interface{}{"op": "add", "path": "/business_uri", "value": after.BusinessURI},
+            map[string]interface{}{"op": "add", "path": "/metadata", "value": after.Metadata},
+        }
+    }
+    patch := []map[string]interface{}{}
+    if before.BusinessName != after.BusinessName {
+        patch = append(patch, map[string]interface{}{
+            "op": "replace", "path": "/business_name", "value": after.BusinessName})
+    }
+    if before.BusinessURI != after.BusinessURI {
+        patch = append(patch, map[string]interface{}{
+            "op": "replace", "path": "/business_uri", "value": after.BusinessURI})
+    }
+    if !equalJSON(before.Metadata, after.Metadata) {
+        patch = append(patch, map[string]interface{}{
+            "op": "replace", "path": "/metadata", "value": after.Metadata})
+    }
+    return patch
+}
+
+// function used to convert a JSON patch applied to business metadata
+// into a JSON patch applied to a business snapshot
+func MetadataPatch(operation []map[string]interface{}) []map[string]interface{} {
+    patch := []map[string]interface{}{}
+    for _, op := range(operation) {
+        prefixed := map[string]interface{}{}
+        for key, value := range(op) {
+            if path, ok := value.(string); ok && (key == "path" || key == "from") {
+                value = "/metadata" + path
+            }
+            prefixed[key] = value
+        }
+        patch = append(patch, prefixed)
+    }
+    return patch
+}
+
+// function used to select the snapshot of a business at a given version
+// from its audit log
+func SnapshotAtVersion(history []AuditEntry, version int) (BusinessSnapshot, error) {
+    for _, entry := range(history) {
+        if entry.Version == version {
+            return entry.After, nil
+        }
+    }
+    return BusinessSnapshot{}, ErrVersionNotFound
+}
+
+// function used to compare two JSON objects. objects are compared as
+// JSON so that numbers decoded from different sources match
+func equalJSON(a, b map[string]interface{}) bool {
+    left, err := json.Marshal(a)
+    if err != nil {
+        return false
+    }
+    right, err := json.Marshal(b)
+    if err != nil {
+        return false
+    }
+    return bytes.Equal(left, right)
+}
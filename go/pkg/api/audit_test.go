@@ -0,0 +1,137 @@
+package api
+
+import (
+    "testing"
+    "encoding/json"
+    "net/http/httptest"
+
+    "github.com/gin-gonic/gin"
+)
+
+// function used to encode a JSON patch so that patches can be compared
+func encodePatch(t *testing.T, patch []map[string]interface{}) string {
+    encoded, err := json.Marshal(patch)
+    if err != nil {
+        t.Fatalf("unable to encode patch: %+v", err)
+    }
+    return string(encoded)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+    before := NewBusinessSnapshot("Dairy", "https://dairy.example", map[string]interface{}{"rating": 4})
+
+    tests := []struct {
+        name     string
+        before   *BusinessSnapshot
+        after    BusinessSnapshot
+        expected string
+    }{
+        {"created", nil, NewBusinessSnapshot("Dairy", "https://dairy.example", nil),
+            `[{"op":"add","path":"/business_name","value":"Dairy"},` +
+            `{"op":"add","path":"/business_uri","value":"https://dairy.example"},` +
+            `{"op":"add","path":"/metadata","value":{}}]`},
+        {"unchanged", &before, before, `[]`},
+        {"equal numbers", &before, NewBusinessSnapshot("Dairy", "https://dairy.example",
+            map[string]interface{}{"rating": 4.0}), `[]`},
+        {"renamed", &before, NewBusinessSnapshot("Dairy Farm", "https://dairy.example", before.Metadata),
+            `[{"op":"replace","path":"/business_name","value":"Dairy Farm"}]`},
+        {"all fields changed", &before, NewBusinessSnapshot("Dairy Farm", "https://farm.example",
+            map[string]interface{}{"rating": 5}),
+            `[{"op":"replace","path":"/business_name","value":"Dairy Farm"},` +
+            `{"op":"replace","path":"/business_uri","value":"https://farm.example"},` +
+            `{"op":"replace","path":"/metadata","value":{"rating":5}}]`},
+    }
+    for _, test := range(tests) {
+        if patch := encodePatch(t, DiffSnapshots(test.before, test.after)); patch != test.expected {
+            t.Errorf("%s: expected %s, got %s", test.name, test.expected, patch)
+        }
+    }
+}
+
+func TestMetadataPatch(t *testing.T) {
+    tests := []struct {
+        operation []map[string]interface{}
+        expected  string
+    }{
+        {[]map[string]interface{}{}, `[]`},
+        {[]map[string]interface{}{{"op": "add", "path": "/rating", "value": "/5"}},
+            `[{"op":"add","path":"/metadata/rating","value":"/5"}]`},
+        {[]map[string]interface{}{{"op": "move", "from": "/old", "path": "/new"}, {"op": "remove", "path": ""}},
+            `[{"from":"/metadata/old","op":"move","path":"/metadata/new"},{"op":"remove","path":"/metadata"}]`},
+    }
+    for _, test := range(tests) {
+        if patch := encodePatch(t, MetadataPatch(test.operation)); patch != test.expected {
+            t.Errorf("%v: expected %s, got %s", test.operation, test.expected, patch)
+        }
+    }
+}
+
+func TestSnapshotAtVersion(t *testing.T) {
+    history := []AuditEntry{
+        {Version: 3, After: NewBusinessSnapshot("Dairy Farm", "", nil)},
+        {Version: 2, After: NewBusinessSnapshot("Dairy", "https://dairy.example", nil)},
+        {Version: 1, After: NewBusinessSnapshot("Dairy", "", nil)},
+    }
+
+    tests := []struct {
+        history  []AuditEntry
+        version  int
+        expected string
+        err      error
+    }{
+        {history, 1, "Dairy", nil},
+        {history, 3, "Dairy Farm", nil},
+        {history, 4, "", ErrVersionNotFound},
+        {history, 0, "", ErrVersionNotFound},
+        {[]AuditEntry{}, 1, "", ErrVersionNotFound},
+    }
+    for _, test := range(tests) {
+        snapshot, err := SnapshotAtVersion(test.history, test.version)
+        if err != test.err || snapshot.BusinessName != test.expected {
+            t.Errorf("version %d: expected %q (%v), got %q (%v)", test.version, test.expected, test.err,
+                snapshot.BusinessName, err)
+        }
+    }
+}
+
+func TestRequestActor(t *testing.T) {
+    tests := []struct {
+        key      string
+        user     string
+        expected Actor
+    }{
+        {"", "", Actor{}},
+        {"", "alice", Actor{ClaimedUser: "alice"}},
+        {"secret", "", Actor{ApiKey: KeyFingerprint("secret")}},
+        {"secret", "alice", Actor{ApiKey: KeyFingerprint("secret"), ClaimedUser: "alice"}},
+    }
+    for _, test := range(tests) {
+        ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+        ctx.Request = httptest.NewRequest("PATCH", "/businesses", nil)
+        if len(test.key) > 0 {
+            ctx.Request.Header.Set(ApiKeyHeader, test.key)
+        }
+        if len(test.user) > 0 {
+            ctx.Request.Header.Set(ClaimedUserHeader, test.user)
+        }
+        if actor := RequestActor(ctx); actor != test.expected {
+            t.Errorf("key %q and user %q: expected %+v, got %+v", test.key, test.user, test.expected, actor)
+        }
+    }
+}
+
+func TestKeyFingerprint(t *testing.T) {
+    tests := []struct {
+        key      string
+        expected string
+    }{
+        // first 12 hex characters of the SHA-256 hash of the key
+        {"secret", "2bb80d537b1d"},
+        {"", "e3b0c44298fc"},
+    }
+    for _, test := range(tests) {
+        if fingerprint := KeyFingerprint(test.key); fingerprint != test.expected {
+            t.Errorf("%q: expected %s, got %s", test.key, test.expected, fingerprint)
+        }
+    }
+}
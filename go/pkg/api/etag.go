@@ -0,0 +1,78 @@
+package api
+
+import (
+    "fmt"
+    "errors"
+    "strconv"
+    "strings"
+)
+
+var (
+    // define custom errors
+    ErrVersionConflict = errors.New("Business has been modified")
+    ErrInvalidPrecondition = errors.New("Invalid If-Match header")
+
+    // define number of times a JSON patch is applied to the latest
+    // version of a business when modified by a concurrent request
+    MaxPatchAttempts = 3
+)
+
+// versions of a business that a change is conditional on, parsed from
+// the If-Match header of a request. changes are applied unconditionally
+// if no versions are given
+type Precondition []int
+
+// function used to generate the entity tag of a version of a business
+func BusinessETag(version int) string {
+    return fmt.Sprintf(`"%d"`, version)
+}
+
+// function used to parse the versions of an If-Match header. entity tags
+// are the quoted versions of a business i.e. '"3"' or '"3", "4"', and
+// the wildcard '*' matches any version
+func ParseIfMatch(header string) (Precondition, error) {
+    header = strings.TrimSpace(header)
+    if len(header) == 0 || header == "*" {
+        return nil, nil
+    }
+    precondition := Precondition{}
+    for _, tag := range(strings.Split(header, ",")) {
+        tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+        if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+            return nil, ErrInvalidPrecondition
+        }
+        version, err := strconv.Atoi(tag[1:len(tag) - 1])
+        if err != nil || version < 1 {
+            return nil, ErrInvalidPrecondition
+        }
+        precondition = append(precondition, version)
+    }
+    return precondition, nil
+}
+
+// function used to check if the current version of a business satisfies
+// the precondition of a change
+func(precondition Precondition) Matches(version int) bool {
+    if len(precondition) == 0 {
+        return true
+    }
+    for _, expected := range(precondition) {
+        if expected == version {
+            return true
+        }
+    }
+    return false
+}
+
+// function used to check if an If-None-Match header matches the current
+// version of a business
+func NoneMatch(header string, version int) bool {
+    if strings.TrimSpace(header) == "*" {
+        return false
+    }
+    precondition, err := ParseIfMatch(header)
+    if err != nil || len(precondition) == 0 {
+        return true
+    }
+    return !precondition.Matches(version)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+    "testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+    tests := []struct {
+        header   string
+        expected Precondition
+        err      error
+    }{
+        {"", nil, nil},
+        {"*", nil, nil},
+        {`"3"`, Precondition{3}, nil},
+        {` "3" , "4" `, Precondition{3, 4}, nil},
+        {`W/"5"`, Precondition{5}, nil},
+        {`3`, nil, ErrInvalidPrecondition},
+        {`"3`, nil, ErrInvalidPrecondition},
+        {`"0"`, nil, ErrInvalidPrecondition},
+        {`"-1"`, nil, ErrInvalidPrecondition},
+        {`"abc"`, nil, ErrInvalidPrecondition},
+        {`"3", `, nil, ErrInvalidPrecondition},
+    }
+    for _, test := range(tests) {
+        precondition, err := ParseIfMatch(test.header)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.header, test.err, err)
+            continue
+        }
+        if len(precondition) != len(test.expected) {
+            t.Errorf("%q: expected %v, got %v", test.header, test.expected, precondition)
+            continue
+        }
+        for i := range(precondition) {
+            if precondition[i] != test.expected[i] {
+                t.Errorf("%q: expected %v, got %v", test.header, test.expected, precondition)
+            }
+        }
+    }
+}
+
+func TestPreconditionMatches(t *testing.T) {
+    tests := []struct {
+        precondition Precondition
+        version      int
+        expected     bool
+    }{
+        {nil, 1, true},
+        {Precondition{}, 7, true},
+        {Precondition{2}, 2, true},
+        {Precondition{2}, 3, false},
+        {Precondition{1, 3}, 3, true},
+        {Precondition{1, 3}, 2, false},
+    }
+    for _, test := range(tests) {
+        if result := test.precondition.Matches(test.version); result != test.expected {
+            t.Errorf("%v matching version %d: expected %v, got %v", test.precondition,
+                test.version, test.expected, result)
+        }
+    }
+}
+
+func TestNoneMatch(t *testing.T) {
+    tests := []struct {
+        header   string
+        version  int
+        expected bool
+    }{
+        {"", 1, true},
+        {"*", 1, false},
+        {BusinessETag(4), 4, false},
+        {BusinessETag(4), 5, true},
+        {`"1", "5"`, 5, false},
+        {"invalid", 1, true},
+    }
+    for _, test := range(tests) {
+        if result := NoneMatch(test.header, test.version); result != test.expected {
+            t.Errorf("%q with version %d: expected %v, got %v", test.header, test.version,
+                test.expected, result)
+        }
+    }
+}
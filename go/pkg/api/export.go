@@ -0,0 +1,445 @@
+package api
+
+import (
+    "io"
+    "fmt"
+    "time"
+    "errors"
+    "strconv"
+    "strings"
+    "net/http"
+    "encoding/csv"
+    "encoding/json"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+var (
+    // define custom errors
+    ErrInvalidExportFormat = errors.New("Invalid export format")
+    ErrInvalidExportData = errors.New("Invalid export data")
+
+    // define number of businesses retrieved per page while exporting
+    ExportPageSize = 500
+
+    // define columns of CSV exports. columns prefixed with 'source_' are
+    // only set when exporting the data of each source
+    exportColumns = []string{"business_id", "business_name", "business_uri", "added",
+        "last_update", "website_live", "business_open", "business_phones", "latitude",
+        "longitude", "metadata"}
+    exportSourceColumns = []string{"source", "source_website_live", "source_business_open",
+        "source_business_phones", "source_collected_at"}
+)
+
+const (
+    // define formats of exports
+    ExportFormatCSV = "csv"
+    ExportFormatJSONL = "jsonl"
+    ExportFormatGeoJSON = "geojson"
+
+    // define data included in exports. reconciled exports contain the
+    // values of golden records, while source exports also contain the
+    // latest data reported by each source
+    ExportDataReconciled = "reconciled"
+    ExportDataSources = "sources"
+)
+
+// struct used to store the settings used to export businesses. the
+// filters and sort order are the same as when listing businesses
+type ExportQuery struct {
+    Format  string
+    Data    string
+    Filters BusinessQuery
+}
+
+// struct used to store a single exported business. current values are
+// taken from the golden record of the business and are empty if no
+// golden record has been computed
+type ExportRecord struct {
+    BusinessId     uuid.UUID                 `json:"business_id"`
+    BusinessName   string                    `json:"business_name"`
+    BusinessURI    string                    `json:"business_uri"`
+    Added          time.Time                 `json:"added"`
+    LastUpdate     time.Time                 `json:"last_update"`
+    WebsiteLive    *bool                     `json:"website_live"`
+    BusinessOpen   *bool                     `json:"business_open"`
+    BusinessPhones []string                  `json:"business_phones"`
+    Latitude       *float64                  `json:"latitude"`
+    Longitude      *float64                  `json:"longitude"`
+    Metadata       map[string]interface{}    `json:"metadata"`
+    Sources        []connectors.BusinessData `json:"sources,omitempty"`
+}
+
+// function used to parse the settings used to export businesses from
+// query parameters. the limit and cursor used to list businesses are
+// ignored since exports always contain all matching businesses
+func ParseExportQuery(values neturl.Values) (ExportQuery, error) {
+    query := ExportQuery{Format: ExportFormatCSV, Data: ExportDataReconciled}
+    if format := values.Get("format"); len(format) > 0 {
+        switch format = strings.ToLower(format); format {
+        case ExportFormatCSV, ExportFormatJSONL, ExportFormatGeoJSON:
+            query.Format = format
+        default:
+            return query, ErrInvalidExportFormat
+        }
+    }
+    if data := values.Get("data"); len(data) > 0 {
+        switch data = strings.ToLower(data); data {
+        case ExportDataReconciled, ExportDataSources:
+            query.Data = data
+        default:
+            return query, ErrInvalidExportData
+        }
+    }
+
+    filters := neturl.Values{}
+    for key, value := range(values) {
+        if key != "limit" && key != "cursor" {
+            filters[key] = value
+        }
+    }
+    businessQuery, err := ParseBusinessQuery(filters)
+    if err != nil {
+        return query, err
+    }
+    businessQuery.Limit = ExportPageSize
+    query.Filters = businessQuery
+    return query, nil
+}
+
+// function used to retrieve the content type of an export format
+func ExportContentType(format string) string {
+    switch format {
+    case ExportFormatJSONL:
+        return "application/x-ndjson"
+    case ExportFormatGeoJSON:
+        return "application/geo+json"
+    }
+    return "text/csv"
+}
+
+// function used to parse a coordinate stored in metadata. coordinates
+// can be stored as numbers or numeric strings
+func parseCoordinate(value interface{}, limit float64) (float64, bool) {
+    var coordinate float64
+    switch typed := value.(type) {
+    case float64:
+        coordinate = typed
+    case string:
+        parsed, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+        if err != nil {
+            return 0, false
+        }
+        coordinate = parsed
+    default:
+        return 0, false
+    }
+    if coordinate < -limit || coordinate > limit {
+        return 0, false
+    }
+    return coordinate, true
+}
+
+// function used to retrieve the coordinates of a business from metadata.
+// coordinates are stored under the 'latitude' and 'longitude' keys, or
+// under a 'coordinates' object as returned by the yelp API
+func BusinessCoordinates(meta map[string]interface{}) (float64, float64, bool) {
+    source := meta
+    if coordinates, ok := meta["coordinates"].(map[string]interface{}); ok {
+        source = coordinates
+    }
+    latitude, ok := parseCoordinate(source["latitude"], 90)
+    if !ok {
+        return 0, 0, false
+    }
+    longitude, ok := parseCoordinate(source["longitude"], 180)
+    if !ok {
+        return 0, 0, false
+    }
+    return latitude, longitude, true
+}
+
+// function used to retrieve the phones of a business from its golden record
+func goldenRecordPhones(info BusinessInfo) []string {
+    phones := []string{}
+    if info.GoldenRecord == nil {
+        return phones
+    }
+    switch typed := info.GoldenRecord.Fields["business_phones"].Value.(type) {
+    case []string:
+        phones = append(phones, typed...)
+    case []interface{}:
+        for _, value := range(typed) {
+            if phone, ok := value.(string); ok {
+                phones = append(phones, phone)
+            }
+        }
+    }
+    return phones
+}
+
+// function used to generate an exported business from a business and the
+// latest data of its sources
+func NewExportRecord(info BusinessInfo, sources []connectors.BusinessData) ExportRecord {
+    record := ExportRecord{
+        BusinessId: info.BusinessId,
+        BusinessName: info.BusinessName,
+        BusinessURI: info.BusinessURI,
+        Added: info.Added,
+        LastUpdate: info.LastUpdate,
+        BusinessPhones: goldenRecordPhones(info),
+        Metadata: info.Metadata,
+        Sources: sources,
+    }
+    if record.Metadata == nil {
+        record.Metadata = map[string]interface{}{}
+    }
+    if live, ok := goldenRecordFlag(info, "website_live"); ok {
+        record.WebsiteLive = &live
+    }
+    if open, ok := goldenRecordFlag(info, "business_open"); ok {
+        record.BusinessOpen = &open
+    }
+    if latitude, longitude, ok := BusinessCoordinates(record.Metadata); ok {
+        record.Latitude, record.Longitude = &latitude, &longitude
+    }
+    return record
+}
+
+// interface used to write exported businesses in a given format. records
+// are written as they are retrieved so that exports are streamed
+type exportWriter interface {
+    // function used to write a single record. false is returned if the
+    // record cannot be represented in the format of the export
+    Write(record ExportRecord) (bool, error)
+    Flush() error
+    Close() error
+}
+
+// function used to generate a writer for the format of an export
+func newExportWriter(query ExportQuery, w io.Writer) exportWriter {
+    switch query.Format {
+    case ExportFormatJSONL:
+        return &jsonlExportWriter{encoder: json.NewEncoder(w)}
+    case ExportFormatGeoJSON:
+        return &geoJSONExportWriter{w: w}
+    }
+    return &csvExportWriter{writer: csv.NewWriter(w), sources: query.Data == ExportDataSources}
+}
+
+// writer used to export businesses as CSV. metadata is encoded as JSON and
+// phones are separated by semicolons. when exporting the data of each source
+// a row is written for each source of a business
+type csvExportWriter struct {
+    writer  *csv.Writer
+    sources bool
+    started bool
+}
+
+// function used to format an optional value as a CSV field
+func csvField(value interface{}) string {
+    switch typed := value.(type) {
+    case *bool:
+        if typed != nil {
+            return strconv.FormatBool(*typed)
+        }
+    case *float64:
+        if typed != nil {
+            return strconv.FormatFloat(*typed, 'f', -1, 64)
+        }
+    }
+    return ""
+}
+
+func(w *csvExportWriter) Write(record ExportRecord) (bool, error) {
+    if !w.started {
+        w.started = true
+        header := exportColumns
+        if w.sources {
+            header = append(append([]string{}, exportColumns...), exportSourceColumns...)
+        }
+        if err := w.writer.Write(header); err != nil {
+            return false, err
+        }
+    }
+
+    metadata, err := json.Marshal(record.Metadata)
+    if err != nil {
+        return false, err
+    }
+    row := []string{record.BusinessId.String(), record.BusinessName, record.BusinessURI,
+        record.Added.Format(time.RFC3339), record.LastUpdate.Format(time.RFC3339),
+        csvField(record.WebsiteLive), csvField(record.BusinessOpen),
+        strings.Join(record.BusinessPhones, ";"), csvField(record.Latitude),
+        csvField(record.Longitude), string(metadata)}
+    if !w.sources {
+        return true, w.writer.Write(row)
+    }
+    if len(record.Sources) == 0 {
+        return true, w.writer.Write(append(row, make([]string, len(exportSourceColumns))...))
+    }
+    for _, data := range(record.Sources) {
+        sourceRow := append(append([]string{}, row...), data.Source,
+            strconv.FormatBool(data.WebsiteLive), strconv.FormatBool(data.BusinessOpen),
+            strings.Join(data.BusinessPhones, ";"), data.CollectedAt.Format(time.RFC3339))
+        if err := w.writer.Write(sourceRow); err != nil {
+            return false, err
+        }
+    }
+    return true, nil
+}
+
+func(w *csvExportWriter) Flush() error {
+    w.writer.Flush()
+    return w.writer.Error()
+}
+
+// function used to close a CSV export. the header is written even if
+// no businesses are exported
+func(w *csvExportWriter) Close() error {
+    if !w.started {
+        header := exportColumns
+        if w.sources {
+            header = append(append([]string{}, exportColumns...), exportSourceColumns...)
+        }
+        if err := w.writer.Write(header); err != nil {
+            return err
+        }
+    }
+    return w.Flush()
+}
+
+// writer used to export businesses as JSON objects separated by newlines
+type jsonlExportWriter struct {
+    encoder *json.Encoder
+}
+
+func(w *jsonlExportWriter) Write(record ExportRecord) (bool, error) {
+    return true, w.encoder.Encode(record)
+}
+
+func(w *jsonlExportWriter) Flush() error { return nil }
+
+func(w *jsonlExportWriter) Close() error { return nil }
+
+// writer used to export businesses as a GeoJSON feature collection.
+// businesses without known coordinates are skipped
+type geoJSONExportWriter struct {
+    w       io.Writer
+    started bool
+}
+
+// struct used to store a GeoJSON point feature
+type geoJSONFeature struct {
+    Type       string       `json:"type"`
+    Id         uuid.UUID    `json:"id"`
+    Geometry   interface{}  `json:"geometry"`
+    Properties ExportRecord `json:"properties"`
+}
+
+// function used to write the start of the feature collection
+func(w *geoJSONExportWriter) start() error {
+    if w.started {
+        return nil
+    }
+    w.started = true
+    _, err := io.WriteString(w.w, `{"type":"FeatureCollection","features":[`)
+    return err
+}
+
+func(w *geoJSONExportWriter) Write(record ExportRecord) (bool, error) {
+    if record.Latitude == nil || record.Longitude == nil {
+        return false, nil
+    }
+    // features are separated by commas after the first feature
+    separator := ",\n"
+    if !w.started {
+        separator = "\n"
+        if err := w.start(); err != nil {
+            return false, err
+        }
+    }
+    feature, err := json.Marshal(geoJSONFeature{
+        Type: "Feature",
+        Id: record.BusinessId,
+        Geometry: map[string]interface{}{
+            "type": "Point",
+            // GeoJSON positions are given as longitude, latitude
+            "coordinates": []float64{*record.Longitude, *record.Latitude},
+        },
+        Properties: record,
+    })
+    if err != nil {
+        return false, err
+    }
+    _, err = io.WriteString(w.w, separator + string(feature))
+    return err == nil, err
+}
+
+func(w *geoJSONExportWriter) Flush() error { return nil }
+
+func(w *geoJSONExportWriter) Close() error {
+    if err := w.start(); err != nil {
+        return err
+    }
+    _, err := io.WriteString(w.w, "\n]}\n")
+    return err
+}
+
+// function used to export all businesses matching the filters of a query.
+// businesses are retrieved a page at a time and written as each page is
+// retrieved, so exports of the whole directory are never held in memory.
+// the number of exported businesses is returned
+func ExportBusinesses(db Repository, query ExportQuery, w io.Writer) (int, error) {
+    writer := newExportWriter(query, w)
+    filters := query.Filters
+    count := 0
+    for {
+        page, err := db.ListBusinesses(filters)
+        if err != nil {
+            return count, err
+        }
+
+        sources := map[uuid.UUID][]connectors.BusinessData{}
+        if query.Data == ExportDataSources && len(page.Businesses) > 0 {
+            businessIds := []uuid.UUID{}
+            for _, info := range(page.Businesses) {
+                businessIds = append(businessIds, info.BusinessId)
+            }
+            if sources, err = db.GetLatestSourceData(businessIds); err != nil {
+                return count, err
+            }
+        }
+
+        for _, info := range(page.Businesses) {
+            written, err := writer.Write(NewExportRecord(info, sources[info.BusinessId]))
+            if err != nil {
+                return count, err
+            }
+            if written {
+                count++
+            }
+        }
+        if err := writer.Flush(); err != nil {
+            return count, err
+        }
+        // flush each page to clients when streaming over HTTP
+        if flusher, ok := w.(http.Flusher); ok {
+            flusher.Flush()
+        }
+
+        if len(page.NextCursor) == 0 {
+            return count, writer.Close()
+        }
+        cursor := NewBusinessCursor(filters, page.Businesses[len(page.Businesses) - 1])
+        filters.Cursor = &cursor
+    }
+}
+
+// function used to generate the file name of an export
+func ExportFileName(format string, now time.Time) string {
+    return fmt.Sprintf("businesses-%s.%s", now.Format("2006-01-02"), format)
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+    "time"
+    "bytes"
+    "strings"
+    "testing"
+    "encoding/json"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
+)
+
+// function used to generate the businesses written by export tests. the
+// first business has a golden record and coordinates, the second has neither
+func testExportRecords() []ExportRecord {
+    added := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+    dairy := BusinessInfo{
+        BusinessId: uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+        BusinessName: "Dairy, Inc",
+        BusinessURI: "https://dairy.example",
+        Added: added,
+        LastUpdate: added.Add(time.Hour),
+        Metadata: map[string]interface{}{"latitude": "30.25", "longitude": -97.75},
+        GoldenRecord: &reconciler.GoldenRecord{Fields: map[string]reconciler.FieldValue{
+            "website_live": {Value: true},
+            "business_phones": {Value: []interface{}{"+15125550100", "+15125550101"}},
+        }},
+    }
+    bakery := BusinessInfo{
+        BusinessId: uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+        BusinessName: "Bakery",
+        Added: added,
+        LastUpdate: added,
+    }
+    sources := []connectors.BusinessData{{Source: "web-scraper", WebsiteLive: true,
+        BusinessPhones: []string{"+15125550100"}, CollectedAt: added.Add(2 * time.Hour)}}
+    return []ExportRecord{NewExportRecord(dairy, sources), NewExportRecord(bakery, nil)}
+}
+
+// function used to write records with the writer of an export format
+func writeExport(t *testing.T, query ExportQuery, records []ExportRecord) (string, int) {
+    var buffer bytes.Buffer
+    writer := newExportWriter(query, &buffer)
+    count := 0
+    for _, record := range(records) {
+        written, err := writer.Write(record)
+        if err != nil {
+            t.Fatalf("unable to write record: %+v", err)
+        }
+        if written {
+            count++
+        }
+    }
+    if err := writer.Close(); err != nil {
+        t.Fatalf("unable to close export: %+v", err)
+    }
+    return buffer.String(), count
+}
+
+func TestParseExportQuery(t *testing.T) {
+    tests := []struct {
+        query  string
+        format string
+        data   string
+        err    error
+    }{
+        {"", ExportFormatCSV, ExportDataReconciled, nil},
+        {"format=GeoJSON&data=Sources", ExportFormatGeoJSON, ExportDataSources, nil},
+        {"format=jsonl&limit=ten&cursor=invalid", ExportFormatJSONL, ExportDataReconciled, nil},
+        {"format=xml", "", "", ErrInvalidExportFormat},
+        {"data=all", "", "", ErrInvalidExportData},
+        {"sort=rating", "", "", ErrInvalidSort},
+    }
+    for _, test := range(tests) {
+        values, err := neturl.ParseQuery(test.query)
+        if err != nil {
+            t.Fatalf("unable to parse query %q: %+v", test.query, err)
+        }
+        query, err := ParseExportQuery(values)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.query, test.err, err)
+            continue
+        }
+        if err != nil {
+            continue
+        }
+        if query.Format != test.format || query.Data != test.data || query.Filters.Limit != ExportPageSize ||
+            query.Filters.Cursor != nil {
+            t.Errorf("%q: expected %s export of %s data, got %+v", test.query, test.format, test.data, query)
+        }
+    }
+}
+
+func TestBusinessCoordinates(t *testing.T) {
+    tests := []struct {
+        meta      map[string]interface{}
+        latitude  float64
+        longitude float64
+        ok        bool
+    }{
+        {map[string]interface{}{"latitude": 30.25, "longitude": -97.75}, 30.25, -97.75, true},
+        {map[string]interface{}{"latitude": " 30.25 ", "longitude": "-97.75"}, 30.25, -97.75, true},
+        {map[string]interface{}{"coordinates": map[string]interface{}{"latitude": 30.25, "longitude": -97.75},
+            "latitude": 0.0, "longitude": 0.0}, 30.25, -97.75, true},
+        {map[string]interface{}{"latitude": 91.0, "longitude": -97.75}, 0, 0, false},
+        {map[string]interface{}{"latitude": 30.25, "longitude": 181.0}, 0, 0, false},
+        {map[string]interface{}{"latitude": "north", "longitude": -97.75}, 0, 0, false},
+        {map[string]interface{}{"latitude": 30.25}, 0, 0, false},
+        {map[string]interface{}{}, 0, 0, false},
+    }
+    for _, test := range(tests) {
+        latitude, longitude, ok := BusinessCoordinates(test.meta)
+        if ok != test.ok || latitude != test.latitude || longitude != test.longitude {
+            t.Errorf("%v: expected %v, %v (%v), got %v, %v (%v)", test.meta, test.latitude, test.longitude,
+                test.ok, latitude, longitude, ok)
+        }
+    }
+}
+
+func TestCSVExport(t *testing.T) {
+    header := "business_id,business_name,business_uri,added,last_update,website_live,business_open," +
+        "business_phones,latitude,longitude,metadata"
+    sourceHeader := header + ",source,source_website_live,source_business_open,source_business_phones," +
+        "source_collected_at"
+    dairy := `00000000-0000-0000-0000-000000000001,"Dairy, Inc",https://dairy.example,2021-03-01T00:00:00Z,` +
+        `2021-03-01T01:00:00Z,true,,+15125550100;+15125550101,30.25,-97.75,` +
+        `"{""latitude"":""30.25"",""longitude"":-97.75}"`
+    bakery := "00000000-0000-0000-0000-000000000002,Bakery,,2021-03-01T00:00:00Z,2021-03-01T00:00:00Z,,,,,,{}"
+
+    tests := []struct {
+        name     string
+        data     string
+        records  []ExportRecord
+        expected []string
+    }{
+        {"reconciled data", ExportDataReconciled, testExportRecords(), []string{header, dairy, bakery}},
+        {"source data", ExportDataSources, testExportRecords(), []string{sourceHeader,
+            dairy + ",web-scraper,true,false,+15125550100,2021-03-01T02:00:00Z", bakery + ",,,,,"}},
+        {"no businesses", ExportDataReconciled, []ExportRecord{}, []string{header}},
+        {"no businesses with source data", ExportDataSources, []ExportRecord{}, []string{sourceHeader}},
+    }
+    for _, test := range(tests) {
+        output, count := writeExport(t, ExportQuery{Format: ExportFormatCSV, Data: test.data}, test.records)
+        expected := strings.Join(test.expected, "\n") + "\n"
+        if output != expected || count != len(test.records) {
+            t.Errorf("%s: expected %d records\n%s\ngot %d records\n%s", test.name, len(test.records), expected,
+                count, output)
+        }
+    }
+}
+
+func TestJSONLExport(t *testing.T) {
+    output, count := writeExport(t, ExportQuery{Format: ExportFormatJSONL, Data: ExportDataSources},
+        testExportRecords())
+    lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+    if count != 2 || len(lines) != 2 {
+        t.Fatalf("expected 2 records on 2 lines, got %d records\n%s", count, output)
+    }
+
+    tests := []struct {
+        line    string
+        name    string
+        live    string
+        sources int
+    }{
+        {lines[0], "Dairy, Inc", "true", 1},
+        {lines[1], "Bakery", "null", 0},
+    }
+    for _, test := range(tests) {
+        var record map[string]json.RawMessage
+        if err := json.Unmarshal([]byte(test.line), &record); err != nil {
+            t.Fatalf("unable to decode line %q: %+v", test.line, err)
+        }
+        var name string
+        var sources []connectors.BusinessData
+        json.Unmarshal(record["business_name"], &name)
+        json.Unmarshal(record["sources"], &sources)
+        if name != test.name || string(record["website_live"]) != test.live || len(sources) != test.sources {
+            t.Errorf("expected %s (live %s) with %d sources, got %s", test.name, test.live, test.sources,
+                test.line)
+        }
+    }
+}
+
+func TestGeoJSONExport(t *testing.T) {
+    tests := []struct {
+        name     string
+        records  []ExportRecord
+        features int
+    }{
+        {"skips businesses without coordinates", testExportRecords(), 1},
+        {"no businesses", []ExportRecord{}, 0},
+        {"no businesses with coordinates", testExportRecords()[1:], 0},
+    }
+    for _, test := range(tests) {
+        output, count := writeExport(t, ExportQuery{Format: ExportFormatGeoJSON}, test.records)
+        var collection struct {
+            Type     string `json:"type"`
+            Features []struct {
+                Id       uuid.UUID `json:"id"`
+                Geometry struct {
+                    Type        string    `json:"type"`
+                    Coordinates []float64 `json:"coordinates"`
+                } `json:"geometry"`
+            } `json:"features"`
+        }
+        if err := json.Unmarshal([]byte(output), &collection); err != nil {
+            t.Errorf("%s: unable to decode collection %q: %+v", test.name, output, err)
+            continue
+        }
+        if collection.Type != "FeatureCollection" || count != test.features ||
+            len(collection.Features) != test.features {
+            t.Errorf("%s: expected %d features, got %d written\n%s", test.name, test.features, count, output)
+            continue
+        }
+        for _, feature := range(collection.Features) {
+            // positions are given as longitude, latitude
+            coordinates := feature.Geometry.Coordinates
+            if feature.Geometry.Type != "Point" || len(coordinates) != 2 || coordinates[0] != -97.75 ||
+                coordinates[1] != 30.25 {
+                t.Errorf("%s: unexpected geometry %+v", test.name, feature.Geometry)
+            }
+        }
+    }
+}
+
+func TestExportFileName(t *testing.T) {
+    now := time.Date(2021, 3, 4, 23, 0, 0, 0, time.UTC)
+    if name := ExportFileName(ExportFormatGeoJSON, now); name != "businesses-2021-03-04.geojson" {
+        t.Errorf("expected businesses-2021-03-04.geojson, got %s", name)
+    }
+}
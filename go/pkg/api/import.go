@@ -0,0 +1,410 @@
+package api
+
+import (
+    "io"
+    "fmt"
+    "mime"
+    "bufio"
+    "errors"
+    "strings"
+    "encoding/csv"
+    "encoding/json"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+)
+
+var (
+    // define custom errors
+    ErrInvalidImportFormat = errors.New("Invalid import format")
+    ErrInvalidImportMapping = errors.New("Invalid column mapping")
+    ErrTooManyImportRows = errors.New("Too many rows in import")
+
+    // define limits of a single import
+    MaxImportRows = 10000
+    MaxImportBytes int64 = 10 << 20
+
+    // define default column mapping. columns prefixed with 'metadata.'
+    // are stored as metadata keys
+    DefaultImportMapping = ImportMapping{
+        "business_name": "business_name",
+        "business_uri": "business_uri",
+    }
+)
+
+const (
+    // define formats accepted by imports
+    ImportFormatCSV = "csv"
+    ImportFormatJSONL = "jsonl"
+
+    // define status of rows in an import
+    ImportStatusCreated = "created"
+    ImportStatusValid = "valid"
+    ImportStatusDuplicate = "duplicate"
+    ImportStatusInvalid = "invalid"
+)
+
+// mapping of business fields to the columns of an import i.e.
+// {"business_name": "Business Name", "metadata.yelp_business_id": "Yelp Place ID"}
+type ImportMapping map[string]string
+
+// struct used to store a single row of an import along with any errors
+// found while parsing the row. rows are numbered from 1 excluding headers
+type ImportRow struct {
+    Row     int
+    Request NewBusinessRequest
+    Errors  []string
+}
+
+// struct used to store the result of importing a single row
+type ImportResult struct {
+    Row          int        `json:"row"`
+    Status       string     `json:"status"`
+    BusinessName string     `json:"business_name"`
+    BusinessURI  string     `json:"business_uri"`
+    BusinessId   *uuid.UUID `json:"business_id,omitempty"`
+    DuplicateOf  *uuid.UUID `json:"duplicate_of,omitempty"`
+    DuplicateRow int        `json:"duplicate_row,omitempty"`
+    Errors       []string   `json:"errors,omitempty"`
+}
+
+// struct used to store the summary of an import
+type ImportSummary struct {
+    DryRun     bool           `json:"dry_run"`
+    Total      int            `json:"total"`
+    Created    int            `json:"created"`
+    Valid      int            `json:"valid"`
+    Duplicates int            `json:"duplicates"`
+    Invalid    int            `json:"invalid"`
+    Results    []ImportResult `json:"results"`
+}
+
+// function used to parse a column mapping from query parameters of the
+// form 'business_name:Business Name'. the default mapping is used if no
+// mapping is given
+func ParseImportMapping(values []string) (ImportMapping, error) {
+    if len(values) == 0 {
+        return DefaultImportMapping, nil
+    }
+    mapping := ImportMapping{}
+    for _, value := range(values) {
+        parts := strings.SplitN(value, ":", 2)
+        if len(parts) != 2 || len(strings.TrimSpace(parts[1])) == 0 {
+            return mapping, ErrInvalidImportMapping
+        }
+        field := strings.TrimSpace(parts[0])
+        switch {
+        case field == "business_name", field == "business_uri":
+        case strings.HasPrefix(field, "metadata.") && len(field) > len("metadata."):
+        default:
+            return mapping, ErrInvalidImportMapping
+        }
+        mapping[field] = strings.TrimSpace(parts[1])
+    }
+    if len(mapping["business_name"]) == 0 || len(mapping["business_uri"]) == 0 {
+        return mapping, ErrInvalidImportMapping
+    }
+    return mapping, nil
+}
+
+// function used to generate a business from the values of a row using a
+// column mapping. empty metadata values are skipped
+func(mapping ImportMapping) request(values map[string]interface{}) (NewBusinessRequest, []string) {
+    request := NewBusinessRequest{Metadata: map[string]interface{}{}}
+    errs := []string{}
+    for field, column := range(mapping) {
+        value, ok := values[column]
+        if !ok || value == nil || value == "" {
+            continue
+        }
+        switch field {
+        case "business_name", "business_uri":
+            text, ok := value.(string)
+            if !ok {
+                errs = append(errs, fmt.Sprintf("column '%s' must be a string", column))
+                continue
+            }
+            if field == "business_name" {
+                request.BusinessName = strings.TrimSpace(text)
+            } else {
+                request.BusinessURI = strings.TrimSpace(text)
+            }
+        default:
+            request.Metadata[strings.TrimPrefix(field, "metadata.")] = value
+        }
+    }
+    return request, errs
+}
+
+// function used to determine the format of an import from the format query
+// parameter, or from the content type of the request if no format is given
+func ImportFormat(format, contentType string) (string, error) {
+    if len(format) == 0 {
+        mediaType, _, _ := mime.ParseMediaType(contentType)
+        switch mediaType {
+        case "text/csv", "application/csv":
+            return ImportFormatCSV, nil
+        case "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+            return ImportFormatJSONL, nil
+        }
+        return format, ErrInvalidImportFormat
+    }
+    switch format = strings.ToLower(format); format {
+    case ImportFormatCSV, ImportFormatJSONL:
+        return format, nil
+    }
+    return format, ErrInvalidImportFormat
+}
+
+// function used to parse the rows of an import in CSV or JSONL format.
+// CSV imports must start with a header row. columns prefixed with
+// 'metadata.' and the 'metadata' object of JSONL rows are stored
+// as metadata when the default mapping is used
+func ParseImportRows(format string, body io.Reader, mapping ImportMapping) ([]ImportRow, error) {
+    switch format {
+    case ImportFormatCSV:
+        return parseCSVRows(body, mapping)
+    case ImportFormatJSONL:
+        return parseJSONLRows(body, mapping)
+    }
+    return []ImportRow{}, ErrInvalidImportFormat
+}
+
+// function used to determine if a mapping is the default mapping
+func(mapping ImportMapping) isDefault() bool {
+    if len(mapping) != len(DefaultImportMapping) {
+        return false
+    }
+    for field, column := range(DefaultImportMapping) {
+        if mapping[field] != column {
+            return false
+        }
+    }
+    return true
+}
+
+// function used to add the default metadata columns to a mapping
+func(mapping ImportMapping) withDefaultMetadata(columns []string) ImportMapping {
+    if !mapping.isDefault() {
+        return mapping
+    }
+    extended := ImportMapping{}
+    for field, column := range(mapping) {
+        extended[field] = column
+    }
+    for _, column := range(columns) {
+        if strings.HasPrefix(column, "metadata.") {
+            extended[column] = column
+        }
+    }
+    return extended
+}
+
+// function used to parse the rows of a CSV import
+func parseCSVRows(body io.Reader, mapping ImportMapping) ([]ImportRow, error) {
+    rows := []ImportRow{}
+    reader := csv.NewReader(body)
+    reader.FieldsPerRecord = -1
+    headers, err := reader.Read()
+    if err != nil {
+        return rows, ErrInvalidImportFormat
+    }
+    for i := range(headers) {
+        headers[i] = strings.TrimSpace(headers[i])
+    }
+    mapping = mapping.withDefaultMetadata(headers)
+
+    for {
+        record, err := reader.Read()
+        if err == io.EOF {
+            return rows, nil
+        }
+        row := ImportRow{Row: len(rows) + 1}
+        if len(rows) >= MaxImportRows {
+            return rows, ErrTooManyImportRows
+        }
+        if err != nil {
+            // rows can be skipped after parse errors, but not after
+            // errors reading the body of the request
+            if _, ok := err.(*csv.ParseError); !ok {
+                return rows, err
+            }
+            row.Errors = []string{fmt.Sprintf("unable to parse row: %v", err)}
+            rows = append(rows, row)
+            continue
+        }
+        values := map[string]interface{}{}
+        for i, value := range(record) {
+            if i < len(headers) {
+                values[headers[i]] = strings.TrimSpace(value)
+            }
+        }
+        row.Request, row.Errors = mapping.request(values)
+        rows = append(rows, row)
+    }
+}
+
+// function used to parse the rows of a JSONL import. blank lines are skipped
+func parseJSONLRows(body io.Reader, mapping ImportMapping) ([]ImportRow, error) {
+    rows := []ImportRow{}
+    scanner := bufio.NewScanner(body)
+    scanner.Buffer(make([]byte, 64 * 1024), int(MaxImportBytes))
+    defaultMapping := mapping.isDefault()
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if len(line) == 0 {
+            continue
+        }
+        if len(rows) >= MaxImportRows {
+            return rows, ErrTooManyImportRows
+        }
+        row := ImportRow{Row: len(rows) + 1}
+        values := map[string]interface{}{}
+        if err := json.Unmarshal([]byte(line), &values); err != nil {
+            row.Errors = []string{"row must be a JSON object"}
+            rows = append(rows, row)
+            continue
+        }
+        row.Request, row.Errors = mapping.request(values)
+        // include metadata objects of rows when using the default mapping
+        if meta, ok := values["metadata"].(map[string]interface{}); ok && defaultMapping {
+            for key, value := range(meta) {
+                row.Request.Metadata[key] = value
+            }
+        }
+        rows = append(rows, row)
+    }
+    if err := scanner.Err(); err != nil {
+        return rows, err
+    }
+    return rows, nil
+}
+
+// function used to validate the URI of a business. URIs must be absolute
+// HTTP or HTTPS URLs with a domain
+func ValidateBusinessURI(uri string) error {
+    parsed, err := neturl.Parse(uri)
+    if err != nil {
+        return fmt.Errorf("invalid URI: %v", err)
+    }
+    if parsed.Scheme != "http" && parsed.Scheme != "https" {
+        return errors.New("URI must use http or https scheme")
+    }
+    if host := parsed.Hostname(); !strings.Contains(host, ".") || strings.HasSuffix(host, ".") {
+        return errors.New("URI must contain a valid domain")
+    }
+    return nil
+}
+
+// function used to normalise the URI of a business to detect duplicates.
+// the scheme, 'www.' prefix, query and trailing slashes are removed
+func NormaliseURI(uri string) string {
+    parsed, err := neturl.Parse(strings.TrimSpace(uri))
+    if err != nil || len(parsed.Hostname()) == 0 {
+        return strings.ToLower(strings.TrimSpace(uri))
+    }
+    host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+    return host + strings.TrimRight(parsed.EscapedPath(), "/")
+}
+
+// function used to normalise the name of a business to detect duplicates.
+// names are compared as lower-case words ignoring punctuation
+func NormaliseName(name string) string {
+    return strings.Join(searchWords(name), " ")
+}
+
+// function used to collect the domains and normalised names of the rows
+// of an import. existing businesses matching either are the only ones that
+// can be duplicates of the rows
+func ImportCandidateKeys(rows []ImportRow) ([]string, []string) {
+    domains, names := []string{}, []string{}
+    seenDomains, seenNames := map[string]bool{}, map[string]bool{}
+    for _, row := range(rows) {
+        if domain := NormaliseDomain(row.Request.BusinessURI); len(domain) > 0 && !seenDomains[domain] {
+            seenDomains[domain] = true
+            domains = append(domains, domain)
+        }
+        if name := NormaliseName(row.Request.BusinessName); len(name) > 0 && !seenNames[name] {
+            seenNames[name] = true
+            names = append(names, name)
+        }
+    }
+    return domains, names
+}
+
+// function used to validate the rows of an import and detect duplicates.
+// rows are duplicates if their normalised URI or name matches an existing
+// business or an earlier row of the import
+func PlanImport(rows []ImportRow, existing []BusinessInfo) []ImportResult {
+    type match struct {
+        businessId *uuid.UUID
+        row        int
+    }
+    uris, names := map[string]match{}, map[string]match{}
+    for _, info := range(existing) {
+        businessId := info.BusinessId
+        uris[NormaliseURI(info.BusinessURI)] = match{businessId: &businessId}
+        names[NormaliseName(info.BusinessName)] = match{businessId: &businessId}
+    }
+
+    results := []ImportResult{}
+    for _, row := range(rows) {
+        result := ImportResult{
+            Row: row.Row,
+            Status: ImportStatusValid,
+            BusinessName: row.Request.BusinessName,
+            BusinessURI: row.Request.BusinessURI,
+            Errors: row.Errors,
+        }
+        // rows that could not be parsed are not validated further
+        if len(result.Errors) == 0 {
+            if len(result.BusinessName) == 0 {
+                result.Errors = append(result.Errors, "business name is required")
+            }
+            if len(result.BusinessURI) == 0 {
+                result.Errors = append(result.Errors, "business URI is required")
+            } else if err := ValidateBusinessURI(result.BusinessURI); err != nil {
+                result.Errors = append(result.Errors, err.Error())
+            }
+        }
+        if len(result.Errors) > 0 {
+            result.Status = ImportStatusInvalid
+            results = append(results, result)
+            continue
+        }
+
+        uri, name := NormaliseURI(result.BusinessURI), NormaliseName(result.BusinessName)
+        duplicate, ok := uris[uri]
+        if !ok {
+            duplicate, ok = names[name]
+        }
+        if ok {
+            result.Status = ImportStatusDuplicate
+            result.DuplicateOf = duplicate.businessId
+            result.DuplicateRow = duplicate.row
+        } else {
+            uris[uri] = match{row: row.Row}
+            names[name] = match{row: row.Row}
+        }
+        results = append(results, result)
+    }
+    return results
+}
+
+// function used to summarise the results of an import
+func SummariseImport(results []ImportResult, dryRun bool) ImportSummary {
+    summary := ImportSummary{DryRun: dryRun, Total: len(results), Results: results}
+    for _, result := range(results) {
+        switch result.Status {
+        case ImportStatusCreated:
+            summary.Created++
+        case ImportStatusValid:
+            summary.Valid++
+        case ImportStatusDuplicate:
+            summary.Duplicates++
+        case ImportStatusInvalid:
+            summary.Invalid++
+        }
+    }
+    return summary
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+    "testing"
+    "strings"
+)
+
+func TestParseImportRows(t *testing.T) {
+    tests := []struct {
+        name     string
+        format   string
+        mapping  ImportMapping
+        body     string
+        err      error
+        expected []ImportRow
+    }{
+        {"csv default mapping", ImportFormatCSV, DefaultImportMapping,
+            "business_name,business_uri,metadata.yelp_business_id\n Dairy ,https://dairy.com,abc\nBakery,https://bakery.com,\n",
+            nil, []ImportRow{
+                {Row: 1, Request: NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com",
+                    Metadata: map[string]interface{}{"yelp_business_id": "abc"}}},
+                {Row: 2, Request: NewBusinessRequest{BusinessName: "Bakery", BusinessURI: "https://bakery.com",
+                    Metadata: map[string]interface{}{}}},
+            }},
+        {"csv custom mapping", ImportFormatCSV,
+            ImportMapping{"business_name": "Name", "business_uri": "Website", "metadata.place": "Place ID"},
+            "Name,Website,Place ID,metadata.ignored\nDairy,https://dairy.com,xyz,skip\n",
+            nil, []ImportRow{
+                {Row: 1, Request: NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com",
+                    Metadata: map[string]interface{}{"place": "xyz"}}},
+            }},
+        {"csv parse error", ImportFormatCSV, DefaultImportMapping,
+            "business_name,business_uri\n\"Dairy,https://dairy.com\n",
+            nil, []ImportRow{{Row: 1, Errors: []string{"unable to parse row"}}}},
+        {"csv without header", ImportFormatCSV, DefaultImportMapping, "", ErrInvalidImportFormat, nil},
+        {"jsonl", ImportFormatJSONL, DefaultImportMapping,
+            "{\"business_name\": \"Dairy\", \"business_uri\": \"https://dairy.com\", \"metadata\": {\"a\": 1}}\n\n[1]\n{\"business_name\": 1}\n",
+            nil, []ImportRow{
+                {Row: 1, Request: NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com",
+                    Metadata: map[string]interface{}{"a": float64(1)}}},
+                {Row: 2, Errors: []string{"row must be a JSON object"}},
+                {Row: 3, Errors: []string{"column 'business_name' must be a string"}},
+            }},
+        {"unknown format", "xml", DefaultImportMapping, "<businesses/>", ErrInvalidImportFormat, nil},
+    }
+    for _, test := range(tests) {
+        t.Run(test.name, func(t *testing.T) {
+            rows, err := ParseImportRows(test.format, strings.NewReader(test.body), test.mapping)
+            if err != test.err {
+                t.Fatalf("expected error %v, got %v", test.err, err)
+            }
+            if err != nil {
+                return
+            }
+            if len(rows) != len(test.expected) {
+                t.Fatalf("expected %d rows, got %+v", len(test.expected), rows)
+            }
+            for i, expected := range(test.expected) {
+                row := rows[i]
+                if row.Row != expected.Row || row.Request.BusinessName != expected.Request.BusinessName ||
+                    row.Request.BusinessURI != expected.Request.BusinessURI {
+                    t.Errorf("expected row %+v, got %+v", expected, row)
+                }
+                if len(row.Errors) != len(expected.Errors) ||
+                    (len(row.Errors) > 0 && !strings.HasPrefix(row.Errors[0], expected.Errors[0])) {
+                    t.Errorf("row %d: expected errors %v, got %v", row.Row, expected.Errors, row.Errors)
+                }
+                if expected.Request.Metadata != nil && !equalJSON(row.Request.Metadata, expected.Request.Metadata) {
+                    t.Errorf("row %d: expected metadata %v, got %v", row.Row, expected.Request.Metadata,
+                        row.Request.Metadata)
+                }
+            }
+        })
+    }
+}
+
+func TestParseImportMapping(t *testing.T) {
+    tests := []struct {
+        values []string
+        err    error
+    }{
+        {nil, nil},
+        {[]string{"business_name:Name", "business_uri: Website ", "metadata.place:Place ID"}, nil},
+        {[]string{"business_name:Name"}, ErrInvalidImportMapping},
+        {[]string{"business_name:Name", "business_uri:"}, ErrInvalidImportMapping},
+        {[]string{"business_name:Name", "business_uri:URI", "phone:Phone"}, ErrInvalidImportMapping},
+        {[]string{"business_name:Name", "business_uri:URI", "metadata.:Key"}, ErrInvalidImportMapping},
+    }
+    for _, test := range(tests) {
+        if _, err := ParseImportMapping(test.values); err != test.err {
+            t.Errorf("%v: expected error %v, got %v", test.values, test.err, err)
+        }
+    }
+}
+
+func TestNormaliseURI(t *testing.T) {
+    tests := []struct {
+        uri      string
+        expected string
+    }{
+        {"https://www.Example.com/", "example.com"},
+        {"http://example.com/farm/?page=1", "example.com/farm"},
+        {" example.com ", "example.com"},
+        {"https://example.com:8080/a//", "example.com/a"},
+    }
+    for _, test := range(tests) {
+        if result := NormaliseURI(test.uri); result != test.expected {
+            t.Errorf("%q: expected %q, got %q", test.uri, test.expected, result)
+        }
+    }
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+    "time"
+    "sort"
+    "errors"
+    "strings"
+    "strconv"
+    "encoding/json"
+    "encoding/base64"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+)
+
+var (
+    // define custom errors
+    ErrInvalidCursor = errors.New("Invalid pagination cursor")
+    ErrInvalidSort = errors.New("Invalid sort field")
+    ErrInvalidLimit = errors.New("Invalid page limit")
+    ErrInvalidFilter = errors.New("Invalid filter")
+
+    // define page sizes used when listing businesses. the default limit
+    // is only used when paginating with a cursor; listings requested
+    // without a limit or cursor return all businesses as a single page
+    DefaultBusinessLimit = 100
+    MaxBusinessLimit = 1000
+)
+
+const (
+    // define fields used to sort businesses
+    SortByName = "name"
+    SortByAdded = "added"
+    SortByLastUpdate = "last_update"
+)
+
+// struct used to store the position of the last business returned in
+// a page. the sort settings are stored so that a cursor cannot be used
+// with a different sort order
+type BusinessCursor struct {
+    Sort       string    `json:"s"`
+    Descending bool      `json:"d"`
+    Name       string    `json:"n,omitempty"`
+    Timestamp  time.Time `json:"t,omitempty"`
+    BusinessId uuid.UUID `json:"id"`
+}
+
+// struct used to store the settings used to list businesses. empty
+// filters are ignored. deleted businesses are only listed, and are
+// always listed, when the deleted flag is set. a limit of 0 lists all
+// matching businesses without a next page
+type BusinessQuery struct {
+    Limit         int
+    Cursor        *BusinessCursor
+    Sort          string
+    Descending    bool
+    Name          string
+    Domain        string
+    HasMetadata   []string
+    UpdatedBefore *time.Time
+    UpdatedAfter  *time.Time
+    Open          *bool
+    Live          *bool
+    Deleted       bool
+}
+
+// struct used to store a single page of businesses. the next cursor
+// is empty if there are no more businesses
+type BusinessPage struct {
+    Businesses []BusinessInfo
+    NextCursor string
+}
+
+// function used to generate the cursor pointing at a given business
+func NewBusinessCursor(query BusinessQuery, info BusinessInfo) BusinessCursor {
+    cursor := BusinessCursor{Sort: query.Sort, Descending: query.Descending,
+        BusinessId: info.BusinessId}
+    switch query.Sort {
+    case SortByName:
+        cursor.Name = info.BusinessName
+    case SortByAdded:
+        cursor.Timestamp = info.Added
+    case SortByLastUpdate:
+        cursor.Timestamp = info.LastUpdate
+    }
+    return cursor
+}
+
+// function used to encode a cursor into an opaque string
+func(cursor BusinessCursor) Encode() string {
+    encoded, _ := json.Marshal(cursor)
+    return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// function used to decode a cursor from an opaque string
+func DecodeBusinessCursor(value string) (BusinessCursor, error) {
+    var cursor BusinessCursor
+    decoded, err := base64.RawURLEncoding.DecodeString(value)
+    if err != nil {
+        return cursor, ErrInvalidCursor
+    }
+    if err := json.Unmarshal(decoded, &cursor); err != nil {
+        return cursor, ErrInvalidCursor
+    }
+    return cursor, nil
+}
+
+// function used to parse a timestamp given as a query parameter.
+// timestamps are accepted in RFC3339 format or the format used
+// for timeseries time ranges
+func parseQueryTimestamp(value string) (time.Time, error) {
+    if ts, err := time.Parse(time.RFC3339, value); err == nil {
+        return ts, nil
+    }
+    return time.Parse("2006-01-02T15:04", value)
+}
+
+// function used to parse the settings used to list businesses from
+// query parameters
+func ParseBusinessQuery(values neturl.Values) (BusinessQuery, error) {
+    query := BusinessQuery{
+        Sort: SortByAdded,
+        Name: strings.TrimSpace(values.Get("name")),
+        Domain: NormaliseDomain(values.Get("domain")),
+    }
+
+    if limit := values.Get("limit"); len(limit) > 0 {
+        value, err := strconv.Atoi(limit)
+        if err != nil || value < 1 || value > MaxBusinessLimit {
+            return query, ErrInvalidLimit
+        }
+        query.Limit = value
+    } else if len(values.Get("cursor")) > 0 {
+        query.Limit = DefaultBusinessLimit
+    }
+
+    if sortField := values.Get("sort"); len(sortField) > 0 {
+        switch sortField {
+        case SortByName, SortByAdded, SortByLastUpdate:
+            query.Sort = sortField
+        default:
+            return query, ErrInvalidSort
+        }
+    }
+    switch values.Get("order") {
+    case "", "asc":
+    case "desc":
+        query.Descending = true
+    default:
+        return query, ErrInvalidSort
+    }
+
+    // metadata keys can be given multiple times or as a comma separated list
+    for _, value := range(values["has"]) {
+        for _, key := range(strings.Split(value, ",")) {
+            if key = strings.TrimSpace(key); len(key) > 0 {
+                query.HasMetadata = append(query.HasMetadata, key)
+            }
+        }
+    }
+
+    for key, target := range(map[string]**time.Time{"updated_before": &query.UpdatedBefore,
+        "updated_after": &query.UpdatedAfter}) {
+        if value := values.Get(key); len(value) > 0 {
+            ts, err := parseQueryTimestamp(value)
+            if err != nil {
+                return query, ErrInvalidFilter
+            }
+            *target = &ts
+        }
+    }
+
+    for key, target := range(map[string]**bool{"open": &query.Open, "live": &query.Live}) {
+        if value := values.Get(key); len(value) > 0 {
+            flag, err := strconv.ParseBool(value)
+            if err != nil {
+                return query, ErrInvalidFilter
+            }
+            *target = &flag
+        }
+    }
+
+    if value := values.Get("deleted"); len(value) > 0 {
+        deleted, err := strconv.ParseBool(value)
+        if err != nil {
+            return query, ErrInvalidFilter
+        }
+        query.Deleted = deleted
+    }
+
+    if value := values.Get("cursor"); len(value) > 0 {
+        cursor, err := DecodeBusinessCursor(value)
+        if err != nil {
+            return query, err
+        }
+        // cursors are only valid for the sort order that generated them
+        if cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+            return query, ErrInvalidCursor
+        }
+        query.Cursor = &cursor
+    }
+    return query, nil
+}
+
+// function used to normalise a domain used to filter businesses. the
+// scheme, path and 'www.' prefix are removed and the domain is lower-case
+func NormaliseDomain(value string) string {
+    value = strings.ToLower(strings.TrimSpace(value))
+    if len(value) == 0 {
+        return value
+    }
+    if !strings.Contains(value, "://") {
+        value = "http://" + value
+    }
+    parsed, err := neturl.Parse(value)
+    if err != nil {
+        return ""
+    }
+    return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
+
+// function used to determine if a domain matches a filter domain. sub
+// domains of the filter domain are matched
+func domainMatches(domain, filter string) bool {
+    return domain == filter || strings.HasSuffix(domain, "." + filter)
+}
+
+// function used to retrieve the current value of a boolean field from
+// the golden record of a business
+func goldenRecordFlag(info BusinessInfo, field string) (bool, bool) {
+    if info.GoldenRecord == nil {
+        return false, false
+    }
+    value, ok := info.GoldenRecord.Fields[field]
+    if !ok {
+        return false, false
+    }
+    flag, ok := value.Value.(bool)
+    return flag, ok
+}
+
+// function used to determine if a business matches the filters of a query.
+// the current open and live state of a business is taken from its golden
+// record, so businesses without golden records never match these filters
+func(query BusinessQuery) Matches(info BusinessInfo) bool {
+    if (info.DeletedAt != nil) != query.Deleted {
+        return false
+    }
+    if len(query.Name) > 0 && !strings.Contains(strings.ToLower(info.BusinessName),
+        strings.ToLower(query.Name)) {
+        return false
+    }
+    if len(query.Domain) > 0 && !domainMatches(NormaliseDomain(info.BusinessURI), query.Domain) {
+        return false
+    }
+    for _, key := range(query.HasMetadata) {
+        if _, ok := info.Metadata[key]; !ok {
+            return false
+        }
+    }
+    if query.UpdatedBefore != nil && !info.LastUpdate.Before(*query.UpdatedBefore) {
+        return false
+    }
+    if query.UpdatedAfter != nil && !info.LastUpdate.After(*query.UpdatedAfter) {
+        return false
+    }
+    for field, target := range(map[string]*bool{"business_open": query.Open, "website_live": query.Live}) {
+        if target == nil {
+            continue
+        }
+        if flag, ok := goldenRecordFlag(info, field); !ok || flag != *target {
+            return false
+        }
+    }
+    return true
+}
+
+// function used to compare the sort position of a business to a cursor.
+// a negative value is returned if the business is before the cursor
+func(query BusinessQuery) compare(info BusinessInfo, cursor BusinessCursor) int {
+    result := 0
+    switch query.Sort {
+    case SortByName:
+        result = strings.Compare(info.BusinessName, cursor.Name)
+    case SortByAdded:
+        result = compareTimes(info.Added, cursor.Timestamp)
+    case SortByLastUpdate:
+        result = compareTimes(info.LastUpdate, cursor.Timestamp)
+    }
+    if result == 0 {
+        result = strings.Compare(info.BusinessId.String(), cursor.BusinessId.String())
+    }
+    if query.Descending {
+        return -result
+    }
+    return result
+}
+
+// function used to compare two timestamps
+func compareTimes(a, b time.Time) int {
+    switch {
+    case a.Before(b):
+        return -1
+    case a.After(b):
+        return 1
+    }
+    return 0
+}
+
+// function used to generate a page of businesses from a list of all
+// businesses. used by repositories that cannot filter businesses
+// when they are retrieved i.e. in-memory repositories
+func(query BusinessQuery) Paginate(businesses []BusinessInfo) BusinessPage {
+    results := []BusinessInfo{}
+    for _, info := range(businesses) {
+        if !query.Matches(info) {
+            continue
+        }
+        if query.Cursor != nil && query.compare(info, *query.Cursor) <= 0 {
+            continue
+        }
+        results = append(results, info)
+    }
+    sort.Slice(results, func(i, j int) bool {
+        return query.compare(results[i], NewBusinessCursor(query, results[j])) < 0
+    })
+    return query.page(results)
+}
+
+// function used to trim sorted businesses to the page limit. the
+// businesses must contain at least one more entry than the limit
+// if another page exists
+func(query BusinessQuery) page(businesses []BusinessInfo) BusinessPage {
+    page := BusinessPage{Businesses: businesses}
+    if query.Limit > 0 && len(businesses) > query.Limit {
+        page.Businesses = businesses[:query.Limit]
+        page.NextCursor = NewBusinessCursor(query, page.Businesses[query.Limit - 1]).Encode()
+    }
+    return page
+}
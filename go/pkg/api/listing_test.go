@@ -0,0 +1,134 @@
+package api
+
+import (
+    "time"
+    "testing"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+)
+
+func TestBusinessCursor(t *testing.T) {
+    added := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+    info := BusinessInfo{BusinessId: uuid.New(), BusinessName: "Dairy", Added: added,
+        LastUpdate: added.Add(time.Hour)}
+
+    tests := []struct {
+        query     BusinessQuery
+        name      string
+        timestamp time.Time
+    }{
+        {BusinessQuery{Sort: SortByName}, "Dairy", time.Time{}},
+        {BusinessQuery{Sort: SortByAdded, Descending: true}, "", added},
+        {BusinessQuery{Sort: SortByLastUpdate}, "", added.Add(time.Hour)},
+    }
+    for _, test := range(tests) {
+        cursor, err := DecodeBusinessCursor(NewBusinessCursor(test.query, info).Encode())
+        if err != nil {
+            t.Fatalf("unable to decode cursor: %+v", err)
+        }
+        if cursor.Sort != test.query.Sort || cursor.Descending != test.query.Descending ||
+            cursor.BusinessId != info.BusinessId || cursor.Name != test.name ||
+            !cursor.Timestamp.Equal(test.timestamp) {
+            t.Errorf("unexpected cursor %+v for query %+v", cursor, test.query)
+        }
+    }
+
+    for _, value := range([]string{"not-base64!", "bm90LWpzb24"}) {
+        if _, err := DecodeBusinessCursor(value); err != ErrInvalidCursor {
+            t.Errorf("%q: expected error %v, got %v", value, ErrInvalidCursor, err)
+        }
+    }
+}
+
+func TestBusinessQueryCompare(t *testing.T) {
+    first, second := uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+        uuid.MustParse("00000000-0000-0000-0000-000000000002")
+    added := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+    cursorInfo := BusinessInfo{BusinessId: first, BusinessName: "Dairy", Added: added, LastUpdate: added}
+
+    tests := []struct {
+        name     string
+        query    BusinessQuery
+        info     BusinessInfo
+        expected int
+    }{
+        {"name before", BusinessQuery{Sort: SortByName},
+            BusinessInfo{BusinessId: second, BusinessName: "Bakery"}, -1},
+        {"name after descending", BusinessQuery{Sort: SortByName, Descending: true},
+            BusinessInfo{BusinessId: second, BusinessName: "Bakery"}, 1},
+        {"equal name uses ID", BusinessQuery{Sort: SortByName},
+            BusinessInfo{BusinessId: second, BusinessName: "Dairy"}, 1},
+        {"same business", BusinessQuery{Sort: SortByAdded}, cursorInfo, 0},
+        {"added later", BusinessQuery{Sort: SortByAdded},
+            BusinessInfo{BusinessId: first, Added: added.Add(time.Second)}, 1},
+        {"updated earlier", BusinessQuery{Sort: SortByLastUpdate},
+            BusinessInfo{BusinessId: second, LastUpdate: added.Add(-time.Second)}, -1},
+        {"updated earlier descending", BusinessQuery{Sort: SortByLastUpdate, Descending: true},
+            BusinessInfo{BusinessId: second, LastUpdate: added.Add(-time.Second)}, 1},
+    }
+    for _, test := range(tests) {
+        cursor := NewBusinessCursor(test.query, cursorInfo)
+        if result := test.query.compare(test.info, cursor); result != test.expected {
+            t.Errorf("%s: expected %d, got %d", test.name, test.expected, result)
+        }
+    }
+}
+
+func TestParseBusinessQuery(t *testing.T) {
+    nameCursor := BusinessCursor{Sort: SortByName, BusinessId: uuid.New()}.Encode()
+
+    tests := []struct {
+        params string
+        err    error
+        check  func(query BusinessQuery) bool
+    }{
+        {"", nil, func(query BusinessQuery) bool {
+            return query.Limit == 0 && query.Sort == SortByAdded && !query.Descending
+        }},
+        {"cursor=" + BusinessCursor{Sort: SortByAdded, BusinessId: uuid.New()}.Encode(), nil,
+            func(query BusinessQuery) bool {
+                return query.Limit == DefaultBusinessLimit && query.Cursor != nil
+            }},
+        {"limit=5&sort=name&order=desc", nil, func(query BusinessQuery) bool {
+            return query.Limit == 5 && query.Sort == SortByName && query.Descending
+        }},
+        {"has=a,b&has=c&has=", nil, func(query BusinessQuery) bool {
+            return len(query.HasMetadata) == 3 && query.HasMetadata[2] == "c"
+        }},
+        {"domain=HTTPS://WWW.Example.com/path&name=%20farm%20", nil, func(query BusinessQuery) bool {
+            return query.Domain == "example.com" && query.Name == "farm"
+        }},
+        {"updated_after=2021-01-02T03:04&updated_before=2021-01-03T00:00:00Z", nil, func(query BusinessQuery) bool {
+            return query.UpdatedAfter.Equal(time.Date(2021, 1, 2, 3, 4, 0, 0, time.UTC)) &&
+                query.UpdatedBefore.Equal(time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC))
+        }},
+        {"open=true&live=false&deleted=1", nil, func(query BusinessQuery) bool {
+            return *query.Open && !*query.Live && query.Deleted
+        }},
+        {"sort=name&cursor=" + nameCursor, nil, func(query BusinessQuery) bool {
+            return query.Cursor != nil && query.Cursor.Sort == SortByName
+        }},
+        {"limit=0", ErrInvalidLimit, nil},
+        {"limit=1001", ErrInvalidLimit, nil},
+        {"limit=ten", ErrInvalidLimit, nil},
+        {"sort=rating", ErrInvalidSort, nil},
+        {"order=up", ErrInvalidSort, nil},
+        {"updated_after=yesterday", ErrInvalidFilter, nil},
+        {"open=maybe", ErrInvalidFilter, nil},
+        {"cursor=invalid!", ErrInvalidCursor, nil},
+        {"cursor=" + nameCursor, ErrInvalidCursor, nil},
+        {"sort=name&order=desc&cursor=" + nameCursor, ErrInvalidCursor, nil},
+    }
+    for _, test := range(tests) {
+        values, _ := neturl.ParseQuery(test.params)
+        query, err := ParseBusinessQuery(values)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.params, test.err, err)
+            continue
+        }
+        if test.check != nil && !test.check(query) {
+            t.Errorf("%q: unexpected query %+v", test.params, query)
+        }
+    }
+}
@@ -4,6 +4,9 @@ import (
     "time"
 
     "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
 )
 
 type NewBusinessRequest struct{
@@ -27,6 +30,34 @@ type BusinessInfo struct{
     LastUpdate     time.Time              `json:"last_update"`
     Added 		   time.Time              `json:"added"`
     Metadata       map[string]interface{} `json:"metadata"`
+    GoldenRecord   *reconciler.GoldenRecord `json:"golden_record,omitempty"`
+    DeletedAt      *time.Time             `json:"deleted_at,omitempty"`
+    Version        int                    `json:"version"`
+}
+
+// struct used to store manually entered business data. only the
+// fields that are set are used when reconciling golden records
+type ManualDataRequest struct{
+    WebsiteLive    *bool    `json:"website_live"`
+    BusinessPhones []string `json:"business_phones"`
+    BusinessOpen   *bool    `json:"business_open"`
+}
+
+// struct used to store static business data along with the
+// freshness of the data based on the SLA of the source
+type StaticBusinessData struct{
+    connectors.BusinessData
+    Stale bool `json:"stale"`
+}
+
+// struct used to store the stats of a rollup bucket. the fractions
+// are the share of samples in the bucket reporting a live website
+// or an open business
+type RollupStats struct{
+    Precision    string  `json:"precision"`
+    FractionLive float64 `json:"fraction_live"`
+    FractionOpen float64 `json:"fraction_open"`
+    Samples      int     `json:"samples"`
 }
 
 type Notification struct{
@@ -34,4 +65,49 @@ type Notification struct{
     EventTimestamp time.Time              `json:"event_timestamp"`
     Notification   map[string]interface{} `json:"notification"`
     Hash           string                 `json:"hash"`
-}
\ No newline at end of file
+}
+// struct used to store the availability of a single boolean state
+// over a period i.e. website uptime or open/closed history. outages
+// are periods in which the state was false
+type StateReport struct{
+    UpPercent                   float64  `json:"up_percent"`
+    StateChanges                int      `json:"state_changes"`
+    Outages                     int      `json:"outages"`
+    LongestOutageHours          float64  `json:"longest_outage_hours"`
+    MeanTimeBetweenOutagesHours *float64 `json:"mean_time_between_outages_hours"`
+    Current                     bool     `json:"current"`
+}
+
+// struct used to store the availability of a business as reported
+// by a single source
+type SourceAvailability struct{
+    Source        string      `json:"source"`
+    Observations  int         `json:"observations"`
+    FirstObserved time.Time   `json:"first_observed"`
+    LastObserved  time.Time   `json:"last_observed"`
+    Website       StateReport `json:"website"`
+    Open          StateReport `json:"open"`
+}
+
+// struct used to store the availability of a business across sources
+type BusinessAvailability struct{
+    BusinessId   uuid.UUID            `json:"business_id"`
+    BusinessName string               `json:"business_name"`
+    Sources      []SourceAvailability `json:"sources"`
+}
+
+// struct used to store directory-wide availability for a single source.
+// businesses are counted as live or open if the last observation in
+// the period reported a live website or an open business
+type DirectoryAvailability struct{
+    Start              time.Time `json:"start"`
+    End                time.Time `json:"end"`
+    Source             string    `json:"source"`
+    Businesses         int       `json:"businesses"`
+    BusinessesObserved int       `json:"businesses_observed"`
+    BusinessesLive     int       `json:"businesses_live"`
+    PercentLive        float64   `json:"percent_live"`
+    MeanUptimePercent  float64   `json:"mean_uptime_percent"`
+    BusinessesOpen     int       `json:"businesses_open"`
+    PercentOpen        float64   `json:"percent_open"`
+}
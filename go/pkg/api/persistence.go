@@ -4,13 +4,18 @@ import (
     "fmt"
     "time"
     "context"
+    "strings"
+    "strconv"
 
     "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
     "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/cleaner"
     "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
 )
 
 
@@ -26,22 +31,54 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
-// function to insert new business into database
-func(db *Persistence) CreateBusiness(request NewBusinessRequest) error {
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function to insert new business into database along with the first
+// entry of its audit log
+func(db *Persistence) CreateBusiness(request NewBusinessRequest, actor Actor) error {
     log.Debug(fmt.Sprintf("creating new businesses %+v", request))
+    _, err := db.ImportBusinesses([]NewBusinessRequest{request}, actor)
+    return err
+}
 
-    var (query string; err error)
-    businessId := uuid.New()
+// function to insert multiple businesses into database in a single
+// transaction. either all businesses are inserted or none are. the
+// IDs of the new businesses are returned in the order of the requests
+func(db *Persistence) ImportBusinesses(requests []NewBusinessRequest, actor Actor) ([]uuid.UUID, error) {
+    log.Debug(fmt.Sprintf("importing %d new businesses", len(requests)))
+    results := []uuid.UUID{}
 
-    // insert data into metadata table
-    query = `INSERT INTO asset_metadata(business_id,business_name,metadata,uri) VALUES($1,$2,$3,$4)`
-    _, err = db.Session.Exec(context.Background(), query, businessId,
-        request.BusinessName, request.Metadata, request.BusinessURI)
+    tx, err := db.Session.Begin(context.Background())
     if err != nil {
-        return err
+        return results, err
     }
-
-    return nil
+    defer tx.Rollback(context.Background())
+
+    query := `INSERT INTO asset_metadata(business_id,business_name,metadata,uri) VALUES($1,$2,$3,$4)`
+    for _, request := range(requests) {
+        businessId := uuid.New()
+        snapshot := NewBusinessSnapshot(request.BusinessName, request.BusinessURI, request.Metadata)
+        if _, err := tx.Exec(context.Background(), query, businessId,
+            snapshot.BusinessName, snapshot.Metadata, snapshot.BusinessURI); err != nil {
+            return []uuid.UUID{}, err
+        }
+        entry := NewAuditEntry(businessId, AuditActionCreate, actor, nil, snapshot, nil)
+        entry.Version = 1
+        if err := insertAuditEntry(tx, entry); err != nil {
+            return []uuid.UUID{}, err
+        }
+        results = append(results, businessId)
+    }
+    if err := tx.Commit(context.Background()); err != nil {
+        return []uuid.UUID{}, err
+    }
+    return results, nil
 }
 
 // function used to retrieve businesses from the database
@@ -51,7 +88,10 @@ func(db *Persistence) GetBusinesses() ([]BusinessInfo, error) {
 
     query := `SELECT asset_metadata.business_id, asset_metadata.business_name,
     asset_metadata.added, asset_metadata.metadata, asset_metadata.uri,
-    asset_metadata.last_update FROM asset_metadata`
+    asset_metadata.last_update, golden_records.fields, golden_records.computed_at,
+    asset_metadata.deleted_at, asset_metadata.version FROM asset_metadata LEFT JOIN golden_records
+    ON asset_metadata.business_id = golden_records.business_id
+    WHERE asset_metadata.deleted_at IS NULL`
 
     // retrieve businesses from database
     rows, err := db.Session.Query(context.Background(), query)
@@ -65,40 +105,255 @@ func(db *Persistence) GetBusinesses() ([]BusinessInfo, error) {
         }
     }
 
+    return scanBusinesses(rows), nil
+}
+
+// function used to retrieve the businesses that may be duplicates of
+// imported businesses i.e. businesses on one of the given domains or with
+// one of the given normalised names. the expressions match the indexes of
+// the import keys migration so that only candidates are read
+func(db *Persistence) GetImportCandidates(domains, names []string) ([]BusinessInfo, error) {
+    log.Debug(fmt.Sprintf("retrieving import candidates for %d domains and %d names", len(domains), len(names)))
+    results := []BusinessInfo{}
+
+    query := `SELECT asset_metadata.business_id, asset_metadata.business_name,
+    asset_metadata.added, asset_metadata.metadata, asset_metadata.uri,
+    asset_metadata.last_update, golden_records.fields, golden_records.computed_at,
+    asset_metadata.deleted_at, asset_metadata.version FROM asset_metadata LEFT JOIN golden_records
+    ON asset_metadata.business_id = golden_records.business_id
+    WHERE asset_metadata.deleted_at IS NULL AND (
+        regexp_replace(lower(substring(asset_metadata.uri from
+            '^(?:[A-Za-z][A-Za-z0-9+.-]*://)?(?:[^@/]*@)?([^/:?#]+)')), '^www\.', '') = ANY($1)
+        OR btrim(regexp_replace(lower(asset_metadata.business_name), '[^[:alnum:]]+', ' ', 'g')) = ANY($2))`
+
+    rows, err := db.Session.Query(context.Background(), query, domains, names)
+    if err != nil {
+        return results, err
+    }
+    return scanBusinesses(rows), nil
+}
+
+// function used to scan businesses joined with their golden records
+// from database rows
+func scanBusinesses(rows pgx.Rows) []BusinessInfo {
+    defer rows.Close()
+    results := []BusinessInfo{}
     for rows.Next() {
-        var businessName, businessUri string
-        var (businessId uuid.UUID; added, lastUpdate time.Time; meta map[string]interface{})
-        // handle errors from scanning values into variables
-        if err := rows.Scan(&businessId, &businessName, &added, &meta,
-            &businessUri, &lastUpdate); err != nil {
+        info, err := scanBusiness(rows)
+        if err != nil {
             log.Error(fmt.Errorf("unable to scan database row: %+v", err))
             continue
         }
         // append new business info to results
-        results = append(results, BusinessInfo{
+        results = append(results, info)
+    }
+    return results
+}
+
+// function used to scan a single business joined with its golden record.
+// additional columns selected after the golden record, deletion time and
+// version are scanned into the given destinations
+func scanBusiness(rows pgx.Rows, extra ...interface{}) (BusinessInfo, error) {
+    var businessName, businessUri string
+    var (businessId uuid.UUID; added, lastUpdate time.Time; meta map[string]interface{})
+    var (fields map[string]reconciler.FieldValue; computedAt, deletedAt *time.Time; version int)
+    // handle errors from scanning values into variables
+    destinations := append([]interface{}{&businessId, &businessName, &added, &meta,
+        &businessUri, &lastUpdate, &fields, &computedAt, &deletedAt, &version}, extra...)
+    if err := rows.Scan(destinations...); err != nil {
+        return BusinessInfo{}, err
+    }
+    info := BusinessInfo{
+        BusinessId: businessId,
+        BusinessName: businessName,
+        BusinessURI: businessUri,
+        Added: added,
+        LastUpdate: lastUpdate,
+        Metadata: meta,
+        DeletedAt: deletedAt,
+        Version: version,
+    }
+    // attach golden record if one has been computed for business
+    if computedAt != nil {
+        info.GoldenRecord = &reconciler.GoldenRecord{
             BusinessId: businessId,
-            BusinessName: businessName,
-            BusinessURI: businessUri,
-            Added: added,
-            LastUpdate: lastUpdate,
-            Metadata: meta,
-        })
+            Fields: fields,
+            ComputedAt: *computedAt,
+        }
     }
-    return results, nil
+    return info, nil
+}
+
+// function used to retrieve a single page of businesses matching the
+// filters of a query. pages are retrieved using the sort value and ID of
+// the last business in the previous page so that pages remain stable
+// while businesses are added or removed
+func(db *Persistence) ListBusinesses(request BusinessQuery) (BusinessPage, error) {
+    log.Debug(fmt.Sprintf("listing businesses with query %+v", request))
+
+    conditions := []string{}
+    args := []interface{}{}
+    // function used to add a query argument and return its placeholder
+    arg := func(value interface{}) string {
+        args = append(args, value)
+        return fmt.Sprintf("$%d", len(args))
+    }
+
+    if len(request.Name) > 0 {
+        conditions = append(conditions, fmt.Sprintf("m.business_name ILIKE %s",
+            arg("%" + escapeLike(request.Name) + "%")))
+    }
+    if len(request.Domain) > 0 {
+        host := `lower(substring(m.uri from '^(?:[A-Za-z][A-Za-z0-9+.-]*://)?(?:[^@/]*@)?([^/:?#]+)'))`
+        host = fmt.Sprintf("regexp_replace(%s, '^www\\.', '')", host)
+        domain := arg(request.Domain)
+        conditions = append(conditions, fmt.Sprintf("(%s = %s OR right(%s, length(%s) + 1) = '.' || %s)",
+            host, domain, host, domain, domain))
+    }
+    for _, key := range(request.HasMetadata) {
+        conditions = append(conditions, fmt.Sprintf("m.metadata::jsonb ? %s",
+            arg(key)))
+    }
+    if request.UpdatedBefore != nil {
+        conditions = append(conditions, fmt.Sprintf("m.last_update < %s", arg(*request.UpdatedBefore)))
+    }
+    if request.UpdatedAfter != nil {
+        conditions = append(conditions, fmt.Sprintf("m.last_update > %s", arg(*request.UpdatedAfter)))
+    }
+    // the current state of a business is taken from its golden record
+    if request.Open != nil {
+        conditions = append(conditions, fmt.Sprintf(
+            "(g.fields->'business_open'->>'value')::boolean = %s", arg(*request.Open)))
+    }
+    if request.Live != nil {
+        conditions = append(conditions, fmt.Sprintf(
+            "(g.fields->'website_live'->>'value')::boolean = %s", arg(*request.Live)))
+    }
+
+    // deleted businesses are only listed when requested
+    if request.Deleted {
+        conditions = append(conditions, "m.deleted_at IS NOT NULL")
+    } else {
+        conditions = append(conditions, "m.deleted_at IS NULL")
+    }
+
+    column := map[string]string{
+        SortByName: "m.business_name",
+        SortByAdded: "m.added",
+        SortByLastUpdate: "m.last_update",
+    }[request.Sort]
+    if len(column) == 0 {
+        return BusinessPage{Businesses: []BusinessInfo{}}, ErrInvalidSort
+    }
+    order, comparison := "ASC", ">"
+    if request.Descending {
+        order, comparison = "DESC", "<"
+    }
+    if request.Cursor != nil {
+        var value interface{} = request.Cursor.Timestamp
+        if request.Sort == SortByName {
+            value = request.Cursor.Name
+        }
+        conditions = append(conditions, fmt.Sprintf("(%s, m.business_id) %s (%s, %s)", column,
+            comparison, arg(value), arg(request.Cursor.BusinessId)))
+    }
+
+    query := `SELECT m.business_id, m.business_name, m.added, m.metadata, m.uri,
+        m.last_update, g.fields, g.computed_at, m.deleted_at, m.version
+        FROM asset_metadata m LEFT JOIN golden_records g ON m.business_id = g.business_id
+        WHERE ` + strings.Join(conditions, " AND ")
+    query += fmt.Sprintf(" ORDER BY %s %s, m.business_id %s", column, order, order)
+    // retrieve an additional business to determine if there is a next page
+    if request.Limit > 0 {
+        query += fmt.Sprintf(" LIMIT %s", arg(request.Limit + 1))
+    }
+
+    rows, err := db.Session.Query(context.Background(), query, args...)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return BusinessPage{Businesses: []BusinessInfo{}}, nil
+        default:
+            return BusinessPage{Businesses: []BusinessInfo{}}, err
+        }
+    }
+    return request.page(scanBusinesses(rows)), nil
+}
+
+// function used to search businesses using trigram similarity of the
+// business name, URI, address and metadata. addresses are stored under
+// the 'address' key of metadata. the similarity thresholds of the pg_trgm
+// operators are set to the minimum score for the transaction so that
+// trigram indexes can be used to find matches
+func(db *Persistence) SearchBusinesses(request SearchQuery) ([]BusinessSearchResult, error) {
+    log.Debug(fmt.Sprintf("searching businesses with query %+v", request))
+    results := []BusinessSearchResult{}
+
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        return results, err
+    }
+    defer tx.Rollback(context.Background())
+
+    threshold := strconv.FormatFloat(request.MinScore, 'f', -1, 64)
+    query := `SELECT set_config('pg_trgm.similarity_threshold', $1, true),
+        set_config('pg_trgm.word_similarity_threshold', $1, true)`
+    if _, err := tx.Exec(context.Background(), query, threshold); err != nil {
+        return results, err
+    }
+
+    // every condition of the search matches an expression with a trigram
+    // index, so that matching businesses are found without a table scan
+    query = `SELECT m.business_id, m.business_name, m.added, m.metadata, m.uri,
+        m.last_update, g.fields, g.computed_at, m.deleted_at, m.version, GREATEST(
+            similarity(m.business_name, $1), word_similarity($1, m.business_name),
+            similarity(m.uri, $1), word_similarity($1, m.uri),
+            word_similarity($1, COALESCE(m.metadata->>'address', '')),
+            word_similarity($1, COALESCE(m.metadata::text, ''))) AS score
+        FROM asset_metadata m LEFT JOIN golden_records g ON m.business_id = g.business_id
+        WHERE m.deleted_at IS NULL AND (m.business_name % $1 OR $1 <% m.business_name
+            OR m.uri % $1 OR $1 <% m.uri OR $1 <% (m.metadata->>'address') OR $1 <% m.metadata::text)
+        ORDER BY score DESC, m.business_name, m.business_id LIMIT $2`
+    rows, err := tx.Query(context.Background(), query, request.Text, request.Limit)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return results, nil
+        default:
+            return results, err
+        }
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var score float64
+        info, err := scanBusiness(rows, &score)
+        if err != nil {
+            log.Error(fmt.Errorf("unable to scan database row: %+v", err))
+            continue
+        }
+        results = append(results, BusinessSearchResult{BusinessInfo: info, Score: score})
+    }
+    return results, rows.Err()
+}
+
+// function used to escape wildcards in a value used in a LIKE pattern
+func escapeLike(value string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
 func(db *Persistence) GetBusinessById(businessId uuid.UUID) (BusinessInfo, error) {
     log.Debug(fmt.Sprintf("retrieving businesses with ID %s", businessId))
 
     query := `SELECT asset_metadata.business_name, asset_metadata.added,
-        asset_metadata.uri, asset_metadata.last_update, asset_metadata.metadata
-        FROM asset_metadata WHERE business_id=$1`
+        asset_metadata.uri, asset_metadata.last_update, asset_metadata.metadata,
+        asset_metadata.version FROM asset_metadata WHERE business_id=$1 AND deleted_at IS NULL`
 
     var (businessName, businessUri string; added, lastUpdated time.Time)
-    var meta map[string]interface{}
+    var (meta map[string]interface{}; version int)
     // retrieve business from database
     err := db.Session.QueryRow(context.Background(), query, businessId.String()).Scan(
-        &businessName, &added, &businessUri, &lastUpdated, &meta)
+        &businessName, &added, &businessUri, &lastUpdated, &meta, &version)
     if err != nil {
         switch err {
         case pgx.ErrNoRows:
@@ -115,43 +370,201 @@ func(db *Persistence) GetBusinessById(businessId uuid.UUID) (BusinessInfo, error
         LastUpdate: lastUpdated,
         Added: added,
         Metadata: meta,
+        Version: version,
     }
     return  info, nil
 }
 
-func(db *Persistence) UpdateBusinessURI(uri string, businessId uuid.UUID) error {
+// function used to update the URI of a business and record the change
+// in its audit log. the recorded change is returned
+func(db *Persistence) UpdateBusinessURI(uri string, businessId uuid.UUID, actor Actor,
+    ifMatch Precondition) (AuditEntry, error) {
     log.Debug(fmt.Sprintf("updating business URI for business %s", businessId))
-    query := `UPDATE asset_metadata SET uri=$1 WHERE business_id=$2`
-    _, err := db.Session.Exec(context.Background(), query, uri, businessId)
+    return db.changeBusiness(businessId, false, ifMatch, func(before BusinessSnapshot) (AuditEntry, error) {
+        after := NewBusinessSnapshot(before.BusinessName, uri, before.Metadata)
+        return NewAuditEntry(businessId, AuditActionUpdateURI, actor, &before, after, nil), nil
+    })
+}
+
+// function used to update the metadata of a business and record the
+// JSON patch operation used to generate the metadata in its audit log.
+// the recorded change is returned
+func(db *Persistence) UpdateBusinessMetadata(meta map[string]interface{}, operation []map[string]interface{},
+    businessId uuid.UUID, actor Actor, ifMatch Precondition) (AuditEntry, error) {
+    log.Debug(fmt.Sprintf("updating business metadata for business %s", businessId))
+    return db.changeBusiness(businessId, false, ifMatch, func(before BusinessSnapshot) (AuditEntry, error) {
+        after := NewBusinessSnapshot(before.BusinessName, before.BusinessURI, meta)
+        return NewAuditEntry(businessId, AuditActionUpdateMetadata, actor, &before, after,
+            MetadataPatch(operation)), nil
+    })
+}
+
+// function to soft delete a business with given business id. deleted
+// businesses are excluded from listings and collection jobs, and their
+// data is retained until purged by the cleaner after a grace period
+func(db *Persistence) DeleteBusiness(businessId uuid.UUID, actor Actor, ifMatch Precondition) error {
+    log.Debug(fmt.Sprintf("deleting business %s", businessId))
+    _, err := db.changeBusiness(businessId, false, ifMatch, func(before BusinessSnapshot) (AuditEntry, error) {
+        return NewAuditEntry(businessId, AuditActionDelete, actor, &before, before, nil), nil
+    })
     return err
 }
 
-func(db *Persistence) UpdateBusinessMetadata(meta map[string]interface{}, businessId uuid.UUID) error {
-    log.Debug(fmt.Sprintf("updating business URI for business %s", businessId))
-    query := `UPDATE asset_metadata SET metadata=$1 WHERE business_id=$2`
-    // update database with new metadata information
-    _, err := db.Session.Exec(context.Background(), query, meta, businessId)
+// function to restore a soft deleted business with given business id
+// before it is purged
+func(db *Persistence) RestoreBusiness(businessId uuid.UUID, actor Actor) error {
+    log.Debug(fmt.Sprintf("restoring business %s", businessId))
+    _, err := db.changeBusiness(businessId, true, nil, func(before BusinessSnapshot) (AuditEntry, error) {
+        return NewAuditEntry(businessId, AuditActionRestore, actor, &before, before, nil), nil
+    })
     return err
 }
 
-// function to delete a business with given business id
-func(db *Persistence) DeleteBusiness(businessId uuid.UUID) error {
-    log.Debug(fmt.Sprintf("deleting business %s", businessId))
+// function used to restore the name, URI and metadata of a business to
+// those of a previous version. the revert is recorded as a new version
+func(db *Persistence) RevertBusiness(businessId uuid.UUID, version int, actor Actor) (AuditEntry, error) {
+    log.Debug(fmt.Sprintf("reverting business %s to version %d", businessId, version))
+
+    // audit entries are never modified, so the snapshot of the version
+    // can be retrieved before the business is locked
+    var target BusinessSnapshot
+    query := `SELECT after FROM business_audit_log WHERE business_id=$1 AND version=$2`
+    if err := db.Session.QueryRow(context.Background(), query, businessId, version).Scan(&target); err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return AuditEntry{}, ErrVersionNotFound
+        default:
+            return AuditEntry{}, err
+        }
+    }
+    return db.changeBusiness(businessId, false, nil, func(before BusinessSnapshot) (AuditEntry, error) {
+        after := NewBusinessSnapshot(target.BusinessName, target.BusinessURI, target.Metadata)
+        entry := NewAuditEntry(businessId, AuditActionRevert, actor, &before, after, nil)
+        entry.RevertedVersion = &version
+        return entry, nil
+    })
+}
+
+// function used to apply a change to a business in a single transaction.
+// the business is locked and the change is generated from its current
+// state, after which the business is updated and the change is added to
+// its audit log as the next version. deleted businesses can only be
+// restored, and businesses are marked as deleted by delete actions
+func(db *Persistence) changeBusiness(businessId uuid.UUID, deleted bool, ifMatch Precondition,
+    change func(before BusinessSnapshot) (AuditEntry, error)) (AuditEntry, error) {
+
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        return AuditEntry{}, err
+    }
+    defer tx.Rollback(context.Background())
+
+    var (name, uri string; meta map[string]interface{}; version int)
+    query := `SELECT business_name, uri, metadata, version FROM asset_metadata
+        WHERE business_id=$1 AND (deleted_at IS NOT NULL)=$2 FOR UPDATE`
+    err = tx.QueryRow(context.Background(), query, businessId, deleted).Scan(&name, &uri, &meta, &version)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return AuditEntry{}, ErrBusinessNotFound
+        default:
+            return AuditEntry{}, err
+        }
+    }
+
+    if !ifMatch.Matches(version) {
+        return AuditEntry{}, ErrVersionConflict
+    }
+
+    entry, err := change(NewBusinessSnapshot(name, uri, meta))
+    if err != nil {
+        return entry, err
+    }
+    entry.Version = version + 1
+    var deletedAt *time.Time
+    if entry.Action == AuditActionDelete {
+        deletedAt = &entry.ChangedAt
+    }
+    query = `UPDATE asset_metadata SET business_name=$1, uri=$2, metadata=$3, deleted_at=$4,
+        version=$5 WHERE business_id=$6`
+    if _, err := tx.Exec(context.Background(), query, entry.After.BusinessName,
+        entry.After.BusinessURI, entry.After.Metadata, deletedAt, entry.Version, businessId); err != nil {
+        return entry, err
+    }
+    if err := insertAuditEntry(tx, entry); err != nil {
+        return entry, err
+    }
+    return entry, tx.Commit(context.Background())
+}
+
+// function used to append an entry to the audit log of a business. the
+// version of the entry is the version of the business after the change
+func insertAuditEntry(tx pgx.Tx, entry AuditEntry) error {
+    query := `INSERT INTO business_audit_log(business_id,version,action,actor_key,actor_claimed_user,
+        changed_at,before,after,patch,reverted_version) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`
+    _, err := tx.Exec(context.Background(), query, entry.BusinessId, entry.Version, entry.Action,
+        entry.Actor.ApiKey, entry.Actor.ClaimedUser, entry.ChangedAt, entry.Before, entry.After,
+        entry.Patch, entry.RevertedVersion)
+    return err
+}
+
+// function used to retrieve the audit log of a business ordered by version.
+// the audit log of deleted businesses is retained until they are purged
+func(db *Persistence) GetBusinessHistory(businessId uuid.UUID) ([]AuditEntry, error) {
+    log.Debug(fmt.Sprintf("retrieving history of business %s", businessId))
+    results := []AuditEntry{}
+
+    query := `SELECT version,action,actor_key,actor_claimed_user,changed_at,before,after,patch,reverted_version
+        FROM business_audit_log WHERE business_id=$1 ORDER BY version`
+    rows, err := db.Session.Query(context.Background(), query, businessId)
+    if err != nil {
+        return results, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        entry := AuditEntry{BusinessId: businessId}
+        if err := rows.Scan(&entry.Version, &entry.Action, &entry.Actor.ApiKey, &entry.Actor.ClaimedUser,
+            &entry.ChangedAt, &entry.Before, &entry.After, &entry.Patch, &entry.RevertedVersion); err != nil {
+            log.Error(fmt.Errorf("unable to read audit entry: %+v", err))
+            return []AuditEntry{}, err
+        }
+        results = append(results, entry)
+    }
+    return results, rows.Err()
+}
 
-    var (err error; query string)
+// function used to store manually entered data for a business. manual
+// data is stored as a separate source, with the fields that were set
+// stored in the source metadata
+func(db *Persistence) UpdateManualData(businessId uuid.UUID, data connectors.BusinessData,
+    fields []string) error {
+    log.Debug(fmt.Sprintf("updating manual data for business %s", businessId))
 
-    query = `DELETE FROM asset_metadata WHERE business_id=$1`
-    _, err = db.Session.Exec(context.Background(), query, businessId)
+    var (query string; err error)
+    meta := map[string]interface{}{"fields": fields}
+    query = `INSERT INTO asset_data(business_id,phone,website_live,source,open,meta,collected_at)
+        VALUES($1,$2,$3,$4,$5,$6,$7) ON CONFLICT (business_id,source) DO UPDATE
+        SET phone=$2, website_live=$3, open=$5, meta=$6, collected_at=$7`
+    _, err = db.Session.Exec(context.Background(), query, businessId, data.BusinessPhones,
+        data.WebsiteLive, reconciler.ManualSource, data.BusinessOpen, meta, time.Now())
     if err != nil {
+        log.Error(fmt.Errorf("unable to insert data into static table: %+v", err))
         return err
     }
 
-    query = `DELETE FROM asset_data WHERE business_id=$1`
-    _, err = db.Session.Exec(context.Background(), query, businessId)
+    query = `INSERT INTO asset_data_timeseries(business_id,source,phone,website_live,open,meta)
+        VALUES($1,$2,$3,$4,$5,$6)`
+    _, err = db.Session.Exec(context.Background(), query, businessId, reconciler.ManualSource,
+        data.BusinessPhones, data.WebsiteLive, data.BusinessOpen, meta)
     if err != nil {
+        log.Error(fmt.Errorf("unable to insert data into timeseries table: %+v", err))
         return err
     }
-    return nil
+
+    query = `UPDATE asset_metadata SET last_update=$1 WHERE business_id=$2`
+    _, err = db.Session.Exec(context.Background(), query, time.Now(), businessId)
+    return err
 }
 
 // function to retrieve static data for a given business with business ID
@@ -159,7 +572,7 @@ func(db *Persistence) GetStaticBusinessData(businessId uuid.UUID) ([]connectors.
     log.Debug(fmt.Sprintf("retrieving static data for business %s", businessId))
 
     results := []connectors.BusinessData{}
-    query := `SELECT phone,website_live,open,source FROM asset_data
+    query := `SELECT phone,website_live,open,source,collected_at FROM asset_data
         WHERE business_id=$1`
     rows, err := db.Session.Query(context.Background(), query, businessId)
     if err != nil {
@@ -175,7 +588,7 @@ func(db *Persistence) GetStaticBusinessData(businessId uuid.UUID) ([]connectors.
         // scan data into local variables
         var data connectors.BusinessData
         if err := rows.Scan(&data.BusinessPhones, &data.WebsiteLive, &data.BusinessOpen,
-            &data.Source); err != nil {
+            &data.Source, &data.CollectedAt); err != nil {
             log.Warn(fmt.Errorf("unable to scan data into local variables: %+v", err))
             continue
         }
@@ -185,21 +598,139 @@ func(db *Persistence) GetStaticBusinessData(businessId uuid.UUID) ([]connectors.
     return results, nil
 }
 
-// struct to that extends business data struct with event timestamp
+// function to retrieve the latest data of all sources for multiple
+// businesses. data is grouped by business and sorted by source
+func(db *Persistence) GetLatestSourceData(businessIds []uuid.UUID) (map[uuid.UUID][]connectors.BusinessData, error) {
+    log.Debug(fmt.Sprintf("retrieving static data for %d businesses", len(businessIds)))
+
+    results := map[uuid.UUID][]connectors.BusinessData{}
+    query := `SELECT business_id,phone,website_live,open,source,collected_at FROM asset_data
+        WHERE business_id = ANY($1) ORDER BY business_id, source`
+    rows, err := db.Session.Query(context.Background(), query, businessIds)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return results, nil
+        default:
+            return results, err
+        }
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var (businessId uuid.UUID; data connectors.BusinessData)
+        if err := rows.Scan(&businessId, &data.BusinessPhones, &data.WebsiteLive,
+            &data.BusinessOpen, &data.Source, &data.CollectedAt); err != nil {
+            log.Warn(fmt.Errorf("unable to scan data into local variables: %+v", err))
+            continue
+        }
+        results[businessId] = append(results[businessId], data)
+    }
+    return results, rows.Err()
+}
+
+// struct to that extends business data struct with event timestamp.
+// entries served from rollup tables also contain the rollup stats
 type TimeSeriesData struct {
     EventTimestamp time.Time `json:"event_timestamp"`
     connectors.BusinessData
+    Rollup *RollupStats `json:"rollup,omitempty"`
 }
 
-// function to retrive timeseries data from database
+// function to retrive timeseries data from database. raw data is only
+// retained for the retention period of the cleaner, so rollups are
+// served for any part of the time range before the oldest raw data
 func(db *Persistence) GetTimeSeriesData(businessId uuid.UUID, start,
     end time.Time) ([]TimeSeriesData, error) {
 
     log.Debug(fmt.Sprintf("retrieving timeseries business data for business %s for time range %s - %s",
         businessId, start, end))
+    rawStart, err := db.GetRawDataStart(businessId)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve start of raw data: %+v", err))
+        return []TimeSeriesData{}, err
+    }
+    if rawStart != nil && !start.Before(*rawStart) {
+        return db.GetRawTimeSeriesData(businessId, start, end)
+    }
+
+    rollupEnd := end
+    if rawStart != nil && rawStart.Before(end) {
+        rollupEnd = *rawStart
+    }
+    results, err := db.GetRollupTimeSeriesData(businessId, selectRollup(start, rollupEnd),
+        start, rollupEnd)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve rollup data: %+v", err))
+        return results, err
+    }
+    if rawStart == nil || !rawStart.Before(end) {
+        return results, nil
+    }
+    raw, err := db.GetRawTimeSeriesData(businessId, *rawStart, end)
+    if err != nil {
+        return results, err
+    }
+    return append(results, raw...), nil
+}
+
+// function used to retrieve the timestamp of the oldest raw timeseries
+// entry for a business. nil is returned if there is no raw data
+func(db *Persistence) GetRawDataStart(businessId uuid.UUID) (*time.Time, error) {
+    var rawStart *time.Time
+    query := `SELECT MIN(event_timestamp) FROM asset_data_timeseries WHERE business_id=$1`
+    if err := db.Session.QueryRow(context.Background(), query, businessId).Scan(&rawStart); err != nil {
+        return nil, err
+    }
+    return rawStart, nil
+}
+
+// function used to retrieve rollup data from a rollup table. rollup
+// buckets are converted into timeseries entries using the majority
+// value of each field and all phone numbers seen in the bucket
+func(db *Persistence) GetRollupTimeSeriesData(businessId uuid.UUID, rollup cleaner.Rollup,
+    start, end time.Time) ([]TimeSeriesData, error) {
+
+    log.Debug(fmt.Sprintf("retrieving %s rollups for business %s for time range %s - %s",
+        rollup.Precision, businessId, start, end))
+    results := []TimeSeriesData{}
+    query := fmt.Sprintf(`SELECT source,bucket,fraction_live,fraction_open,phones,samples
+        FROM %s WHERE business_id=$1 AND bucket > $2 AND bucket < $3
+        ORDER BY bucket`, rollup.Table)
+    rows, err := db.Session.Query(context.Background(), query, businessId,
+        start.Add(-rollup.Interval), end)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return results, nil
+        default:
+            return results, err
+        }
+    }
+
+    for rows.Next() {
+        var (data connectors.BusinessData; ts time.Time)
+        stats := RollupStats{Precision: rollup.Precision}
+        if err := rows.Scan(&data.Source, &ts, &stats.FractionLive, &stats.FractionOpen,
+            &data.BusinessPhones, &stats.Samples); err != nil {
+            log.Warn(fmt.Errorf("unable to scan data into local variables: %+v", err))
+            continue
+        }
+        data.WebsiteLive = stats.FractionLive >= 0.5
+        data.BusinessOpen = stats.FractionOpen >= 0.5
+        results = append(results, TimeSeriesData{EventTimestamp: ts, BusinessData: data,
+            Rollup: &stats})
+    }
+    return results, nil
+}
+
+// function to retrive raw timeseries data from database
+func(db *Persistence) GetRawTimeSeriesData(businessId uuid.UUID, start,
+    end time.Time) ([]TimeSeriesData, error) {
+
     results := []TimeSeriesData{}
     query := `SELECT phone,website_live,open,source,event_timestamp
-        FROM asset_data_timeseries WHERE business_id=$1 AND event_timestamp > $2 AND event_timestamp < $3`
+        FROM asset_data_timeseries WHERE business_id=$1 AND event_timestamp >= $2 AND event_timestamp < $3`
     // query rows from postgres database
     rows, err := db.Session.Query(context.Background(), query, businessId, start, end)
     if err != nil {
@@ -220,7 +751,7 @@ func(db *Persistence) GetTimeSeriesData(businessId uuid.UUID, start,
             continue
         }
         // adddata entry to results array
-        results = append(results, TimeSeriesData{ts, data})
+        results = append(results, TimeSeriesData{EventTimestamp: ts, BusinessData: data})
     }
     return results, nil
 }
@@ -263,7 +794,7 @@ func(db *Persistence) GetTimeSeriesDataLimited(businessId uuid.UUID, limit int)
                 continue
             }
             // adddata entry to results array
-            results = append(results, TimeSeriesData{ts, data})
+            results = append(results, TimeSeriesData{EventTimestamp: ts, BusinessData: data})
         }
     }
     return results, nil
@@ -290,4 +821,125 @@ func(db *Persistence) GetUniqueSources() ([]string, error) {
         sources = append(sources, source)
     }
     return sources, nil
-}
\ No newline at end of file
+}
+
+// function used to compute the availability of all businesses for a
+// single source. as with the timeseries data of a single business,
+// rollups are used for any part of the time range before the oldest raw
+// data of the source. the observations of each business are reduced to
+// the totals of their states in the database so that a single row is
+// read for each business
+func(db *Persistence) GetDirectoryAvailability(source string, start,
+    end time.Time) ([]BusinessAvailability, error) {
+
+    log.Debug(fmt.Sprintf("computing availability from source %s for time range %s - %s",
+        source, start, end))
+    results := []BusinessAvailability{}
+    rawStart, err := db.GetSourceRawDataStart(source)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve start of raw data: %+v", err))
+        return results, err
+    }
+    useRollups := rawStart == nil || start.Before(*rawStart)
+    rollupEnd := end
+    if rawStart != nil && rawStart.Before(end) {
+        rollupEnd = *rawStart
+    }
+    rollup := selectRollup(start, rollupEnd)
+
+    // observations are numbered to order the observations of a business
+    // consistently, and consecutive observations with the same state are
+    // grouped into runs to find the longest outage of each state
+    query := fmt.Sprintf(`WITH points AS (
+            SELECT business_id, bucket AS observed_at, fraction_live >= 0.5 AS live,
+                fraction_open >= 0.5 AS open
+            FROM %s WHERE $4::boolean AND source=$1 AND bucket > $5 AND bucket < $6
+            UNION ALL
+            SELECT business_id, event_timestamp, website_live, open
+            FROM asset_data_timeseries WHERE source=$1 AND event_timestamp >= $2 AND event_timestamp < $3
+        ), ordered AS (
+            SELECT business_id, observed_at, live, open,
+                ROW_NUMBER() OVER w AS position,
+                GREATEST(EXTRACT(EPOCH FROM COALESCE(LEAD(observed_at) OVER w, $7::timestamp)
+                    - observed_at)::double precision, 0) AS seconds,
+                LAG(live) OVER w AS previous_live, LAG(open) OVER w AS previous_open
+            FROM points WINDOW w AS (PARTITION BY business_id ORDER BY observed_at)
+        ), runs AS (
+            SELECT *,
+                COUNT(*) FILTER (WHERE previous_live IS DISTINCT FROM live) OVER w AS live_run,
+                COUNT(*) FILTER (WHERE previous_open IS DISTINCT FROM open) OVER w AS open_run
+            FROM ordered WINDOW w AS (PARTITION BY business_id ORDER BY position)
+        ), run_totals AS (
+            SELECT *,
+                SUM(seconds) OVER (PARTITION BY business_id, live_run) AS live_run_seconds,
+                SUM(seconds) OVER (PARTITION BY business_id, open_run) AS open_run_seconds
+            FROM runs
+        ), totals AS (
+            SELECT business_id, COUNT(*) AS observations, MIN(observed_at) AS first_observed,
+                MAX(observed_at) AS last_observed, SUM(seconds) AS observed_seconds,
+                COALESCE(SUM(seconds) FILTER (WHERE live), 0) AS live_seconds,
+                COUNT(*) FILTER (WHERE previous_live <> live) AS live_changes,
+                COUNT(*) FILTER (WHERE NOT live AND previous_live IS DISTINCT FROM live) AS live_outages,
+                COALESCE(MAX(live_run_seconds) FILTER (WHERE NOT live), 0) AS longest_live_outage,
+                (array_agg(live ORDER BY position DESC))[1] AS live_current,
+                COALESCE(SUM(seconds) FILTER (WHERE open), 0) AS open_seconds,
+                COUNT(*) FILTER (WHERE previous_open <> open) AS open_changes,
+                COUNT(*) FILTER (WHERE NOT open AND previous_open IS DISTINCT FROM open) AS open_outages,
+                COALESCE(MAX(open_run_seconds) FILTER (WHERE NOT open), 0) AS longest_open_outage,
+                (array_agg(open ORDER BY position DESC))[1] AS open_current
+            FROM run_totals GROUP BY business_id
+        )
+        SELECT m.business_id, m.business_name, COALESCE(t.observations, 0), t.first_observed,
+            t.last_observed, t.observed_seconds, t.live_seconds, t.live_changes, t.live_outages,
+            t.longest_live_outage, t.live_current, t.open_seconds, t.open_changes, t.open_outages,
+            t.longest_open_outage, t.open_current
+        FROM asset_metadata AS m LEFT JOIN totals AS t ON m.business_id = t.business_id
+        WHERE m.deleted_at IS NULL ORDER BY m.added, m.business_id`, rollup.Table)
+    rows, err := db.Session.Query(context.Background(), query, source, start, end, useRollups,
+        start.Add(-rollup.Interval), rollupEnd, availabilityEnd(end))
+    if err != nil {
+        return results, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var (availability BusinessAvailability; report SourceAvailability)
+        var (firstObserved, lastObserved *time.Time; observedSeconds *float64)
+        var (website, open stateTotals; websiteCurrent, openCurrent *bool)
+        var (websiteSeconds, openSeconds, websiteOutage, openOutage *float64)
+        var (websiteChanges, websiteOutages, openChanges, openOutages *int)
+        if err := rows.Scan(&availability.BusinessId, &availability.BusinessName, &report.Observations,
+            &firstObserved, &lastObserved, &observedSeconds, &websiteSeconds, &websiteChanges,
+            &websiteOutages, &websiteOutage, &websiteCurrent, &openSeconds, &openChanges,
+            &openOutages, &openOutage, &openCurrent); err != nil {
+            return results, err
+        }
+        availability.Sources = []SourceAvailability{}
+        if report.Observations > 0 {
+            website = stateTotals{upSeconds: *websiteSeconds, observedSeconds: *observedSeconds,
+                stateChanges: *websiteChanges, outages: *websiteOutages,
+                longestOutageSeconds: *websiteOutage, current: *websiteCurrent}
+            open = stateTotals{upSeconds: *openSeconds, observedSeconds: *observedSeconds,
+                stateChanges: *openChanges, outages: *openOutages,
+                longestOutageSeconds: *openOutage, current: *openCurrent}
+            report.Source = source
+            report.FirstObserved, report.LastObserved = *firstObserved, *lastObserved
+            report.Website, report.Open = website.report(), open.report()
+            availability.Sources = append(availability.Sources, report)
+        }
+        results = append(results, availability)
+    }
+    return results, rows.Err()
+}
+
+// function used to retrieve the timestamp of the oldest raw timeseries
+// entry for a source. nil is returned if there is no raw data
+func(db *Persistence) GetSourceRawDataStart(source string) (*time.Time, error) {
+    var rawStart *time.Time
+    query := `SELECT MIN(event_timestamp) FROM asset_data_timeseries WHERE source=$1`
+    if err := db.Session.QueryRow(context.Background(), query, source).Scan(&rawStart); err != nil {
+        return nil, err
+    }
+    return rawStart, nil
+}
+
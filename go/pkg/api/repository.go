@@ -0,0 +1,78 @@
+package api
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v4/pgxpool"
+
+    "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
+)
+
+// interface used to store businesses and their metadata. all changes
+// to a business are recorded in its audit log along with the actor, and
+// each change increments the version of the business. changes made with
+// a precondition fail with ErrVersionConflict if the business has been
+// modified since the versions of the precondition
+type BusinessRepository interface {
+    CreateBusiness(request NewBusinessRequest, actor Actor) error
+    ImportBusinesses(requests []NewBusinessRequest, actor Actor) ([]uuid.UUID, error)
+    GetBusinesses() ([]BusinessInfo, error)
+    GetImportCandidates(domains, names []string) ([]BusinessInfo, error)
+    ListBusinesses(query BusinessQuery) (BusinessPage, error)
+    SearchBusinesses(query SearchQuery) ([]BusinessSearchResult, error)
+    GetBusinessById(businessId uuid.UUID) (BusinessInfo, error)
+    UpdateBusinessURI(uri string, businessId uuid.UUID, actor Actor, ifMatch Precondition) (AuditEntry, error)
+    UpdateBusinessMetadata(meta map[string]interface{}, operation []map[string]interface{},
+        businessId uuid.UUID, actor Actor, ifMatch Precondition) (AuditEntry, error)
+    DeleteBusiness(businessId uuid.UUID, actor Actor, ifMatch Precondition) error
+    RestoreBusiness(businessId uuid.UUID, actor Actor) error
+    GetBusinessHistory(businessId uuid.UUID) ([]AuditEntry, error)
+    RevertBusiness(businessId uuid.UUID, version int, actor Actor) (AuditEntry, error)
+}
+
+// interface used to store the latest data reported by each source
+type SourceDataRepository interface {
+    UpdateManualData(businessId uuid.UUID, data connectors.BusinessData, fields []string) error
+    GetStaticBusinessData(businessId uuid.UUID) ([]connectors.BusinessData, error)
+    GetLatestSourceData(businessIds []uuid.UUID) (map[uuid.UUID][]connectors.BusinessData, error)
+    GetUniqueSources() ([]string, error)
+}
+
+// interface used to retrieve the history of data reported by sources
+type TimeseriesRepository interface {
+    GetTimeSeriesData(businessId uuid.UUID, start, end time.Time) ([]TimeSeriesData, error)
+    GetTimeSeriesDataLimited(businessId uuid.UUID, limit int) ([]TimeSeriesData, error)
+    GetDirectoryAvailability(source string, start, end time.Time) ([]BusinessAvailability, error)
+}
+
+// interface containing all repositories used by the API. golden
+// records are read and recomputed through the reconciler repository
+type Repository interface {
+    BusinessRepository
+    SourceDataRepository
+    TimeseriesRepository
+    reconciler.Repository
+}
+
+// define name of reconciler persistence when embedded alongside the
+// persistence of the API
+type goldenRecordPersistence = reconciler.Persistence
+
+// struct used to combine the postgres persistence of the API with the
+// postgres persistence of the reconciler into a single repository
+type postgresRepository struct {
+    *Persistence
+    *goldenRecordPersistence
+}
+
+// function used to generate a new postgres repository using the
+// shared connection pool of the service
+func NewRepository(pool *pgxpool.Pool) Repository {
+    return postgresRepository{
+        NewPersistenceWithPool(pool),
+        &goldenRecordPersistence{BasePostgresPersistence: utils.NewPersistenceWithPool(pool)},
+    }
+}
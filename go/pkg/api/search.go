@@ -0,0 +1,286 @@
+package api
+
+import (
+    "sort"
+    "html"
+    "errors"
+    "regexp"
+    "strings"
+    "strconv"
+    "encoding/json"
+    neturl "net/url"
+)
+
+var (
+    // define custom errors
+    ErrInvalidSearch = errors.New("Invalid search query")
+
+    // define settings used to search businesses
+    DefaultSearchLimit = 20
+    MaxSearchLimit = 100
+    DefaultSearchScore = 0.3
+    DefaultDuplicateScore = 0.5
+
+    // define pattern used to split text into words. words are
+    // compared and highlighted individually
+    wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+// struct used to store the settings used to search businesses
+type SearchQuery struct {
+    Text     string
+    Limit    int
+    MinScore float64
+}
+
+// struct used to store a business matching a search query. the score
+// is between 0 and 1, and matched words are wrapped in <em> tags in
+// the highlights of each matching field
+type BusinessSearchResult struct {
+    BusinessInfo
+    Score      float64           `json:"score"`
+    Highlights map[string]string `json:"highlights"`
+}
+
+// function used to parse the settings used to search businesses from
+// query parameters
+func ParseSearchQuery(values neturl.Values, defaultScore float64) (SearchQuery, error) {
+    query := SearchQuery{
+        Text: strings.TrimSpace(values.Get("q")),
+        Limit: DefaultSearchLimit,
+        MinScore: defaultScore,
+    }
+    if len(query.Text) == 0 {
+        return query, ErrInvalidSearch
+    }
+    if limit := values.Get("limit"); len(limit) > 0 {
+        value, err := strconv.Atoi(limit)
+        if err != nil || value < 1 || value > MaxSearchLimit {
+            return query, ErrInvalidLimit
+        }
+        query.Limit = value
+    }
+    if score := values.Get("min_score"); len(score) > 0 {
+        value, err := strconv.ParseFloat(score, 64)
+        if err != nil || value <= 0 || value > 1 {
+            return query, ErrInvalidSearch
+        }
+        query.MinScore = value
+    }
+    return query, nil
+}
+
+// function used to split text into lower-case words
+func searchWords(value string) []string {
+    return wordPattern.FindAllString(strings.ToLower(value), -1)
+}
+
+// function used to generate the trigrams of a text. as in the postgres
+// pg_trgm extension, each word is padded with two spaces at the start
+// and one space at the end before being split into trigrams
+func trigrams(value string) map[string]bool {
+    results := map[string]bool{}
+    for _, word := range(searchWords(value)) {
+        padded := []rune("  " + word + " ")
+        for i := 0; i + 3 <= len(padded); i++ {
+            results[string(padded[i:i + 3])] = true
+        }
+    }
+    return results
+}
+
+// function used to compute the trigram similarity of two texts i.e. the
+// number of shared trigrams divided by the number of distinct trigrams
+func TrigramSimilarity(a, b string) float64 {
+    first, second := trigrams(a), trigrams(b)
+    if len(first) == 0 || len(second) == 0 {
+        return 0
+    }
+    shared := 0
+    for trigram := range(first) {
+        if second[trigram] {
+            shared++
+        }
+    }
+    return float64(shared) / float64(len(first) + len(second) - shared)
+}
+
+// function used to compute the greatest trigram similarity between a
+// query and any sequence of consecutive words in a text. this matches
+// queries against parts of longer texts such as metadata
+func WordSimilarity(query, text string) float64 {
+    words := searchWords(text)
+    size := len(searchWords(query))
+    best := 0.0
+    for length := 1; length <= size + 1 && length <= len(words); length++ {
+        for i := 0; i + length <= len(words); i++ {
+            if score := TrigramSimilarity(query, strings.Join(words[i:i + length], " ")); score > best {
+                best = score
+            }
+        }
+    }
+    return best
+}
+
+// function used to retrieve the searchable fields of a business. string
+// values of metadata are searched individually so that they can be
+// highlighted under their key
+func searchFields(info BusinessInfo) map[string]string {
+    fields := map[string]string{
+        "business_name": info.BusinessName,
+        "business_uri": info.BusinessURI,
+    }
+    for key, value := range(info.Metadata) {
+        switch typed := value.(type) {
+        case string:
+            fields["metadata." + key] = typed
+        case map[string]interface{}, []interface{}:
+            encoded, _ := json.Marshal(typed)
+            fields["metadata." + key] = string(encoded)
+        }
+    }
+    return fields
+}
+
+// function used to compute the search score of a business. the name and
+// URI are compared as a whole and by word, while the address and other
+// metadata are only compared by word
+func SearchScore(info BusinessInfo, text string) float64 {
+    best := 0.0
+    for field, value := range(searchFields(info)) {
+        score := WordSimilarity(text, value)
+        if field == "business_name" || field == "business_uri" {
+            if similarity := TrigramSimilarity(text, value); similarity > score {
+                score = similarity
+            }
+        }
+        if score > best {
+            best = score
+        }
+    }
+    return best
+}
+
+// function used to wrap words of a value that match a search query in
+// <em> tags. words match if they contain a query word or are similar
+// to a query word. the remaining text is escaped
+func highlight(value string, words []string, minScore float64) (string, bool) {
+    var builder strings.Builder
+    matched, last := false, 0
+    for _, position := range(wordPattern.FindAllStringIndex(value, -1)) {
+        word := value[position[0]:position[1]]
+        builder.WriteString(html.EscapeString(value[last:position[0]]))
+        last = position[1]
+
+        lower := strings.ToLower(word)
+        match := false
+        for _, query := range(words) {
+            // short words are only matched by similarity to avoid
+            // highlighting every word containing a single letter
+            if (len(query) > 2 && strings.Contains(lower, query)) ||
+                TrigramSimilarity(query, lower) >= minScore {
+                match = true
+                break
+            }
+        }
+        if match {
+            matched = true
+            builder.WriteString("<em>" + word + "</em>")
+        } else {
+            builder.WriteString(word)
+        }
+    }
+    builder.WriteString(html.EscapeString(value[last:]))
+    return builder.String(), matched
+}
+
+// function used to generate the highlighted fields of a business that
+// match a search query
+func Highlight(info BusinessInfo, query SearchQuery) map[string]string {
+    highlights := map[string]string{}
+    words := searchWords(query.Text)
+    for field, value := range(searchFields(info)) {
+        if highlighted, ok := highlight(value, words, query.MinScore); ok {
+            highlights[field] = highlighted
+        }
+    }
+    return highlights
+}
+
+// function used to search a list of businesses. used by repositories that
+// cannot search businesses when they are retrieved i.e. in-memory repositories
+func(query SearchQuery) Search(businesses []BusinessInfo) []BusinessSearchResult {
+    results := []BusinessSearchResult{}
+    for _, info := range(businesses) {
+        if score := SearchScore(info, query.Text); score >= query.MinScore {
+            results = append(results, BusinessSearchResult{BusinessInfo: info, Score: score})
+        }
+    }
+    sortSearchResults(results)
+    if len(results) > query.Limit {
+        results = results[:query.Limit]
+    }
+    return results
+}
+
+// function used to sort search results by score, then by name
+func sortSearchResults(results []BusinessSearchResult) {
+    sort.SliceStable(results, func(i, j int) bool {
+        if results[i].Score != results[j].Score {
+            return results[i].Score > results[j].Score
+        }
+        if results[i].BusinessName != results[j].BusinessName {
+            return results[i].BusinessName < results[j].BusinessName
+        }
+        return results[i].BusinessId.String() < results[j].BusinessId.String()
+    })
+}
+
+// function used to find possible duplicates of a business. businesses
+// with a similar name are found by searching, and businesses with
+// the same domain are added with a score of 1
+func findDuplicates(db Repository, business BusinessInfo,
+    query SearchQuery) ([]BusinessSearchResult, error) {
+
+    matches, err := db.SearchBusinesses(SearchQuery{Text: query.Text,
+        Limit: query.Limit + 1, MinScore: query.MinScore})
+    if err != nil {
+        return []BusinessSearchResult{}, err
+    }
+    found := map[string]int{}
+    results := []BusinessSearchResult{}
+    for _, match := range(matches) {
+        if match.BusinessId == business.BusinessId {
+            continue
+        }
+        found[match.BusinessId.String()] = len(results)
+        results = append(results, match)
+    }
+
+    if domain := NormaliseDomain(business.BusinessURI); len(domain) > 0 {
+        page, err := db.ListBusinesses(BusinessQuery{Limit: query.Limit, Sort: SortByAdded,
+            Domain: domain})
+        if err != nil {
+            return []BusinessSearchResult{}, err
+        }
+        for _, info := range(page.Businesses) {
+            if info.BusinessId == business.BusinessId {
+                continue
+            }
+            if index, ok := found[info.BusinessId.String()]; ok {
+                results[index].Score = 1
+                continue
+            }
+            results = append(results, BusinessSearchResult{BusinessInfo: info, Score: 1})
+        }
+    }
+
+    sortSearchResults(results)
+    if len(results) > query.Limit {
+        results = results[:query.Limit]
+    }
+    for i := range(results) {
+        results[i].Highlights = Highlight(results[i].BusinessInfo, query)
+    }
+    return results, nil
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+    "strings"
+    "testing"
+    neturl "net/url"
+)
+
+func TestTrigramSimilarity(t *testing.T) {
+    tests := []struct {
+        a        string
+        b        string
+        expected float64
+    }{
+        {"dairy", "dairy", 1},
+        {"Dairy", "DAIRY!", 1},
+        {"cat", "cart", 2.0 / 7},
+        {"cafe", "café", 3.0 / 7},
+        {"abc", "xyz", 0},
+        {"", "dairy", 0},
+        {"...", "...", 0},
+    }
+    for _, test := range(tests) {
+        if result := TrigramSimilarity(test.a, test.b); !floatsEqual(result, test.expected) {
+            t.Errorf("%q and %q: expected %.3f, got %.3f", test.a, test.b, test.expected, result)
+        }
+    }
+}
+
+func TestSearchWords(t *testing.T) {
+    tests := []struct {
+        value    string
+        expected []string
+    }{
+        {"", []string{}},
+        {"Joe's Café, 2nd St.", []string{"joe", "s", "café", "2nd", "st"}},
+        {"  --  ", []string{}},
+    }
+    for _, test := range(tests) {
+        if result := searchWords(test.value); strings.Join(result, ",") != strings.Join(test.expected, ",") {
+            t.Errorf("%q: expected %v, got %v", test.value, test.expected, result)
+        }
+    }
+}
+
+func TestParseSearchQuery(t *testing.T) {
+    tests := []struct {
+        query    string
+        expected SearchQuery
+        err      error
+    }{
+        {"q=dairy", SearchQuery{Text: "dairy", Limit: DefaultSearchLimit, MinScore: DefaultSearchScore}, nil},
+        {"q=+dairy+farm+&limit=5&min_score=0.8", SearchQuery{Text: "dairy farm", Limit: 5, MinScore: 0.8}, nil},
+        {"q=dairy&min_score=1", SearchQuery{Text: "dairy", Limit: DefaultSearchLimit, MinScore: 1}, nil},
+        {"", SearchQuery{}, ErrInvalidSearch},
+        {"q=++", SearchQuery{}, ErrInvalidSearch},
+        {"q=dairy&limit=0", SearchQuery{}, ErrInvalidLimit},
+        {"q=dairy&limit=101", SearchQuery{}, ErrInvalidLimit},
+        {"q=dairy&limit=ten", SearchQuery{}, ErrInvalidLimit},
+        {"q=dairy&min_score=0", SearchQuery{}, ErrInvalidSearch},
+        {"q=dairy&min_score=1.5", SearchQuery{}, ErrInvalidSearch},
+        {"q=dairy&min_score=high", SearchQuery{}, ErrInvalidSearch},
+    }
+    for _, test := range(tests) {
+        values, err := neturl.ParseQuery(test.query)
+        if err != nil {
+            t.Fatalf("unable to parse query %q: %+v", test.query, err)
+        }
+        query, err := ParseSearchQuery(values, DefaultSearchScore)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.query, test.err, err)
+            continue
+        }
+        if err == nil && query != test.expected {
+            t.Errorf("%q: expected %+v, got %+v", test.query, test.expected, query)
+        }
+    }
+}
+
+func TestSearch(t *testing.T) {
+    businesses := []BusinessInfo{
+        {BusinessName: "Texas Dairy"},
+        {BusinessName: "Bakery"},
+        {BusinessName: "Dairy Farm"},
+        {BusinessName: "Corner Shop", Metadata: map[string]interface{}{"address": "12 Dairy Lane"}},
+        {BusinessName: "Daisy Florist"},
+    }
+
+    tests := []struct {
+        name     string
+        query    SearchQuery
+        expected []string
+    }{
+        {"matches by name and metadata", SearchQuery{Text: "dairy", Limit: 10, MinScore: 0.5},
+            []string{"Corner Shop", "Dairy Farm", "Texas Dairy"}},
+        {"similar names score lower", SearchQuery{Text: "dairy", Limit: 10, MinScore: 0.3},
+            []string{"Corner Shop", "Dairy Farm", "Texas Dairy", "Daisy Florist"}},
+        {"limited results", SearchQuery{Text: "dairy", Limit: 2, MinScore: 0.3},
+            []string{"Corner Shop", "Dairy Farm"}},
+        {"similar words", SearchQuery{Text: "daisy", Limit: 10, MinScore: 0.3},
+            []string{"Daisy Florist", "Corner Shop", "Dairy Farm", "Texas Dairy"}},
+        {"no matches", SearchQuery{Text: "butcher", Limit: 10, MinScore: 0.3}, []string{}},
+    }
+    for _, test := range(tests) {
+        names := []string{}
+        for _, result := range(test.query.Search(businesses)) {
+            names = append(names, result.BusinessName)
+        }
+        if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+            t.Errorf("%s: expected %v, got %v", test.name, test.expected, names)
+        }
+    }
+}
+
+func TestHighlight(t *testing.T) {
+    tests := []struct {
+        value    string
+        query    string
+        expected string
+        matched  bool
+    }{
+        {"Texas Dairy", "dairy", "Texas <em>Dairy</em>", true},
+        {"Green Café & Bar", "cafe", "Green <em>Café</em> &amp; Bar", true},
+        {"Dairyland <Farm>", "dairy", "<em>Dairyland</em> &lt;Farm&gt;", true},
+        {"A Bakery", "a", "<em>A</em> Bakery", true},
+        {"Bakery", "dairy", "Bakery", false},
+    }
+    for _, test := range(tests) {
+        result, matched := highlight(test.value, searchWords(test.query), DefaultSearchScore)
+        if result != test.expected || matched != test.matched {
+            t.Errorf("%q for %q: expected %q (%v), got %q (%v)", test.value, test.query, test.expected,
+                test.matched, result, matched)
+        }
+    }
+}
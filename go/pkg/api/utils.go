@@ -4,10 +4,15 @@ import (
     "fmt"
     "errors"
     "time"
+    "net/http"
+    "encoding/csv"
     "encoding/json"
 
+    "github.com/gin-gonic/gin"
     log "github.com/sirupsen/logrus"
     jsonpatch "github.com/evanphx/json-patch"
+
+    "texas_real_foods/pkg/cleaner"
 )
 
 var (
@@ -111,4 +116,29 @@ func ParseTimeRange(start, end string) (TimeRange, error) {
     }
     timeRange := TimeRange{startTimestamp, endTimestamp}
     return timeRange, nil
-}
\ No newline at end of file
+}
+
+// function used to write CSV records to a response as a file download
+func writeCSV(ctx *gin.Context, filename string, records [][]string) {
+    ctx.Header("Content-Type", "text/csv")
+    ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+    ctx.Status(http.StatusOK)
+
+    writer := csv.NewWriter(ctx.Writer)
+    if err := writer.WriteAll(records); err != nil {
+        log.Error(fmt.Errorf("unable to write CSV response: %+v", err))
+    }
+}
+
+// function used to select the rollup table used to serve data for a
+// given time range. hourly rollups are used unless the time range
+// exceeds the configured threshold
+func selectRollup(start, end time.Time) cleaner.Rollup {
+    selected := cleaner.Rollups[0]
+    for _, rollup := range(cleaner.Rollups) {
+        if rollup.Interval > selected.Interval && end.Sub(start) > rollupDailyThreshold {
+            selected = rollup
+        }
+    }
+    return selected
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+    "time"
+    "testing"
+)
+
+func TestSelectRollup(t *testing.T) {
+    threshold := rollupDailyThreshold
+    defer func() { rollupDailyThreshold = threshold }()
+    rollupDailyThreshold = 31 * 24 * time.Hour
+    start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        end      time.Time
+        expected string
+    }{
+        {start, "hour"},
+        {start.Add(24 * time.Hour), "hour"},
+        {start.Add(31 * 24 * time.Hour), "hour"},
+        {start.Add(31 * 24 * time.Hour + time.Hour), "day"},
+        {start.AddDate(1, 0, 0), "day"},
+    }
+    for _, test := range(tests) {
+        if rollup := selectRollup(start, test.end); rollup.Precision != test.expected {
+            t.Errorf("%s - %s: expected %s rollups, got %s", start, test.end, test.expected, rollup.Precision)
+        }
+    }
+}
@@ -9,16 +9,17 @@ import (
     log "github.com/sirupsen/logrus"
 )
 
-var postgresURL string
+// define repository shared by all requests
+var repository Repository
 
 type Authenticator struct{
-    PostgresURL string
+    Repository Repository
     Engine *gin.Engine
     ListenAddress string
     ListenPort int
 }
 
-func New(postgresUrl, listenAddress string, listenPort int) *Authenticator {
+func New(repo Repository, listenAddress string, listenPort int) *Authenticator {
     // create new instance of authenticator
     router := gin.Default()
     router.Use(cors.New(cors.Config{
@@ -29,10 +30,10 @@ func New(postgresUrl, listenAddress string, listenPort int) *Authenticator {
     }))
 
     router.Any("/authenticate", Authenticate)
-    // set postgres url for global user
-    postgresURL = postgresUrl
+    // set repository for global user
+    repository = repo
     return &Authenticator{
-        PostgresURL: postgresUrl,
+        Repository: repo,
         Engine: router,
         ListenAddress: listenAddress,
         ListenPort: listenPort,
@@ -56,16 +57,7 @@ func Authenticate(ctx *gin.Context) {
         return
     }
 
-    // create new persistence instance and connect to postgres
-    db := NewPersistence(postgresURL)
-    conn, err := db.Connect()
-    if err != nil {
-        log.Error(fmt.Errorf("unable to connect to postgres server: %+v", err))
-        ctx.JSON(http.StatusInternalServerError,
-            gin.H{"http_code": http.StatusInternalServerError, "message": "Internal server error"})
-        return
-    }
-    defer conn.Close()
+    db := repository
 
     // validate API key in postgres server
     valid, err := db.IsValidApiKey(apiKey)
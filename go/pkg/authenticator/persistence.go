@@ -5,6 +5,7 @@ import (
     "context"
 
     "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
@@ -22,6 +23,14 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
 // function to retrieve all API access keys from database
 func(db *Persistence) GetAPIKeys() ([]string, error) {
     log.Debug("retrieving API keys")
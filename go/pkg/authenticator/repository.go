@@ -0,0 +1,8 @@
+package authenticator
+
+// interface used to validate API keys. postgres persistence is the
+// default implementation
+type Repository interface {
+    GetAPIKeys() ([]string, error)
+    IsValidApiKey(key string) (bool, error)
+}
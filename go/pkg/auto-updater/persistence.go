@@ -5,6 +5,7 @@ import (
     "time"
     "context"
 
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
@@ -24,18 +25,32 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
 // function to update business data in the database. note that
 // updates are done as inserts i.e. existing values are overwritten
 func(db *Persistence) UpdateBusinessData(update connectors.BusinessUpdate) error {
     log.Debug(fmt.Sprintf("updating business %+v", update))
 
     var (query string; err error)
+    // set collection timestamp if not set by connector
+    collectedAt := update.Data.CollectedAt
+    if collectedAt.IsZero() {
+        collectedAt = time.Now()
+    }
     // execute query to insert new data arguments
-    query = `INSERT INTO asset_data(business_id,phone,website_live,source,open)
-        VALUES($1,$2,$3,$4,$5) ON CONFLICT (business_id,source) DO UPDATE
-        SET phone=$2, website_live=$3, open=$5`
+    query = `INSERT INTO asset_data(business_id,phone,website_live,source,open,collected_at)
+        VALUES($1,$2,$3,$4,$5,$6) ON CONFLICT (business_id,source) DO UPDATE
+        SET phone=$2, website_live=$3, open=$5, collected_at=$6`
     _, err = db.Session.Exec(context.Background(), query, update.Meta.BusinessId,
-        update.Data.BusinessPhones, update.Data.WebsiteLive, update.Data.Source, update.Data.BusinessOpen)
+        update.Data.BusinessPhones, update.Data.WebsiteLive, update.Data.Source, update.Data.BusinessOpen,
+        collectedAt)
     if err != nil {
         log.Error(fmt.Errorf("unable to insert data into static table: %+v", err))
         return err
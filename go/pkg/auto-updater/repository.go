@@ -0,0 +1,11 @@
+package auto_updater
+
+import (
+    "texas_real_foods/pkg/connectors"
+)
+
+// interface used to store data collected by connectors. postgres
+// persistence is the default implementation
+type Repository interface {
+    UpdateBusinessData(update connectors.BusinessUpdate) error
+}
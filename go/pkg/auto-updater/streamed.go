@@ -5,34 +5,47 @@ import (
     "time"
     "sync"
 
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/reconciler"
 )
 
 // function used to create new streaming auto updater. the auto-updater
 // takes a variety of components to operate. particularly
 // important is the instance of a AutoUpdateDataConnector
 // interface implementation, which is used to collect data from
-// a particular data source (yelp, google, website etc)
+// a particular data source (yelp, google, website etc). all
+// updates share the given connection pool
 func NewStreamedAutoUpdater(connector connectors.StreamedAutoUpdateDataConnector,
-    collectionPeriod int, postgresUrl string, apiConfig utils.APIDependencyConfig) *AutoUpdater {
+    collectionPeriod int, pool *pgxpool.Pool, apiConfig utils.APIDependencyConfig,
+    reconcilerConfig reconciler.Config) *AutoUpdater {
     return &AutoUpdater{
-        PostgresURL: postgresUrl,
+        Repository: NewPersistenceWithPool(pool),
+        Records: reconciler.NewPersistenceWithPool(pool),
         StreamedConnector: connector,
         CollectionPeriodMinutes: collectionPeriod,
         TRFApiConfig: apiConfig,
+        ReconcilerConfig: reconcilerConfig,
     }
 }
 
-// function used to process a single update for a given business
-func(updater *AutoUpdater) ProcessSingleBusinessUpdate(db *Persistence,
-    update connectors.BusinessUpdate) error {
+// function used to process a single update for a given business.
+// the golden record of the business is recomputed once the new
+// data has been written
+func(updater *AutoUpdater) ProcessSingleBusinessUpdate(db Repository,
+    merger *reconciler.Reconciler, update connectors.BusinessUpdate) error {
     // iterate over businesses and update in database
     if err := db.UpdateBusinessData(update); err != nil {
         log.Warn(fmt.Errorf("unable to update business '%s': %+v",
             update.Meta.BusinessName, err))
+        return nil
+    }
+    if _, err := merger.Reconcile(update.Meta.BusinessId); err != nil {
+        log.Warn(fmt.Errorf("unable to reconcile golden record for business '%s': %+v",
+            update.Meta.BusinessName, err))
     }
     return nil
 }
@@ -45,20 +58,13 @@ func(updater *AutoUpdater) RunWithStreaming() {
     ticker := time.NewTicker(time.Duration(updater.CollectionPeriodMinutes) * time.Minute)
     quitChan := make(chan bool)
 
-    // establish new connection to postgres persistence
-    db := NewPersistence(updater.PostgresURL)
-    conn, err := db.Connect()
-    if err != nil {
-        log.Error(fmt.Errorf("unable to connect to postgres server: %+v", err))
-        panic(err)
-    }
-    defer conn.Close()
+    merger := reconciler.New(updater.Records, updater.ReconcilerConfig)
 
     // generate new event queue to process business update
     updates := make(chan connectors.BusinessUpdate)
     go func() {
         for update := range(updates) {
-            if err := updater.ProcessSingleBusinessUpdate(db, update); err != nil {
+            if err := updater.ProcessSingleBusinessUpdate(updater.Repository, merger, update); err != nil {
                 log.Error(fmt.Errorf("unable to updated business %s: %+v",
                     update.Meta.BusinessName, err))
             }
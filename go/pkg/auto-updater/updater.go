@@ -5,10 +5,12 @@ import (
     "time"
     "sync"
 
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/reconciler"
     api "texas_real_foods/pkg/utils/api_accessors"
 )
 
@@ -17,25 +19,43 @@ import (
 // takes a variety of components to operate. particularly
 // important is the instance of a AutoUpdateDataConnector
 // interface implementation, which is used to collect data from
-// a particular data source (yelp, google, website etc)
+// a particular data source (yelp, google, website etc). golden
+// records are recomputed using the given reconciliation settings
+// whenever new data is written. all collection jobs share the
+// given connection pool
 func New(connector connectors.AutoUpdateDataConnector, collectionPeriod int,
-    postgresUrl string, apiConfig utils.APIDependencyConfig) *AutoUpdater {
+    pool *pgxpool.Pool, apiConfig utils.APIDependencyConfig,
+    reconcilerConfig reconciler.Config) *AutoUpdater {
+    return NewWithRepository(connector, collectionPeriod, NewPersistenceWithPool(pool),
+        reconciler.NewPersistenceWithPool(pool), apiConfig, reconcilerConfig)
+}
+
+// function used to create new auto updater that stores data in the
+// given repositories i.e. in-memory
+// repositories for local development
+func NewWithRepository(connector connectors.AutoUpdateDataConnector, collectionPeriod int,
+    repo Repository, records reconciler.Repository, apiConfig utils.APIDependencyConfig,
+    reconcilerConfig reconciler.Config) *AutoUpdater {
     return &AutoUpdater{
-        PostgresURL: postgresUrl,
+        Repository: repo,
+        Records: records,
         DataConnector: connector,
         CollectionPeriodMinutes: collectionPeriod,
         TRFApiConfig: apiConfig,
+        ReconcilerConfig: reconcilerConfig,
     }
 }
 
 // struct to store components for auto updater. note that each
 // instance has a separate data connector and notification engine
 type AutoUpdater struct{
-    PostgresURL             string
+    Repository              Repository
+    Records                 reconciler.Repository
     CollectionPeriodMinutes int
     TRFApiConfig            utils.APIDependencyConfig
     DataConnector           connectors.AutoUpdateDataConnector
     StreamedConnector       connectors.StreamedAutoUpdateDataConnector
+    ReconcilerConfig        reconciler.Config
 }
 
 // function used to retrieve business metadata for all stored
@@ -53,21 +73,11 @@ func(updater *AutoUpdater) GetCurrentBusinesses(host string, port *int) ([]conne
 
 // function used to process business data updates
 func(updater *AutoUpdater) ProcessBusinessUpdates(updates []connectors.BusinessUpdate) error {
-    // establish new connection to postgres persistence
-    db := NewPersistence(updater.PostgresURL)
-    conn, err := db.Connect()
-    if err != nil {
-        log.Error(fmt.Errorf("unable to connect to postgres server: %+v", err))
-        return err
-    }
-    defer conn.Close()
+    merger := reconciler.New(updater.Records, updater.ReconcilerConfig)
 
     // iterate over businesses and update in database
     for _, update := range(updates) {
-        if err := db.UpdateBusinessData(update); err != nil {
-            log.Warn(fmt.Errorf("unable to update business '%s': %+v",
-                update.Meta.BusinessName, err))
-        }
+        updater.ProcessSingleBusinessUpdate(updater.Repository, merger, update)
     }
     return nil
 }
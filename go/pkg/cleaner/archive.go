@@ -0,0 +1,426 @@
+package cleaner
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "hash"
+    "time"
+    "errors"
+    "strings"
+    "strconv"
+    "io/ioutil"
+    "compress/gzip"
+    "crypto/sha256"
+    "encoding/csv"
+    "encoding/hex"
+    "encoding/json"
+
+    "github.com/google/uuid"
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define custom errors
+    ErrInvalidArchiveFormat = errors.New("Invalid archive format")
+    ErrArchiveVerification  = errors.New("Unable to verify archive")
+
+    // define supported archive formats
+    ArchiveFormats = []string{"jsonl", "csv"}
+
+    // define header used for CSV archives
+    archiveCSVHeader = []string{"business_id", "event_timestamp", "source", "website_live",
+        "phone", "meta", "open"}
+)
+
+// struct used to store a single archived timeseries row
+type ArchiveRecord struct {
+    BusinessId     uuid.UUID              `json:"business_id"`
+    EventTimestamp time.Time              `json:"event_timestamp"`
+    Source         string                 `json:"source"`
+    WebsiteLive    *bool                  `json:"website_live"`
+    Phones         []string               `json:"phone"`
+    Meta           map[string]interface{} `json:"meta"`
+    Open           bool                   `json:"open"`
+}
+
+// function used to stream records into archives. the handler is called
+// for each record in order, and streaming stops if the handler fails
+type RecordStream func(handle func(ArchiveRecord) error) error
+
+// struct used to store the details of a written archive
+type Archive struct {
+    Name     string
+    Records  int
+    Checksum string
+}
+
+// function used to generate a new archiver
+func NewArchiver(storage ArchiveStorage, format string) (*Archiver, error) {
+    if !stringSliceContains(ArchiveFormats, format) {
+        return nil, ErrInvalidArchiveFormat
+    }
+    return &Archiver{Storage: storage, Format: format}, nil
+}
+
+// struct used to export timeseries rows into compressed archives
+type Archiver struct {
+    Storage ArchiveStorage
+    Format  string
+}
+
+// function used to generate the name of an archive. archives are
+// partitioned by the month of the archived rows, and named after
+// the cutoff of the cleaner run that generated them
+func ArchiveName(month, cutoff time.Time, format string) string {
+    return fmt.Sprintf("%s/asset_data_timeseries-%s.%s.gz", month.Format("2006-01"),
+        cutoff.Format("20060102"), format)
+}
+
+// function used to determine the format of an archive from its name
+func ArchiveFormat(name string) (string, error) {
+    for _, format := range(ArchiveFormats) {
+        if strings.HasSuffix(name, fmt.Sprintf(".%s.gz", format)) {
+            return format, nil
+        }
+    }
+    return "", ErrInvalidArchiveFormat
+}
+
+// function used to stream records into archives partitioned by month.
+// records must be ordered by event timestamp, so that each archive is
+// written to a temporary file and uploaded once all records of its month
+// have been written. each archive is read back from storage and verified
+// against the written checksum and record count before the next archive
+// is written, so that rows are never deleted unless their archive can be
+// restored
+func(archiver *Archiver) Archive(stream RecordStream, cutoff time.Time) ([]Archive, error) {
+    archives := []Archive{}
+    var writer *archiveWriter
+    // function used to upload and verify the archive currently written
+    complete := func() error {
+        completed := writer
+        writer = nil
+        defer completed.Remove()
+        archive, err := archiver.upload(completed)
+        if err != nil {
+            return err
+        }
+        log.Info(fmt.Sprintf("archived %d records to %s", archive.Records, archive.Name))
+        archives = append(archives, archive)
+        return nil
+    }
+
+    err := stream(func(record ArchiveRecord) error {
+        name := ArchiveName(record.EventTimestamp, cutoff, archiver.Format)
+        if writer != nil && writer.Name != name {
+            if err := complete(); err != nil {
+                return err
+            }
+        }
+        if writer == nil {
+            var err error
+            if writer, err = newArchiveWriter(name, archiver.Format); err != nil {
+                log.Error(fmt.Errorf("unable to create archive %s: %+v", name, err))
+                return err
+            }
+        }
+        if err := writer.Write(record); err != nil {
+            log.Error(fmt.Errorf("unable to encode archive %s: %+v", name, err))
+            return err
+        }
+        return nil
+    })
+    if err != nil {
+        if writer != nil {
+            writer.Remove()
+        }
+        return archives, err
+    }
+    if writer != nil {
+        return archives, complete()
+    }
+    return archives, nil
+}
+
+// function used to upload a written archive to storage and verify it
+func(archiver *Archiver) upload(writer *archiveWriter) (Archive, error) {
+    size, err := writer.Close()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to encode archive %s: %+v", writer.Name, err))
+        return Archive{}, err
+    }
+    archive := Archive{Name: writer.Name, Records: writer.Records, Checksum: writer.Checksum()}
+    if err := archiver.Storage.Put(archive.Name, writer.File, size, archive.Checksum); err != nil {
+        log.Error(fmt.Errorf("unable to write archive %s: %+v", archive.Name, err))
+        return archive, err
+    }
+    if err := archiver.Verify(archive); err != nil {
+        log.Error(fmt.Errorf("unable to verify archive %s: %+v", archive.Name, err))
+        return archive, err
+    }
+    return archive, nil
+}
+
+// function used to verify that an archive in storage matches the
+// checksum and record count of the written archive. the archive is
+// streamed from storage so that it is never held in memory
+func(archiver *Archiver) Verify(archive Archive) error {
+    reader, err := archiver.Storage.Get(archive.Name)
+    if err != nil {
+        return err
+    }
+    defer reader.Close()
+
+    hash := sha256.New()
+    records := 0
+    if err := DecodeArchive(io.TeeReader(reader, hash), archiver.Format, func(record ArchiveRecord) error {
+        records++
+        return nil
+    }); err != nil {
+        return err
+    }
+    // read any data remaining after the end of the compressed stream
+    // so that the checksum covers the entire archive
+    if _, err := io.Copy(hash, reader); err != nil {
+        return err
+    }
+    if hex.EncodeToString(hash.Sum(nil)) != archive.Checksum {
+        return fmt.Errorf("%w: checksum mismatch", ErrArchiveVerification)
+    }
+    if records != archive.Records {
+        return fmt.Errorf("%w: expected %d records, found %d", ErrArchiveVerification,
+            archive.Records, records)
+    }
+    return nil
+}
+
+// function used to read the records of an archive from storage
+func(archiver *Archiver) Read(name string) ([]ArchiveRecord, error) {
+    records := []ArchiveRecord{}
+    format, err := ArchiveFormat(name)
+    if err != nil {
+        return records, err
+    }
+    reader, err := archiver.Storage.Get(name)
+    if err != nil {
+        return records, err
+    }
+    defer reader.Close()
+
+    err = DecodeArchive(reader, format, func(record ArchiveRecord) error {
+        records = append(records, record)
+        return nil
+    })
+    return records, err
+}
+
+// struct used to write records into a gzip compressed archive stored in
+// a temporary file. the number of records and the checksum of the
+// compressed archive are tracked as records are written
+type archiveWriter struct {
+    Name    string
+    Records int
+    File    *os.File
+    hash    hash.Hash
+    gzip    *gzip.Writer
+    json    *json.Encoder
+    csv     *csv.Writer
+}
+
+// function used to generate a new archive writer for a given format
+func newArchiveWriter(name, format string) (*archiveWriter, error) {
+    if !stringSliceContains(ArchiveFormats, format) {
+        return nil, ErrInvalidArchiveFormat
+    }
+    file, err := ioutil.TempFile("", "asset_data_timeseries-*.gz")
+    if err != nil {
+        return nil, err
+    }
+    writer := &archiveWriter{Name: name, File: file, hash: sha256.New()}
+    writer.gzip = gzip.NewWriter(io.MultiWriter(file, writer.hash))
+    switch format {
+    case "jsonl":
+        writer.json = json.NewEncoder(writer.gzip)
+    case "csv":
+        writer.csv = csv.NewWriter(writer.gzip)
+        if err := writer.csv.Write(archiveCSVHeader); err != nil {
+            writer.Remove()
+            return nil, err
+        }
+    }
+    return writer, nil
+}
+
+// function used to write a single record to an archive
+func(writer *archiveWriter) Write(record ArchiveRecord) error {
+    if writer.json != nil {
+        if err := writer.json.Encode(record); err != nil {
+            return err
+        }
+    } else {
+        row, err := record.csvRow()
+        if err != nil {
+            return err
+        }
+        if err := writer.csv.Write(row); err != nil {
+            return err
+        }
+    }
+    writer.Records++
+    return nil
+}
+
+// function used to finish writing an archive. the temporary file is
+// rewound so that it can be uploaded, and its size is returned
+func(writer *archiveWriter) Close() (int64, error) {
+    if writer.csv != nil {
+        writer.csv.Flush()
+        if err := writer.csv.Error(); err != nil {
+            return 0, err
+        }
+    }
+    if err := writer.gzip.Close(); err != nil {
+        return 0, err
+    }
+    size, err := writer.File.Seek(0, io.SeekCurrent)
+    if err != nil {
+        return 0, err
+    }
+    _, err = writer.File.Seek(0, io.SeekStart)
+    return size, err
+}
+
+// function used to retrieve the checksum of a closed archive
+func(writer *archiveWriter) Checksum() string {
+    return hex.EncodeToString(writer.hash.Sum(nil))
+}
+
+// function used to remove the temporary file of an archive
+func(writer *archiveWriter) Remove() {
+    writer.File.Close()
+    if err := os.Remove(writer.File.Name()); err != nil {
+        log.Warn(fmt.Errorf("unable to remove temporary archive %s: %+v", writer.File.Name(), err))
+    }
+}
+
+// function used to decode records from a gzip compressed archive. each
+// record is passed to the handler as it is decoded
+func DecodeArchive(data io.Reader, format string, handle func(ArchiveRecord) error) error {
+    if !stringSliceContains(ArchiveFormats, format) {
+        return ErrInvalidArchiveFormat
+    }
+    reader, err := gzip.NewReader(data)
+    if err != nil {
+        return err
+    }
+    defer reader.Close()
+
+    switch format {
+    case "jsonl":
+        decoder := json.NewDecoder(reader)
+        for {
+            var record ArchiveRecord
+            if err := decoder.Decode(&record); err == io.EOF {
+                return nil
+            } else if err != nil {
+                return err
+            }
+            if err := handle(record); err != nil {
+                return err
+            }
+        }
+    default:
+        csvReader := csv.NewReader(reader)
+        // skip header row
+        if _, err := csvReader.Read(); err == io.EOF {
+            return nil
+        } else if err != nil {
+            return err
+        }
+        for {
+            row, err := csvReader.Read()
+            if err == io.EOF {
+                return nil
+            } else if err != nil {
+                return err
+            }
+            record, err := recordFromCSVRow(row)
+            if err != nil {
+                return err
+            }
+            if err := handle(record); err != nil {
+                return err
+            }
+        }
+    }
+}
+
+// function used to convert a record into a CSV row. phones and metadata
+// are stored as JSON, and a missing website state is stored as empty
+func(record ArchiveRecord) csvRow() ([]string, error) {
+    phones, err := json.Marshal(record.Phones)
+    if err != nil {
+        return nil, err
+    }
+    meta, err := json.Marshal(record.Meta)
+    if err != nil {
+        return nil, err
+    }
+    live := ""
+    if record.WebsiteLive != nil {
+        live = strconv.FormatBool(*record.WebsiteLive)
+    }
+    return []string{
+        record.BusinessId.String(),
+        record.EventTimestamp.Format(time.RFC3339Nano),
+        record.Source,
+        live,
+        string(phones),
+        string(meta),
+        strconv.FormatBool(record.Open),
+    }, nil
+}
+
+// function used to convert a CSV row into a record
+func recordFromCSVRow(row []string) (ArchiveRecord, error) {
+    var record ArchiveRecord
+    if len(row) != len(archiveCSVHeader) {
+        return record, fmt.Errorf("%w: invalid CSV row length %d", ErrArchiveVerification, len(row))
+    }
+    var err error
+    if record.BusinessId, err = uuid.Parse(row[0]); err != nil {
+        return record, err
+    }
+    if record.EventTimestamp, err = time.Parse(time.RFC3339Nano, row[1]); err != nil {
+        return record, err
+    }
+    record.Source = row[2]
+    if len(row[3]) > 0 {
+        live, err := strconv.ParseBool(row[3])
+        if err != nil {
+            return record, err
+        }
+        record.WebsiteLive = &live
+    }
+    if err := json.Unmarshal([]byte(row[4]), &record.Phones); err != nil {
+        return record, err
+    }
+    if err := json.Unmarshal([]byte(row[5]), &record.Meta); err != nil {
+        return record, err
+    }
+    if record.Open, err = strconv.ParseBool(row[6]); err != nil {
+        return record, err
+    }
+    return record, nil
+}
+
+// helper function used to check if a string slice contains a value
+func stringSliceContains(slice []string, element string) bool {
+    for _, item := range(slice) {
+        if item == element {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,147 @@
+package cleaner
+
+import (
+    "time"
+    "errors"
+    "testing"
+    "encoding/json"
+
+    "github.com/google/uuid"
+)
+
+// function used to generate archive records spanning two months
+func testRecords() []ArchiveRecord {
+    live, down := true, false
+    businessId := uuid.MustParse("655357ab-e7a8-406d-87bb-0736f6538339")
+    return []ArchiveRecord{
+        {BusinessId: businessId, EventTimestamp: time.Date(2021, 2, 27, 23, 59, 59, 123456000, time.UTC),
+            Source: "web-scraper", WebsiteLive: &live, Phones: []string{"+15125550100"},
+            Meta: map[string]interface{}{"status": "ok, \"quoted\""}, Open: true},
+        {BusinessId: businessId, EventTimestamp: time.Date(2021, 2, 28, 12, 0, 0, 0, time.UTC),
+            Source: "yelp-api-connector", WebsiteLive: nil, Phones: []string{}, Open: false},
+        {BusinessId: uuid.New(), EventTimestamp: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+            Source: "web-scraper", WebsiteLive: &down, Phones: []string{"+15125550100", "+15125550101"},
+            Meta: map[string]interface{}{}, Open: true},
+    }
+}
+
+// function used to stream records into an archiver
+func streamRecords(records []ArchiveRecord) RecordStream {
+    return func(handle func(ArchiveRecord) error) error {
+        for _, record := range(records) {
+            if err := handle(record); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+    records := testRecords()
+    cutoff := time.Date(2021, 4, 15, 0, 0, 0, 0, time.UTC)
+
+    for _, format := range(ArchiveFormats) {
+        t.Run(format, func(t *testing.T) {
+            archiver, err := NewArchiver(&LocalArchiveStorage{Directory: t.TempDir()}, format)
+            if err != nil {
+                t.Fatalf("unable to create archiver: %+v", err)
+            }
+            archives, err := archiver.Archive(streamRecords(records), cutoff)
+            if err != nil {
+                t.Fatalf("unable to archive records: %+v", err)
+            }
+            expected := []Archive{
+                {Name: "2021-02/asset_data_timeseries-20210415." + format + ".gz", Records: 2},
+                {Name: "2021-03/asset_data_timeseries-20210415." + format + ".gz", Records: 1},
+            }
+            if len(archives) != len(expected) {
+                t.Fatalf("expected %d archives, got %+v", len(expected), archives)
+            }
+
+            decoded := []ArchiveRecord{}
+            for i, archive := range(archives) {
+                if archive.Name != expected[i].Name || archive.Records != expected[i].Records {
+                    t.Errorf("expected archive %+v, got %+v", expected[i], archive)
+                }
+                read, err := archiver.Read(archive.Name)
+                if err != nil {
+                    t.Fatalf("unable to read archive %s: %+v", archive.Name, err)
+                }
+                decoded = append(decoded, read...)
+            }
+            // records are compared as JSON as empty metadata is decoded as nil
+            for i := range(records) {
+                if records[i].Meta == nil {
+                    records[i].Meta = map[string]interface{}{}
+                }
+            }
+            for i := range(decoded) {
+                if decoded[i].Meta == nil {
+                    decoded[i].Meta = map[string]interface{}{}
+                }
+            }
+            original, _ := json.Marshal(records)
+            result, _ := json.Marshal(decoded)
+            if string(original) != string(result) {
+                t.Errorf("expected records %s, got %s", original, result)
+            }
+        })
+    }
+}
+
+func TestVerifyArchive(t *testing.T) {
+    archiver, err := NewArchiver(&LocalArchiveStorage{Directory: t.TempDir()}, "jsonl")
+    if err != nil {
+        t.Fatalf("unable to create archiver: %+v", err)
+    }
+    archives, err := archiver.Archive(streamRecords(testRecords()[:2]), time.Now())
+    if err != nil || len(archives) != 1 {
+        t.Fatalf("unable to archive records: %+v", err)
+    }
+    archive := archives[0]
+
+    tests := []struct {
+        name    string
+        archive Archive
+        err     error
+    }{
+        {"valid", archive, nil},
+        {"checksum mismatch", Archive{Name: archive.Name, Records: archive.Records, Checksum: "invalid"},
+            ErrArchiveVerification},
+        {"record count mismatch", Archive{Name: archive.Name, Records: 3, Checksum: archive.Checksum},
+            ErrArchiveVerification},
+        {"missing archive", Archive{Name: "2021-01/missing.jsonl.gz"}, ErrArchiveNotFound},
+    }
+    for _, test := range(tests) {
+        if err := archiver.Verify(test.archive); !errors.Is(err, test.err) {
+            t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+        }
+    }
+}
+
+func TestArchiveStreamFailure(t *testing.T) {
+    directory := t.TempDir()
+    archiver, err := NewArchiver(&LocalArchiveStorage{Directory: directory}, "csv")
+    if err != nil {
+        t.Fatalf("unable to create archiver: %+v", err)
+    }
+    failure := errors.New("stream failed")
+    records := testRecords()
+    archives, err := archiver.Archive(func(handle func(ArchiveRecord) error) error {
+        for _, record := range(records) {
+            if err := handle(record); err != nil {
+                return err
+            }
+        }
+        return failure
+    }, time.Date(2021, 4, 15, 0, 0, 0, 0, time.UTC))
+    // archives completed before the failure remain, the incomplete
+    // archive of the last month is never uploaded
+    if err != failure || len(archives) != 1 {
+        t.Fatalf("expected error %v after one archive, got %v and %+v", failure, err, archives)
+    }
+    if _, err := archiver.Read("2021-03/asset_data_timeseries-20210415.csv.gz"); err != ErrArchiveNotFound {
+        t.Errorf("expected incomplete archive to be missing, got %v", err)
+    }
+}
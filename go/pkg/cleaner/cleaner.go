@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+    "fmt"
+    "time"
+    "sync"
+
+    log "github.com/sirupsen/logrus"
+)
+
+// function used to generate a new cleaner. all runs share the
+// connection pool of the given persistence
+func New(db *Persistence, interval int, policies []RetentionPolicy, archiver *Archiver,
+    monthsAhead, purgeGraceDays int) *Cleaner {
+    return &Cleaner{
+        DB: db,
+        IntervalMinutes: interval,
+        Policies: policies,
+        Archiver: archiver,
+        PartitionMonthsAhead: monthsAhead,
+        PurgeGraceDays: purgeGraceDays,
+    }
+}
+
+// struct used to remove data once it has passed the retention period
+// of its table. raw timeseries data is exported to archives and
+// aggregated into hourly and daily rollups before being removed to
+// retain long-term history. monthly partitions of the timeseries table
+// are created ahead of time so that expired months can be dropped.
+// deleted businesses are purged once their grace period has passed
+type Cleaner struct {
+    DB                   *Persistence
+    IntervalMinutes      int
+    Policies             []RetentionPolicy
+    Archiver             *Archiver
+    PartitionMonthsAhead int
+    PurgeGraceDays       int
+}
+
+// function used to create any missing monthly partitions of the
+// timeseries table up to the configured number of months ahead
+func(cleaner *Cleaner) MaintainPartitions() ([]Partition, error) {
+    db := cleaner.DB
+
+    created, err := db.EnsurePartitions(cleaner.PartitionMonthsAhead)
+    if err != nil {
+        return created, err
+    }
+    for _, partition := range(created) {
+        log.Info(fmt.Sprintf("created partition %s for %s to %s", partition.Name,
+            partition.Start.Format("2006-01-02"), partition.End.Format("2006-01-02")))
+    }
+    return created, nil
+}
+
+// function used to enforce all retention policies. policies are applied
+// independently, so a failing policy does not prevent other policies
+// from being applied. the number of rows removed by each policy is
+// logged and returned. cutoffs are computed from the time of the
+// database so that they match timestamps stored in its local time
+func(cleaner *Cleaner) Clean() ([]RetentionResult, error) {
+    results := []RetentionResult{}
+    db := cleaner.DB
+
+    var lastErr error
+    now, err := db.Now()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve database time: %+v", err))
+        return results, err
+    }
+    for _, policy := range(cleaner.Policies) {
+        var (policyResults []RetentionResult; err error)
+        if policy.Table == TimeseriesTable {
+            policyResults, err = cleaner.CleanTimeseries(db, policy, now)
+        } else {
+            policyResults, err = db.ApplyRetentionPolicy(policy, now)
+        }
+        if err != nil {
+            log.Error(fmt.Errorf("unable to apply retention policy %s: %+v", policy.Name, err))
+            lastErr = err
+            continue
+        }
+        for _, result := range(policyResults) {
+            log.Info(fmt.Sprintf("retention policy %s removed %d rows from %s", result.Policy,
+                result.Removed, result.Table))
+        }
+        results = append(results, policyResults...)
+    }
+    return results, lastErr
+}
+
+// function used to purge all businesses deleted before the grace period
+// along with all related rows
+func(cleaner *Cleaner) PurgeDeletedBusinesses() ([]RetentionResult, error) {
+    db := cleaner.DB
+
+    before := time.Now().Add(-time.Duration(cleaner.PurgeGraceDays) * 24 * time.Hour)
+    results, err := db.PurgeDeletedBusinesses(before)
+    if err != nil {
+        return results, err
+    }
+    for _, result := range(results) {
+        log.Info(fmt.Sprintf("purge of deleted businesses removed %d rows from %s", result.Removed,
+            result.Table))
+    }
+    return results, nil
+}
+
+// function used to archive, roll up and clear all timeseries data before
+// the cutoff of a policy. expired rows are streamed into archives, and
+// data is only removed once all archives have been verified
+func(cleaner *Cleaner) CleanTimeseries(db *Persistence, policy RetentionPolicy,
+    now time.Time) ([]RetentionResult, error) {
+
+    ts := policy.Cutoff(now)
+    log.Info(fmt.Sprintf("archiving records before %s", ts))
+    archives, err := cleaner.Archiver.Archive(func(handle func(ArchiveRecord) error) error {
+        _, err := db.StreamExpiredRecords(ts, handle)
+        return err
+    }, ts)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to archive data. skipping clear: %+v", err))
+        return []RetentionResult{}, err
+    }
+    archived := 0
+    for _, archive := range(archives) {
+        archived += archive.Records
+    }
+    if archived == 0 {
+        log.Debug(fmt.Sprintf("no data found before %s", ts))
+        return []RetentionResult{RetentionResult{policy.Name, policy.Table, 0}}, nil
+    }
+
+    log.Info(fmt.Sprintf("rolling up and clearing %d records before %s", archived, ts))
+    if err := db.RollupAndClearData(ts, archived); err != nil {
+        return []RetentionResult{}, err
+    }
+    return []RetentionResult{RetentionResult{policy.Name, policy.Table, int64(archived)}}, nil
+}
+
+// function used to start cleaner
+func(cleaner *Cleaner) Run() {
+    // generate ticker and channel for messages
+    ticker := time.NewTicker(time.Duration(cleaner.IntervalMinutes) * time.Minute)
+    quitChan := make(chan bool)
+
+    var wg sync.WaitGroup
+    // add to waitgroup to prevent go routine from closing
+    wg.Add(1)
+
+    go func() {
+        for {
+            select {
+            case <- ticker.C:
+                log.Info("starting new data clearing job...")
+                start := time.Now()
+
+                // create partitions before clearing so that new rows are
+                // never written to the default partition
+                if _, err := cleaner.MaintainPartitions(); err != nil {
+                    log.Error(fmt.Errorf("unable to maintain partitions: %+v", err))
+                }
+                if _, err := cleaner.Clean(); err != nil {
+                    log.Error(fmt.Errorf("unable to clear data: %+v", err))
+                }
+                if _, err := cleaner.PurgeDeletedBusinesses(); err != nil {
+                    log.Error(fmt.Errorf("unable to purge deleted businesses: %+v", err))
+                }
+                // log total time elapsed to process job
+                elapsed := time.Now().Sub(start)
+                log.Info(fmt.Sprintf("finished clearing job. took %fs to process", elapsed.Seconds()))
+            case <- quitChan:
+                // stop ticker and add to waitgroup
+                ticker.Stop()
+                wg.Done()
+                return
+            }
+        }
+    }()
+
+    wg.Wait()
+    log.Info("stopping data clearer...")
+}
@@ -0,0 +1,244 @@
+package cleaner
+
+import (
+    "fmt"
+    "time"
+    "sort"
+    "errors"
+    "strings"
+    "context"
+
+    "github.com/jackc/pgx/v4"
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define custom errors
+    ErrNotPartitioned = errors.New("Timeseries table is not partitioned")
+
+    // define name of default partition. rows that do not belong to a
+    // monthly partition i.e. restored archives for dropped months are
+    // stored in the default partition
+    DefaultPartition = TimeseriesTable + "_default"
+
+    // define format used for the suffix of monthly partitions
+    partitionSuffixFormat = "p2006_01"
+)
+
+// struct used to store a monthly partition of the timeseries table.
+// partitions contain all rows from the start (inclusive) to the end
+// (exclusive) of the month
+type Partition struct {
+    Name  string
+    Start time.Time
+    End   time.Time
+}
+
+// function used to generate the monthly partition containing a timestamp.
+// timestamps are wall clock times of the database, so the month is read
+// from the timestamp as given and bounds are expressed in UTC to avoid
+// converting them when they are sent to the database
+func PartitionForMonth(ts time.Time) Partition {
+    start := time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC)
+    return Partition{
+        Name: fmt.Sprintf("%s_%s", TimeseriesTable, start.Format(partitionSuffixFormat)),
+        Start: start,
+        End: start.AddDate(0, 1, 0),
+    }
+}
+
+// function used to parse a monthly partition from its table name. false
+// is returned if the table is not a monthly partition
+func parsePartition(name string) (Partition, bool) {
+    prefix := TimeseriesTable + "_"
+    if !strings.HasPrefix(name, prefix) {
+        return Partition{}, false
+    }
+    start, err := time.Parse(partitionSuffixFormat, strings.TrimPrefix(name, prefix))
+    if err != nil {
+        return Partition{}, false
+    }
+    return PartitionForMonth(start), true
+}
+
+// struct used to store the partitions of the timeseries table
+type partitionLayout struct {
+    Partitioned bool
+    HasDefault  bool
+    Monthly     []Partition
+}
+
+// function used to retrieve the partitions of the timeseries table.
+// monthly partitions are sorted by start
+func listPartitions(tx pgx.Tx) (partitionLayout, error) {
+    layout := partitionLayout{Monthly: []Partition{}}
+    query := `SELECT EXISTS(SELECT 1 FROM pg_partitioned_table
+        INNER JOIN pg_class ON pg_partitioned_table.partrelid = pg_class.oid
+        WHERE pg_class.relname = $1)`
+    if err := tx.QueryRow(context.Background(), query, TimeseriesTable).Scan(&layout.Partitioned); err != nil {
+        return layout, err
+    }
+    if !layout.Partitioned {
+        return layout, nil
+    }
+
+    query = `SELECT child.relname FROM pg_inherits
+        INNER JOIN pg_class parent ON pg_inherits.inhparent = parent.oid
+        INNER JOIN pg_class child ON pg_inherits.inhrelid = child.oid
+        WHERE parent.relname = $1`
+    rows, err := tx.Query(context.Background(), query, TimeseriesTable)
+    if err != nil {
+        return layout, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return layout, err
+        }
+        if name == DefaultPartition {
+            layout.HasDefault = true
+        } else if partition, ok := parsePartition(name); ok {
+            layout.Monthly = append(layout.Monthly, partition)
+        }
+    }
+    sort.Slice(layout.Monthly, func(i, j int) bool {
+        return layout.Monthly[i].Start.Before(layout.Monthly[j].Start)
+    })
+    return layout, rows.Err()
+}
+
+// function used to create monthly partitions from the current month up
+// to the given number of months ahead. partitions are created as
+// standalone tables and attached once any matching rows have been
+// moved out of the default partition, as postgres does not allow
+// partitions to be created for rows already in the default partition.
+// the current month is taken from the database, as event timestamps
+// are stored without time zones in the local time of the database
+func(db *Persistence) EnsurePartitions(monthsAhead int) ([]Partition, error) {
+    created := []Partition{}
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return created, err
+    }
+    defer tx.Rollback(context.Background())
+
+    layout, err := listPartitions(tx)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to list partitions: %+v", err))
+        return created, err
+    }
+    if !layout.Partitioned {
+        log.Error(fmt.Errorf("unable to maintain partitions of %s: %+v", TimeseriesTable, ErrNotPartitioned))
+        return created, ErrNotPartitioned
+    }
+    names := map[string]bool{}
+    for _, partition := range(layout.Monthly) {
+        names[partition.Name] = true
+    }
+
+    var month time.Time
+    query := `SELECT date_trunc('month', now()::timestamp)`
+    if err := tx.QueryRow(context.Background(), query).Scan(&month); err != nil {
+        log.Error(fmt.Errorf("unable to retrieve current month: %+v", err))
+        return created, err
+    }
+    for i := 0; i <= monthsAhead; i++ {
+        partition := PartitionForMonth(month.AddDate(0, i, 0))
+        if names[partition.Name] {
+            continue
+        }
+        log.Info(fmt.Sprintf("creating partition %s", partition.Name))
+        query := fmt.Sprintf(`CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`,
+            partition.Name, TimeseriesTable)
+        if _, err := tx.Exec(context.Background(), query); err != nil {
+            log.Error(fmt.Errorf("unable to create partition %s: %+v", partition.Name, err))
+            return created, err
+        }
+        if layout.HasDefault {
+            if err := moveDefaultRows(tx, partition); err != nil {
+                log.Error(fmt.Errorf("unable to move rows into partition %s: %+v", partition.Name, err))
+                return created, err
+            }
+        }
+        query = fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`,
+            TimeseriesTable, partition.Name, partition.Start.Format("2006-01-02"),
+            partition.End.Format("2006-01-02"))
+        if _, err := tx.Exec(context.Background(), query); err != nil {
+            log.Error(fmt.Errorf("unable to attach partition %s: %+v", partition.Name, err))
+            return created, err
+        }
+        created = append(created, partition)
+    }
+    return created, tx.Commit(context.Background())
+}
+
+// function used to move rows belonging to a partition out of the default
+// partition and into the partition table before it is attached
+func moveDefaultRows(tx pgx.Tx, partition Partition) error {
+    query := fmt.Sprintf(`INSERT INTO %s SELECT * FROM %s
+        WHERE event_timestamp >= $1 AND event_timestamp < $2`, partition.Name, DefaultPartition)
+    if _, err := tx.Exec(context.Background(), query, partition.Start, partition.End); err != nil {
+        return err
+    }
+    query = fmt.Sprintf(`DELETE FROM %s WHERE event_timestamp >= $1 AND event_timestamp < $2`,
+        DefaultPartition)
+    _, err := tx.Exec(context.Background(), query, partition.Start, partition.End)
+    return err
+}
+
+// function used to remove all timeseries rows before the given timestamp.
+// monthly partitions that end before the timestamp are dropped, and rows
+// in the partition containing the timestamp and the default partition are
+// deleted. tables created before partitioning was introduced are
+// converted by migrations, so data is never cleared from a table that is
+// not partitioned. the number of removed rows is returned
+func clearPartitions(tx pgx.Tx, ts time.Time) (int64, error) {
+    layout, err := listPartitions(tx)
+    if err != nil {
+        return 0, err
+    }
+    if !layout.Partitioned {
+        return 0, ErrNotPartitioned
+    }
+
+    removed := int64(0)
+    for _, partition := range(layout.Monthly) {
+        if !partition.Start.Before(ts) {
+            break
+        }
+        // drop partitions that have fully expired, counting rows first
+        // so that removed rows can be compared with archived rows
+        if !partition.End.After(ts) {
+            var count int64
+            query := fmt.Sprintf(`SELECT COUNT(*) FROM %s`, partition.Name)
+            if err := tx.QueryRow(context.Background(), query).Scan(&count); err != nil {
+                return removed, err
+            }
+            log.Info(fmt.Sprintf("dropping partition %s with %d rows", partition.Name, count))
+            if _, err := tx.Exec(context.Background(), fmt.Sprintf(`DROP TABLE %s`, partition.Name)); err != nil {
+                return removed, err
+            }
+            removed += count
+            continue
+        }
+        query := fmt.Sprintf(`DELETE FROM %s WHERE event_timestamp < $1`, partition.Name)
+        result, err := tx.Exec(context.Background(), query, ts)
+        if err != nil {
+            return removed, err
+        }
+        removed += result.RowsAffected()
+    }
+
+    if !layout.HasDefault {
+        return removed, nil
+    }
+    query := fmt.Sprintf(`DELETE FROM %s WHERE event_timestamp < $1`, DefaultPartition)
+    result, err := tx.Exec(context.Background(), query, ts)
+    if err != nil {
+        return removed, err
+    }
+    return removed + result.RowsAffected(), nil
+}
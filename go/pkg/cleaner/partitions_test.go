@@ -0,0 +1,59 @@
+package cleaner
+
+import (
+    "time"
+    "testing"
+)
+
+func TestPartitionForMonth(t *testing.T) {
+    central := time.FixedZone("CST", -6 * 60 * 60)
+    tests := []struct {
+        ts    time.Time
+        name  string
+        start time.Time
+        end   time.Time
+    }{
+        {time.Date(2021, 3, 15, 12, 0, 0, 0, time.UTC), "asset_data_timeseries_p2021_03",
+            time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+        {time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), "asset_data_timeseries_p2021_03",
+            time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+        {time.Date(2020, 12, 31, 23, 59, 59, 0, time.UTC), "asset_data_timeseries_p2020_12",
+            time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+        {time.Date(2020, 2, 29, 8, 0, 0, 0, time.UTC), "asset_data_timeseries_p2020_02",
+            time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+        // wall clock times are used as given without converting to UTC
+        {time.Date(2021, 3, 31, 22, 0, 0, 0, central), "asset_data_timeseries_p2021_03",
+            time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+    }
+    for _, test := range(tests) {
+        partition := PartitionForMonth(test.ts)
+        if partition.Name != test.name || !partition.Start.Equal(test.start) || !partition.End.Equal(test.end) {
+            t.Errorf("%s: expected %s (%s - %s), got %+v", test.ts, test.name, test.start, test.end, partition)
+        }
+    }
+}
+
+func TestParsePartition(t *testing.T) {
+    tests := []struct {
+        name     string
+        ok       bool
+        expected time.Time
+    }{
+        {"asset_data_timeseries_p2021_03", true, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+        {"asset_data_timeseries_p1999_12", true, time.Date(1999, 12, 1, 0, 0, 0, 0, time.UTC)},
+        {DefaultPartition, false, time.Time{}},
+        {"asset_data_timeseries_p2021_13", false, time.Time{}},
+        {"asset_data_timeseries_legacy", false, time.Time{}},
+        {"notifications_p2021_03", false, time.Time{}},
+    }
+    for _, test := range(tests) {
+        partition, ok := parsePartition(test.name)
+        if ok != test.ok {
+            t.Errorf("%s: expected monthly partition %v, got %v", test.name, test.ok, ok)
+            continue
+        }
+        if ok && (partition.Name != test.name || !partition.Start.Equal(test.expected)) {
+            t.Errorf("%s: expected partition starting %s, got %+v", test.name, test.expected, partition)
+        }
+    }
+}
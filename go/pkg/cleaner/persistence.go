@@ -0,0 +1,239 @@
+package cleaner
+
+import (
+    "fmt"
+    "time"
+    "errors"
+    "context"
+
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+)
+
+var (
+    // define custom errors
+    ErrArchiveMismatch = errors.New("Deleted rows do not match archived rows")
+
+    // define rollup tables and the precision of their buckets. the
+    // precision is used as the field in postgres date_trunc calls
+    Rollups = []Rollup{
+        Rollup{Table: "asset_data_rollup_hourly", Precision: "hour", Interval: time.Hour},
+        Rollup{Table: "asset_data_rollup_daily", Precision: "day", Interval: 24 * time.Hour},
+    }
+)
+
+// struct used to define a rollup table
+type Rollup struct {
+    Table     string
+    Precision string
+    Interval  time.Duration
+}
+
+type Persistence struct{
+    *utils.BasePostgresPersistence
+}
+
+func NewPersistence(url string) *Persistence {
+    // create instance of base persistence
+    basePersistence := utils.NewPersistence(url)
+    return &Persistence{
+        basePersistence,
+    }
+}
+
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to retrieve the current time of the database. timestamps
+// are stored without time zones in the local time of the database, so the
+// time is returned as a wall clock time in UTC to match stored timestamps
+func(db *Persistence) Now() (time.Time, error) {
+    var now time.Time
+    err := db.Session.QueryRow(context.Background(), `SELECT now()::timestamp`).Scan(&now)
+    return now, err
+}
+
+// function used to stream all timeseries rows older than the given
+// timestamp for archiving. rows are passed to the handler in order of
+// event timestamp as they are read from the database, and the number
+// of streamed rows is returned
+func(db *Persistence) StreamExpiredRecords(ts time.Time, handle func(ArchiveRecord) error) (int, error) {
+    log.Debug(fmt.Sprintf("retrieving data before %s...", ts))
+    query := `SELECT business_id,event_timestamp,source,website_live,phone,meta,open
+        FROM asset_data_timeseries WHERE event_timestamp < $1 ORDER BY event_timestamp`
+    rows, err := db.Session.Query(context.Background(), query, ts)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return 0, nil
+        default:
+            return 0, err
+        }
+    }
+    defer rows.Close()
+
+    count := 0
+    for rows.Next() {
+        var record ArchiveRecord
+        if err := rows.Scan(&record.BusinessId, &record.EventTimestamp, &record.Source,
+            &record.WebsiteLive, &record.Phones, &record.Meta, &record.Open); err != nil {
+            return count, err
+        }
+        if err := handle(record); err != nil {
+            return count, err
+        }
+        count++
+    }
+    return count, rows.Err()
+}
+
+// function used to re-import archived records into the timeseries
+// table. records that already exist are skipped, and the number of
+// inserted records is returned
+func(db *Persistence) RestoreRecords(records []ArchiveRecord) (int, error) {
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return 0, err
+    }
+    defer tx.Rollback(context.Background())
+
+    restored := 0
+    query := `INSERT INTO asset_data_timeseries(business_id,event_timestamp,source,website_live,phone,meta,open)
+        VALUES($1,$2,$3,$4,$5,$6,$7) ON CONFLICT (business_id,source,event_timestamp) DO NOTHING`
+    for _, record := range(records) {
+        phones, meta := record.Phones, record.Meta
+        if phones == nil {
+            phones = []string{}
+        }
+        if meta == nil {
+            meta = map[string]interface{}{}
+        }
+        result, err := tx.Exec(context.Background(), query, record.BusinessId, record.EventTimestamp,
+            record.Source, record.WebsiteLive, phones, meta, record.Open)
+        if err != nil {
+            log.Error(fmt.Errorf("unable to restore record for business %s: %+v", record.BusinessId, err))
+            return 0, err
+        }
+        restored += int(result.RowsAffected())
+    }
+    return restored, tx.Commit(context.Background())
+}
+
+// function used to aggregate timeseries data older than the given
+// timestamp into rollup tables and remove the raw rows, dropping any
+// partitions that have fully expired. rollups and removals are run in
+// a single transaction so that no rows are deleted unless they have
+// been aggregated. the transaction is rolled back if the number of
+// removed rows does not match the number of archived rows
+func(db *Persistence) RollupAndClearData(ts time.Time, archived int) error {
+    log.Debug(fmt.Sprintf("rolling up and clearing data before %s...", ts))
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return err
+    }
+    defer tx.Rollback(context.Background())
+
+    for _, rollup := range(Rollups) {
+        if err := rollupData(tx, rollup, ts); err != nil {
+            log.Error(fmt.Errorf("unable to generate %s rollups: %+v", rollup.Precision, err))
+            return err
+        }
+    }
+
+    removed, err := clearPartitions(tx, ts)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to delete data from database: %+v", err))
+        return err
+    }
+    if int(removed) != archived {
+        log.Error(fmt.Errorf("deleted %d rows but archived %d rows", removed, archived))
+        return ErrArchiveMismatch
+    }
+    return tx.Commit(context.Background())
+}
+
+// function used to aggregate timeseries data older than the given
+// timestamp into a single rollup table. as the cutoff is always the
+// start of a day, buckets are complete when generated, and existing
+// buckets are replaced so that restored archives are not counted twice
+func rollupData(tx pgx.Tx, rollup Rollup, ts time.Time) error {
+    query := fmt.Sprintf(`WITH expired AS (
+            SELECT business_id,source,date_trunc($2, event_timestamp) AS bucket,
+                website_live,open,phone
+            FROM asset_data_timeseries WHERE event_timestamp < $1
+        ), stats AS (
+            SELECT business_id,source,bucket,
+                AVG(CASE WHEN website_live THEN 1 ELSE 0 END) AS fraction_live,
+                AVG(CASE WHEN open THEN 1 ELSE 0 END) AS fraction_open,
+                COUNT(*) AS samples
+            FROM expired GROUP BY business_id,source,bucket
+        ), phones AS (
+            SELECT business_id,source,bucket,array_agg(DISTINCT number ORDER BY number) AS phones
+            FROM expired, unnest(phone) AS number GROUP BY business_id,source,bucket
+        )
+        INSERT INTO %[1]s(business_id,source,bucket,fraction_live,fraction_open,phones,samples)
+        SELECT stats.business_id,stats.source,stats.bucket,stats.fraction_live,stats.fraction_open,
+            COALESCE(phones.phones, '{}'),stats.samples
+        FROM stats LEFT JOIN phones ON stats.business_id = phones.business_id
+            AND stats.source = phones.source AND stats.bucket = phones.bucket
+        ON CONFLICT (business_id,source,bucket) DO UPDATE SET
+            fraction_live=EXCLUDED.fraction_live, fraction_open=EXCLUDED.fraction_open,
+            phones=EXCLUDED.phones, samples=EXCLUDED.samples`, rollup.Table)
+    _, err := tx.Exec(context.Background(), query, ts, rollup.Precision)
+    return err
+}
+
+// function used to remove all rows from a table that have expired
+// under a retention policy. rows in dependent tables are removed
+// along with the expired rows, as are any dependent rows that no
+// longer reference a row in the table. all rows are removed in a
+// single transaction
+func(db *Persistence) ApplyRetentionPolicy(policy RetentionPolicy,
+    now time.Time) ([]RetentionResult, error) {
+
+    results := []RetentionResult{}
+    table := RetentionTables[policy.Table]
+    expired := policy.expiredQuery(table)
+    ts := policy.Cutoff(now)
+    log.Debug(fmt.Sprintf("applying retention policy %s to %s before %s...", policy.Name, policy.Table, ts))
+
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return results, err
+    }
+    defer tx.Rollback(context.Background())
+
+    // remove dependent rows first as expired rows are selected from table
+    for _, dependent := range(table.Dependents) {
+        query := fmt.Sprintf(`DELETE FROM %[1]s WHERE %[2]s IN (%[3]s)
+            OR NOT EXISTS (SELECT 1 FROM %[4]s WHERE %[4]s.%[2]s = %[1]s.%[2]s)`,
+            dependent, table.Key, expired, policy.Table)
+        result, err := tx.Exec(context.Background(), query, ts)
+        if err != nil {
+            log.Error(fmt.Errorf("unable to delete rows from %s: %+v", dependent, err))
+            return results, err
+        }
+        results = append(results, RetentionResult{policy.Name, dependent, result.RowsAffected()})
+    }
+
+    query := fmt.Sprintf(`DELETE FROM %s WHERE (%s) IN (%s)`, policy.Table, table.Key, expired)
+    result, err := tx.Exec(context.Background(), query, ts)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to delete rows from %s: %+v", policy.Table, err))
+        return results, err
+    }
+    results = append([]RetentionResult{RetentionResult{policy.Name, policy.Table, result.RowsAffected()}},
+        results...)
+    return results, tx.Commit(context.Background())
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+    "fmt"
+    "time"
+    "context"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v4"
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define name used to report rows removed when purging deleted businesses
+    PurgePolicy = "deleted-businesses"
+
+    // define tables containing rows keyed by business that are removed
+    // when a deleted business is purged. rollup tables are added from
+    // the rollup definitions
+    PurgeTables = []string{"asset_data", TimeseriesTable, "golden_records", "asset_updates",
+        "site_text_snapshots", "business_audit_log"}
+)
+
+// struct used to define the rows removed from a table when purging businesses
+type purgeQuery struct {
+    Table string
+    Query string
+    Arg   interface{}
+}
+
+// function used to remove all businesses deleted before the given time
+// along with all related rows i.e. source data, timeseries data, rollups,
+// golden records, updates, snapshots, audit logs and notifications.
+// businesses are purged in a single transaction and locked so that a
+// business restored while being purged is never removed. note that
+// archived timeseries data is not removed from archive storage
+func(db *Persistence) PurgeDeletedBusinesses(before time.Time) ([]RetentionResult, error) {
+    log.Debug(fmt.Sprintf("purging businesses deleted before %s...", before))
+    results := []RetentionResult{}
+
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return results, err
+    }
+    defer tx.Rollback(context.Background())
+
+    businessIds, err := lockDeletedBusinesses(tx, before)
+    if err != nil || len(businessIds) == 0 {
+        return results, err
+    }
+    // notifications store business IDs as text in the notification body
+    keys := []string{}
+    for _, businessId := range(businessIds) {
+        keys = append(keys, businessId.String())
+    }
+
+    // remove notification metadata first as notifications are used to
+    // select the metadata to remove
+    queries := []purgeQuery{
+        purgeQuery{"notification_metadata", `DELETE FROM notification_metadata WHERE notification_id IN (
+            SELECT notification_id FROM notifications WHERE notification->>'business_id' = ANY($1))`, keys},
+        purgeQuery{"notifications", `DELETE FROM notifications WHERE notification->>'business_id' = ANY($1)`, keys},
+    }
+    tables := append([]string{}, PurgeTables...)
+    for _, rollup := range(Rollups) {
+        tables = append(tables, rollup.Table)
+    }
+    for _, table := range(append(tables, "asset_metadata")) {
+        queries = append(queries, purgeQuery{table,
+            fmt.Sprintf(`DELETE FROM %s WHERE business_id = ANY($1)`, table), businessIds})
+    }
+
+    for _, purge := range(queries) {
+        result, err := tx.Exec(context.Background(), purge.Query, purge.Arg)
+        if err != nil {
+            log.Error(fmt.Errorf("unable to delete rows from %s: %+v", purge.Table, err))
+            return []RetentionResult{}, err
+        }
+        results = append(results, RetentionResult{PurgePolicy, purge.Table, result.RowsAffected()})
+    }
+    return results, tx.Commit(context.Background())
+}
+
+// function used to lock and retrieve the IDs of all businesses deleted
+// before the given time
+func lockDeletedBusinesses(tx pgx.Tx, before time.Time) ([]uuid.UUID, error) {
+    businessIds := []uuid.UUID{}
+    query := `SELECT business_id FROM asset_metadata WHERE deleted_at < $1 FOR UPDATE`
+    rows, err := tx.Query(context.Background(), query, before)
+    if err != nil {
+        return businessIds, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var businessId uuid.UUID
+        if err := rows.Scan(&businessId); err != nil {
+            return businessIds, err
+        }
+        businessIds = append(businessIds, businessId)
+    }
+    return businessIds, rows.Err()
+}
@@ -0,0 +1,149 @@
+package cleaner
+
+import (
+    "fmt"
+    "time"
+    "errors"
+    "strings"
+    "encoding/json"
+)
+
+var (
+    // define custom errors
+    ErrInvalidRetentionPolicy = errors.New("Invalid retention policy")
+
+    // define table of timeseries data. timeseries data is archived and
+    // rolled up before being removed, so only age limits are supported
+    TimeseriesTable = "asset_data_timeseries"
+
+    // define tables that support retention policies
+    RetentionTables = map[string]RetentionTable{
+        TimeseriesTable: RetentionTable{
+            Key: "business_id,source,event_timestamp",
+            Timestamp: "event_timestamp",
+        },
+        "notifications": RetentionTable{
+            Key: "notification_id",
+            Timestamp: "event_timestamp",
+            Business: "notification->>'business_id'",
+            Unread: "read IS NOT TRUE",
+            Dependents: []string{"notification_metadata"},
+        },
+        "mail_relay_entries": RetentionTable{
+            Key: "entry_id",
+            Timestamp: "event_timestamp",
+            Unread: "completed = false",
+        },
+    }
+
+    // define default retention policies
+    DefaultRetentionPolicies = `[
+        {"name": "timeseries", "table": "asset_data_timeseries", "max_age_days": 14},
+        {"name": "notifications", "table": "notifications", "max_age_days": 90,
+            "keep_unread": true, "keep_last_per_business": 10},
+        {"name": "mail-relay", "table": "mail_relay_entries", "max_age_days": 30}
+    ]`
+)
+
+// struct used to define how rows are identified in a table with a
+// retention policy. the business and unread expressions are empty
+// if the table does not support the keep last and keep unread
+// options. rows in dependent tables are removed along with rows
+// in the table, and are joined on the key of the table
+type RetentionTable struct {
+    Key        string
+    Timestamp  string
+    Business   string
+    Unread     string
+    Dependents []string
+}
+
+// struct used to define a retention policy for a single table. rows
+// older than the max age are removed unless they are unread (or not
+// completed for mail relay entries) and keep unread is set, or they
+// are among the most recent rows of their business
+type RetentionPolicy struct {
+    Name                string `json:"name"`
+    Table               string `json:"table"`
+    MaxAgeDays          int    `json:"max_age_days"`
+    KeepUnread          bool   `json:"keep_unread"`
+    KeepLastPerBusiness int    `json:"keep_last_per_business"`
+}
+
+// struct used to store the number of rows removed from a table
+// by a retention policy
+type RetentionResult struct {
+    Policy  string
+    Table   string
+    Removed int64
+}
+
+// function used to parse retention policies from a JSON list
+func ParseRetentionPolicies(value string) ([]RetentionPolicy, error) {
+    policies := []RetentionPolicy{}
+    if err := json.Unmarshal([]byte(value), &policies); err != nil {
+        return policies, fmt.Errorf("%w: %+v", ErrInvalidRetentionPolicy, err)
+    }
+    names := map[string]bool{}
+    for _, policy := range(policies) {
+        if err := policy.Validate(); err != nil {
+            return policies, err
+        }
+        if names[policy.Name] {
+            return policies, fmt.Errorf("%w: duplicate policy name '%s'", ErrInvalidRetentionPolicy,
+                policy.Name)
+        }
+        names[policy.Name] = true
+    }
+    return policies, nil
+}
+
+// function used to validate a retention policy against the options
+// supported by its table
+func(policy RetentionPolicy) Validate() error {
+    if len(strings.TrimSpace(policy.Name)) == 0 {
+        return fmt.Errorf("%w: missing policy name", ErrInvalidRetentionPolicy)
+    }
+    table, ok := RetentionTables[policy.Table]
+    if !ok {
+        return fmt.Errorf("%w: unsupported table '%s'", ErrInvalidRetentionPolicy, policy.Table)
+    }
+    if policy.MaxAgeDays < 1 {
+        return fmt.Errorf("%w: invalid max age for policy '%s'", ErrInvalidRetentionPolicy, policy.Name)
+    }
+    if policy.KeepUnread && len(table.Unread) == 0 {
+        return fmt.Errorf("%w: table '%s' does not support keep unread", ErrInvalidRetentionPolicy,
+            policy.Table)
+    }
+    if policy.KeepLastPerBusiness < 0 ||
+        (policy.KeepLastPerBusiness > 0 && len(table.Business) == 0) {
+        return fmt.Errorf("%w: table '%s' does not support keep last per business",
+            ErrInvalidRetentionPolicy, policy.Table)
+    }
+    return nil
+}
+
+// function used to determine the timestamp before which rows are
+// removed. the timestamp is truncated to the start of the day so
+// that rollup buckets are always complete when generated
+func(policy RetentionPolicy) Cutoff(now time.Time) time.Time {
+    ts := now.Add(time.Duration(-policy.MaxAgeDays) * 24 * time.Hour)
+    return ts.Truncate(24 * time.Hour)
+}
+
+// function used to generate a query selecting the keys of all rows
+// that are removed by the policy. the cutoff timestamp is given as $1
+func(policy RetentionPolicy) expiredQuery(table RetentionTable) string {
+    conditions := []string{fmt.Sprintf("%s < $1", table.Timestamp)}
+    if policy.KeepUnread {
+        conditions = append(conditions, fmt.Sprintf("NOT (%s)", table.Unread))
+    }
+    if policy.KeepLastPerBusiness > 0 {
+        conditions = append(conditions, fmt.Sprintf("row_rank > %d", policy.KeepLastPerBusiness))
+        return fmt.Sprintf(`SELECT %[1]s FROM (SELECT *, ROW_NUMBER() OVER (
+            PARTITION BY %[2]s ORDER BY %[3]s DESC) AS row_rank FROM %[4]s) ranked WHERE %[5]s`,
+            table.Key, table.Business, table.Timestamp, policy.Table, strings.Join(conditions, " AND "))
+    }
+    return fmt.Sprintf(`SELECT %s FROM %s WHERE %s`, table.Key, policy.Table,
+        strings.Join(conditions, " AND "))
+}
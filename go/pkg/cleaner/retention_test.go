@@ -0,0 +1,87 @@
+package cleaner
+
+import (
+    "time"
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestParseRetentionPolicies(t *testing.T) {
+    tests := []struct {
+        name     string
+        value    string
+        err      error
+        policies int
+    }{
+        {"defaults", DefaultRetentionPolicies, nil, 3},
+        {"empty", `[]`, nil, 0},
+        {"invalid JSON", `{"name": "timeseries"}`, ErrInvalidRetentionPolicy, 0},
+        {"missing name", `[{"table": "notifications", "max_age_days": 1}]`, ErrInvalidRetentionPolicy, 0},
+        {"unsupported table", `[{"name": "a", "table": "asset_metadata", "max_age_days": 1}]`,
+            ErrInvalidRetentionPolicy, 0},
+        {"invalid max age", `[{"name": "a", "table": "notifications", "max_age_days": 0}]`,
+            ErrInvalidRetentionPolicy, 0},
+        {"unsupported keep unread", `[{"name": "a", "table": "asset_data_timeseries", "max_age_days": 1,
+            "keep_unread": true}]`, ErrInvalidRetentionPolicy, 0},
+        {"unsupported keep last", `[{"name": "a", "table": "mail_relay_entries", "max_age_days": 1,
+            "keep_last_per_business": 5}]`, ErrInvalidRetentionPolicy, 0},
+        {"negative keep last", `[{"name": "a", "table": "notifications", "max_age_days": 1,
+            "keep_last_per_business": -1}]`, ErrInvalidRetentionPolicy, 0},
+        {"duplicate name", `[{"name": "a", "table": "notifications", "max_age_days": 1},
+            {"name": "a", "table": "mail_relay_entries", "max_age_days": 1}]`, ErrInvalidRetentionPolicy, 0},
+    }
+    for _, test := range(tests) {
+        policies, err := ParseRetentionPolicies(test.value)
+        if !errors.Is(err, test.err) || (test.err == nil && err != nil) {
+            t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+            continue
+        }
+        if err == nil && len(policies) != test.policies {
+            t.Errorf("%s: expected %d policies, got %+v", test.name, test.policies, policies)
+        }
+    }
+}
+
+func TestExpiredQuery(t *testing.T) {
+    tests := []struct {
+        name     string
+        policy   RetentionPolicy
+        expected string
+    }{
+        {"age only", RetentionPolicy{Table: "mail_relay_entries", MaxAgeDays: 30},
+            `SELECT entry_id FROM mail_relay_entries WHERE event_timestamp < $1`},
+        {"keep unread", RetentionPolicy{Table: "mail_relay_entries", MaxAgeDays: 30, KeepUnread: true},
+            `SELECT entry_id FROM mail_relay_entries WHERE event_timestamp < $1 AND NOT (completed = false)`},
+        {"keep last per business", RetentionPolicy{Table: "notifications", MaxAgeDays: 90, KeepUnread: true,
+            KeepLastPerBusiness: 10},
+            `SELECT notification_id FROM (SELECT *, ROW_NUMBER() OVER ( PARTITION BY ` +
+            `notification->>'business_id' ORDER BY event_timestamp DESC) AS row_rank FROM notifications) ` +
+            `ranked WHERE event_timestamp < $1 AND NOT (read IS NOT TRUE) AND row_rank > 10`},
+        {"timeseries", RetentionPolicy{Table: TimeseriesTable, MaxAgeDays: 14},
+            `SELECT business_id,source,event_timestamp FROM asset_data_timeseries WHERE event_timestamp < $1`},
+    }
+    for _, test := range(tests) {
+        query := strings.Join(strings.Fields(test.policy.expiredQuery(RetentionTables[test.policy.Table])), " ")
+        if query != test.expected {
+            t.Errorf("%s: expected query %q, got %q", test.name, test.expected, query)
+        }
+    }
+}
+
+func TestRetentionCutoff(t *testing.T) {
+    policy := RetentionPolicy{MaxAgeDays: 14}
+    tests := []struct {
+        now      time.Time
+        expected time.Time
+    }{
+        {time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+        {time.Date(2021, 3, 15, 23, 59, 59, 0, time.UTC), time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)},
+        {time.Date(2021, 3, 1, 6, 0, 0, 0, time.UTC), time.Date(2021, 2, 15, 0, 0, 0, 0, time.UTC)},
+    }
+    for _, test := range(tests) {
+        if cutoff := policy.Cutoff(test.now); !cutoff.Equal(test.expected) {
+            t.Errorf("%s: expected cutoff %s, got %s", test.now, test.expected, cutoff)
+        }
+    }
+}
@@ -0,0 +1,225 @@
+package cleaner
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "time"
+    "sort"
+    "errors"
+    "strings"
+    "net/http"
+    "path/filepath"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    neturl "net/url"
+)
+
+var (
+    // define custom errors
+    ErrInvalidArchiveStorage = errors.New("Invalid archive storage")
+    ErrArchiveNotFound       = errors.New("Cannot find archive")
+    ErrArchiveStorageFailed  = errors.New("Archive storage request failed")
+)
+
+// define interface for archive storage. archives are identified
+// by a relative name i.e. '2021-03/asset_data_timeseries-20210415.jsonl.gz'
+type ArchiveStorage interface{
+    // function used to write archive contents to storage. the size and
+    // hex encoded SHA256 checksum of the contents are known in advance
+    Put(name string, data io.Reader, size int64, checksum string) error
+    // function used to read archive contents from storage
+    Get(name string) (io.ReadCloser, error)
+}
+
+// struct used to store the settings of archive storage
+type StorageConfig struct {
+    // type of storage, either 'local' or 's3'
+    Type        string
+    Directory   string
+    Endpoint    string
+    Bucket      string
+    Region      string
+    AccessKey   string
+    SecretKey   string
+}
+
+// function used to generate archive storage from config
+func NewArchiveStorage(config StorageConfig) (ArchiveStorage, error) {
+    switch config.Type {
+    case "local":
+        if len(strings.TrimSpace(config.Directory)) == 0 {
+            return nil, ErrInvalidArchiveStorage
+        }
+        return &LocalArchiveStorage{Directory: config.Directory}, nil
+    case "s3":
+        if len(config.Endpoint) == 0 || len(config.Bucket) == 0 {
+            return nil, ErrInvalidArchiveStorage
+        }
+        return &S3ArchiveStorage{
+            Endpoint: strings.TrimSuffix(config.Endpoint, "/"),
+            Bucket: config.Bucket,
+            Region: config.Region,
+            AccessKey: config.AccessKey,
+            SecretKey: config.SecretKey,
+            Client: &http.Client{Timeout: 5 * time.Minute},
+        }, nil
+    }
+    return nil, ErrInvalidArchiveStorage
+}
+
+// struct used to store archives in a local directory
+type LocalArchiveStorage struct {
+    Directory string
+}
+
+func(storage *LocalArchiveStorage) Put(name string, data io.Reader, size int64, checksum string) error {
+    path := filepath.Join(storage.Directory, filepath.FromSlash(name))
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return err
+    }
+    // write to temporary file first so that partial archives are never
+    // visible under the final name
+    tmp := path + ".tmp"
+    file, err := os.Create(tmp)
+    if err != nil {
+        return err
+    }
+    if _, err := io.Copy(file, data); err != nil {
+        file.Close()
+        return err
+    }
+    if err := file.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
+
+func(storage *LocalArchiveStorage) Get(name string) (io.ReadCloser, error) {
+    file, err := os.Open(filepath.Join(storage.Directory, filepath.FromSlash(name)))
+    if os.IsNotExist(err) {
+        return nil, ErrArchiveNotFound
+    }
+    return file, err
+}
+
+// struct used to store archives in an S3 compatible object store
+// i.e. AWS S3 or MinIO. objects are addressed using path-style URLs
+// and requests are signed with AWS signature version 4
+type S3ArchiveStorage struct {
+    Endpoint  string
+    Bucket    string
+    Region    string
+    AccessKey string
+    SecretKey string
+    Client    *http.Client
+}
+
+func(storage *S3ArchiveStorage) Put(name string, data io.Reader, size int64, checksum string) error {
+    resp, err := storage.do("PUT", name, data, size, checksum)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("%w: received %d response", ErrArchiveStorageFailed, resp.StatusCode)
+    }
+    return nil
+}
+
+func(storage *S3ArchiveStorage) Get(name string) (io.ReadCloser, error) {
+    resp, err := storage.do("GET", name, nil, 0, sha256Hex([]byte{}))
+    if err != nil {
+        return nil, err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK:
+        return resp.Body, nil
+    case http.StatusNotFound:
+        resp.Body.Close()
+        return nil, ErrArchiveNotFound
+    default:
+        resp.Body.Close()
+        return nil, fmt.Errorf("%w: received %d response", ErrArchiveStorageFailed, resp.StatusCode)
+    }
+}
+
+// function used to execute a signed request against the object store.
+// the body is streamed, so the hex encoded SHA256 hash of the body is
+// given rather than computed
+func(storage *S3ArchiveStorage) do(method, name string, body io.Reader, size int64,
+    payloadHash string) (*http.Response, error) {
+    endpoint, err := neturl.Parse(storage.Endpoint)
+    if err != nil {
+        return nil, err
+    }
+    endpoint.Path = fmt.Sprintf("/%s/%s", storage.Bucket, strings.TrimPrefix(name, "/"))
+    if body == nil {
+        body = http.NoBody
+    }
+    req, err := http.NewRequest(method, endpoint.String(), body)
+    if err != nil {
+        return nil, err
+    }
+    req.ContentLength = size
+    storage.sign(req, payloadHash, time.Now().UTC())
+    return storage.Client.Do(req)
+}
+
+// function used to sign a request with AWS signature version 4
+func(storage *S3ArchiveStorage) sign(req *http.Request, payloadHash string, now time.Time) {
+    region := storage.Region
+    if len(region) == 0 {
+        region = "us-east-1"
+    }
+    amzDate := now.Format("20060102T150405Z")
+    date := now.Format("20060102")
+    req.Header.Set("Host", req.URL.Host)
+    req.Header.Set("X-Amz-Date", amzDate)
+    req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+    // generate canonical headers from signed headers
+    headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+    sort.Strings(headers)
+    canonicalHeaders := ""
+    for _, header := range(headers) {
+        canonicalHeaders += fmt.Sprintf("%s:%s\n", header, strings.TrimSpace(req.Header.Get(header)))
+    }
+    signedHeaders := strings.Join(headers, ";")
+
+    canonicalRequest := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        req.URL.RawQuery,
+        canonicalHeaders,
+        signedHeaders,
+        payloadHash,
+    }, "\n")
+    scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+    stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope,
+        sha256Hex([]byte(canonicalRequest))}, "\n")
+
+    key := hmacSHA256([]byte("AWS4" + storage.SecretKey), date)
+    key = hmacSHA256(key, region)
+    key = hmacSHA256(key, "s3")
+    key = hmacSHA256(key, "aws4_request")
+    signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+    req.Header.Set("Authorization", fmt.Sprintf(
+        "AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+        storage.AccessKey, scope, signedHeaders, signature))
+}
+
+// helper function used to generate hex encoded SHA256 hashes
+func sha256Hex(data []byte) string {
+    hashed := sha256.Sum256(data)
+    return hex.EncodeToString(hashed[:])
+}
+
+// helper function used to generate HMAC SHA256 signatures
+func hmacSHA256(key []byte, data string) []byte {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(data))
+    return mac.Sum(nil)
+}
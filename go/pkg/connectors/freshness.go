@@ -0,0 +1,68 @@
+package connectors
+
+import (
+    "time"
+    "errors"
+    "strings"
+    "strconv"
+)
+
+var (
+    // define custom errors
+    ErrInvalidFreshnessSLA = errors.New("Invalid freshness SLA")
+
+    // define freshness SLA used for sources without a configured SLA
+    DefaultFreshnessSLA = 72 * time.Hour
+)
+
+// struct used to store the freshness SLAs of data sources. data
+// collected longer ago than the SLA of a source is considered stale
+type FreshnessSLAs map[string]time.Duration
+
+// function used to parse freshness SLAs from a configuration string
+// of the form 'web-scraper:24,yelp-api-connector:48' where each SLA
+// is given in hours
+func ParseFreshnessSLAs(value string) (FreshnessSLAs, error) {
+    slas := FreshnessSLAs{}
+    if len(strings.TrimSpace(value)) == 0 {
+        return slas, nil
+    }
+    for _, entry := range(strings.Split(value, ",")) {
+        parts := strings.Split(strings.TrimSpace(entry), ":")
+        if len(parts) != 2 {
+            return slas, ErrInvalidFreshnessSLA
+        }
+        hours, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+        if err != nil || hours <= 0 {
+            return slas, ErrInvalidFreshnessSLA
+        }
+        slas[strings.TrimSpace(parts[0])] = time.Duration(hours * float64(time.Hour))
+    }
+    return slas, nil
+}
+
+// function used to retrieve the freshness SLA of a given source
+func(slas FreshnessSLAs) Get(source string) time.Duration {
+    if sla, ok := slas[source]; ok {
+        return sla
+    }
+    return DefaultFreshnessSLA
+}
+
+// function used to determine if data collected from a given
+// source at a given time is stale
+func(slas FreshnessSLAs) IsStale(source string, collectedAt, now time.Time) bool {
+    return now.Sub(collectedAt) > slas.Get(source)
+}
+
+// function used to retrieve the shortest SLA of all sources. no
+// source can be stale if data was collected within this period
+func(slas FreshnessSLAs) Min() time.Duration {
+    min := DefaultFreshnessSLA
+    for _, sla := range(slas) {
+        if sla < min {
+            min = sla
+        }
+    }
+    return min
+}
@@ -0,0 +1,66 @@
+package connectors
+
+import (
+    "time"
+    "testing"
+)
+
+func TestParseFreshnessSLAs(t *testing.T) {
+    tests := []struct {
+        value    string
+        err      error
+        source   string
+        expected time.Duration
+        min      time.Duration
+    }{
+        {"", nil, "web-scraper", DefaultFreshnessSLA, DefaultFreshnessSLA},
+        {"web-scraper:24, yelp-api-connector: 48", nil, "web-scraper", 24 * time.Hour, 24 * time.Hour},
+        {"web-scraper:24,yelp-api-connector:48", nil, "google-api-connector", DefaultFreshnessSLA,
+            24 * time.Hour},
+        {"web-scraper:0.5", nil, "web-scraper", 30 * time.Minute, 30 * time.Minute},
+        {"web-scraper:96", nil, "web-scraper", 96 * time.Hour, DefaultFreshnessSLA},
+        {"web-scraper", ErrInvalidFreshnessSLA, "", 0, 0},
+        {"web-scraper:day", ErrInvalidFreshnessSLA, "", 0, 0},
+        {"web-scraper:0", ErrInvalidFreshnessSLA, "", 0, 0},
+        {"web-scraper:-24", ErrInvalidFreshnessSLA, "", 0, 0},
+    }
+    for _, test := range(tests) {
+        slas, err := ParseFreshnessSLAs(test.value)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.value, test.err, err)
+            continue
+        }
+        if err != nil {
+            continue
+        }
+        if sla := slas.Get(test.source); sla != test.expected {
+            t.Errorf("%q: expected SLA %s for %s, got %s", test.value, test.expected, test.source, sla)
+        }
+        if min := slas.Min(); min != test.min {
+            t.Errorf("%q: expected minimum SLA %s, got %s", test.value, test.min, min)
+        }
+    }
+}
+
+func TestIsStale(t *testing.T) {
+    now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    slas := FreshnessSLAs{"web-scraper": 24 * time.Hour}
+
+    tests := []struct {
+        source      string
+        collectedAt time.Time
+        expected    bool
+    }{
+        {"web-scraper", now.Add(-time.Hour), false},
+        {"web-scraper", now.Add(-24 * time.Hour), false},
+        {"web-scraper", now.Add(-25 * time.Hour), true},
+        {"yelp-api-connector", now.Add(-25 * time.Hour), false},
+        {"yelp-api-connector", now.Add(-DefaultFreshnessSLA - time.Second), true},
+    }
+    for _, test := range(tests) {
+        if result := slas.IsStale(test.source, test.collectedAt, now); result != test.expected {
+            t.Errorf("%s collected at %s: expected stale %v, got %v", test.source, test.collectedAt,
+                test.expected, result)
+        }
+    }
+}
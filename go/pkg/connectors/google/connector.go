@@ -7,18 +7,20 @@ import (
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
-    "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/phone"
 )
 
 type GoogleAPIConnector struct{
-    BaseAPIUrl string
-    APIKey     string
+    BaseAPIUrl  string
+    APIKey      string
+    PhoneRegion string
 }
 
-func NewGoogleAPIConnector(baseUrl, apiKey string) *GoogleAPIConnector {
+func NewGoogleAPIConnector(baseUrl, apiKey, phoneRegion string) *GoogleAPIConnector {
     return &GoogleAPIConnector{
         BaseAPIUrl: baseUrl,
         APIKey: apiKey,
+        PhoneRegion: phoneRegion,
     }
 }
 
@@ -74,7 +76,7 @@ func(connector *GoogleAPIConnector) UpdateBusiness(business connectors.BusinessM
     payload := connectors.BusinessData{
         WebsiteLive: true,
         BusinessOpen: response.BusinessStatus == "OPERATIONAL",
-        BusinessPhones: []string{utils.CleanNumber(response.FormattedPhoneNumber)},
+        BusinessPhones: phone.NormalizeAll([]string{response.FormattedPhoneNumber}, phone.RegionFromMetadata(business.Metadata, connector.PhoneRegion)),
         Source: connector.Name(),
     }
     // generate new update and return
@@ -1,6 +1,8 @@
 package connectors
 
 import (
+    "time"
+
     "github.com/google/uuid"
 )
 
@@ -27,4 +29,5 @@ type BusinessData struct{
     BusinessPhones []string `json:"business_phones"`
     BusinessOpen   bool     `json:"business_open"`
     Source         string   `json:"source"`
+    CollectedAt    time.Time `json:"collected_at"`
 }
\ No newline at end of file
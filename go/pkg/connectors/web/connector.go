@@ -3,14 +3,15 @@ package connectors
 import (
     "fmt"
     "errors"
-    "net/http"
-    "io/ioutil"
 
     "github.com/PSauerborn/hermes/pkg/client"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/phone"
+    "texas_real_foods/pkg/page-cache"
+    "texas_real_foods/pkg/content-change"
     api "texas_real_foods/pkg/utils/api_accessors"
 )
 
@@ -22,14 +23,26 @@ var (
 // define function used to generate new web connector. note
 // that each instance of the WebConnector is created with a
 // Phone Validation API host, which is used to validate phone
-// numbers scraped from a site(s)
-func NewWebConnector(apiConfig utils.APIDependencyConfig) *WebConnector {
-    return &WebConnector{apiConfig}
+// numbers scraped from a site(s). pages are retrieved using the
+// shared page fetcher to prevent sites being fetched multiple times,
+// and the contents of each page are passed to the change detector
+func NewWebConnector(apiConfig utils.APIDependencyConfig,
+    fetcher *page_cache.PageFetcher, detector *content_change.ChangeDetector,
+    phoneRegion string) *WebConnector {
+    return &WebConnector{
+        UtilsAPIConfig: apiConfig,
+        Fetcher: fetcher,
+        Detector: detector,
+        PhoneRegion: phoneRegion,
+    }
 }
 
 // struct used to store
 type WebConnector struct{
     UtilsAPIConfig utils.APIDependencyConfig
+    Fetcher        *page_cache.PageFetcher
+    Detector       *content_change.ChangeDetector
+    PhoneRegion    string
 }
 
 // function used to scrape sites for updated asset
@@ -109,37 +122,28 @@ func(connector *WebConnector) ScrapeSiteData(business connectors.BusinessMetadat
     connectors.BusinessUpdate, error) {
 
     var (scrapeError error; data connectors.BusinessData; update connectors.BusinessUpdate)
-    // add callbacks to scraper and start
-    // generate new HTTP request with given settings
-    req, err := http.NewRequest("GET", business.BusinessURI, nil)
-    if err != nil {
-        log.Error(fmt.Errorf("unable to generate new HTTP Request: %+v", err))
-        return update, err
-    }
-
-    client := &http.Client{}
-    resp, err := client.Do(req)
+    // retrieve page from shared page cache
+    snapshot, err := connector.Fetcher.Fetch(business.BusinessURI)
     if err != nil {
-        log.Error(fmt.Errorf("unable to execute HTTP request: %+v", err))
+        log.Error(fmt.Errorf("unable to fetch site data: %+v", err))
         return update, err
     }
-    defer resp.Body.Close()
 
-    switch resp.StatusCode {
+    switch snapshot.StatusCode {
     case 200:
-        // extract request body
-        bytes, err := ioutil.ReadAll(resp.Body)
-        if err != nil {
-            log.Error(fmt.Errorf("unable to read response body: %+v", err))
-            return update, err
-        }
         // parse site and extract data
-        data, scrapeError = connector.ParseSiteData(business, bytes)
+        data, scrapeError = connector.ParseSiteData(business, snapshot.Body)
         if scrapeError != nil {
             log.Error(fmt.Errorf("unable to scrape site data: %+v", scrapeError))
         }
+        // check page contents for changes since previous scrape
+        if connector.Detector != nil {
+            if _, err := connector.Detector.ProcessPage(business, snapshot.Body); err != nil {
+                log.Error(fmt.Errorf("unable to process content changes: %+v", err))
+            }
+        }
     default:
-        log.Error(fmt.Errorf("unable to scrape site data: received status code %d", resp.StatusCode))
+        log.Error(fmt.Errorf("unable to scrape site data: received status code %d", snapshot.StatusCode))
         // update asset to indicate that website is no longer active
         data = connectors.BusinessData{
             WebsiteLive: false,
@@ -160,13 +164,20 @@ func(connector *WebConnector) ParseSiteData(business connectors.BusinessMetadata
     data []byte) (connectors.BusinessData, error) {
 
     log.Info(fmt.Sprintf("received and parsing %d bytes of data", len(data)))
+    // determine extraction profile for business. the profile determines
+    // which regexes are run and the region used to validate numbers
+    profile, err := phone.ProfileForBusiness(business.Metadata, connector.PhoneRegion)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to determine phone extraction profile: %+v", err))
+        return connectors.BusinessData{}, err
+    }
     // parse site data for phone numbers by using regex expressions
-    phones := utils.GetPhoneNumbersByRegex(string(data))
+    phones := profile.ExtractNumbers(string(data))
 
     // create new accessor for utils API and validate phone numbers
     access := api.NewUtilsAPIAccessor(connector.UtilsAPIConfig.Host, "http",
         connector.UtilsAPIConfig.Port)
-    results, err := access.ValidatePhoneNumbers(phones)
+    results, err := access.ValidatePhoneNumbers(phones, profile.Country)
     if err != nil {
         log.Error(fmt.Errorf("unable to verify phone numbers with API: %+v", err))
         return connectors.BusinessData{}, err
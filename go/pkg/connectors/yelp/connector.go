@@ -7,7 +7,7 @@ import (
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
-    "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/phone"
 )
 
 // define struct for Yelp API connector
@@ -16,15 +16,18 @@ type YelpAPIConnector struct{
     BaseAPIUrl string
     // API key to gain access to API
     APIKey     string
+    // default region used to normalize phone numbers
+    PhoneRegion string
 }
 
 // function used to generate a new Yelp API connector. note that
 // both the base URL and a valid API key must be provided to the
 // constructor
-func NewYelpAPIConnector(baseUrl, apiKey string) *YelpAPIConnector {
+func NewYelpAPIConnector(baseUrl, apiKey, phoneRegion string) *YelpAPIConnector {
     return &YelpAPIConnector{
         BaseAPIUrl: baseUrl,
         APIKey: apiKey,
+        PhoneRegion: phoneRegion,
     }
 }
 
@@ -81,7 +84,7 @@ func(connector *YelpAPIConnector) UpdateBusiness(business connectors.BusinessMet
     // generate new payload based on results from yelp API
     payload := connectors.BusinessData{
         WebsiteLive: true,
-        BusinessPhones: []string{utils.CleanNumber(yelpResults.PhoneNumber)},
+        BusinessPhones: phone.NormalizeAll([]string{yelpResults.PhoneNumber}, phone.RegionFromMetadata(business.Metadata, connector.PhoneRegion)),
         Source: connector.Name(),
         BusinessOpen: yelpResults.IsOpen,
     }
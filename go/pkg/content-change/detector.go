@@ -0,0 +1,157 @@
+package content_change
+
+import (
+    "fmt"
+    "time"
+    "strings"
+    "crypto/sha256"
+    "encoding/hex"
+
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/notifications"
+    apis "texas_real_foods/pkg/utils/api_accessors"
+)
+
+var (
+    // define maximum number of diff lines attached to a notification
+    MaxNotificationDiffLines = 200
+)
+
+// function used to generate a new content change detector. the
+// detector stores the visible text of each scraped page and
+// compares it to the previously stored text. notifications are
+// sent for any changes containing keyword hits or for changes
+// with a change score above the given threshold
+func NewChangeDetector(db *Persistence, notifyAPIConfig utils.APIDependencyConfig,
+    threshold float64) *ChangeDetector {
+    return &ChangeDetector{
+        Persistence: db,
+        NotificationsAPIConfig: notifyAPIConfig,
+        ChangeThreshold: threshold,
+        Keywords: ChangeKeywords,
+    }
+}
+
+type ChangeDetector struct {
+    Persistence            *Persistence
+    NotificationsAPIConfig utils.APIDependencyConfig
+    ChangeThreshold        float64
+    Keywords               []string
+}
+
+// function used to process the contents of a scraped page. the
+// visible text is extracted and compared to the previous snapshot
+// stored for the business, and notifications are sent if required
+func(detector *ChangeDetector) ProcessPage(business connectors.BusinessMetadata,
+    body []byte) (ChangeReport, error) {
+
+    var report ChangeReport
+    now := time.Now()
+    lines := ExtractVisibleText(body)
+    current := TextSnapshot{
+        BusinessId: business.BusinessId,
+        ContentHash: HashLines(lines),
+        Content: lines,
+        FirstSeen: now,
+        LastSeen: now,
+    }
+
+    previous, err := detector.Persistence.GetLatestSnapshot(business.BusinessId)
+    if err == ErrSnapshotNotFound {
+        log.Info(fmt.Sprintf("storing initial text snapshot for business %s", business.BusinessName))
+        return report, detector.Persistence.InsertSnapshot(current)
+    } else if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve previous snapshot: %+v", err))
+        return report, err
+    }
+
+    // update last seen timestamp if content has not changed
+    if previous.ContentHash == current.ContentHash {
+        log.Debug(fmt.Sprintf("no content changes detected for business %s", business.BusinessName))
+        return report, detector.Persistence.TouchSnapshot(previous, now)
+    }
+
+    diff := DiffLines(previous.Content, current.Content)
+    report = ChangeReport{
+        Changed: true,
+        ChangeScore: ScoreChange(diff),
+        KeywordHits: FindKeywordHits(diff, detector.Keywords),
+        Diff: ChangedLines(diff),
+    }
+    log.Info(fmt.Sprintf("detected content change for business %s with score %f and keyword hits %+v",
+        business.BusinessName, report.ChangeScore, report.KeywordHits))
+
+    current.ChangeScore = report.ChangeScore
+    if err := detector.Persistence.InsertSnapshot(current); err != nil {
+        return report, err
+    }
+
+    if len(report.KeywordHits) > 0 || report.ChangeScore >= detector.ChangeThreshold {
+        if err := detector.SendChangeNotification(business, previous, current, report); err != nil {
+            log.Warn(fmt.Sprintf("unable to send content change notification: %+v", err))
+        }
+    }
+    return report, nil
+}
+
+// function used to send a notification for a detected content change
+// from the previous snapshot to the given snapshot. the diff is attached
+// to the notification as metadata
+func(detector *ChangeDetector) SendChangeNotification(business connectors.BusinessMetadata,
+    previous, snapshot TextSnapshot, report ChangeReport) error {
+
+    notificationString := fmt.Sprintf("Found website content change for business %s at URI %s (change score %.2f)",
+        business.BusinessName, business.BusinessURI, report.ChangeScore)
+    if len(report.KeywordHits) > 0 {
+        notificationString = fmt.Sprintf("%s: the following keywords were found %+v",
+            notificationString, report.KeywordHits)
+    }
+
+    // convert diff to list of strings and truncate to limit
+    diff := []string{}
+    for _, line := range(report.Diff) {
+        if len(diff) >= MaxNotificationDiffLines {
+            break
+        }
+        diff = append(diff, line.String())
+    }
+
+    notification := notifications.ChangeNotification{
+        BusinessId: business.BusinessId,
+        BusinessName: business.BusinessName,
+        EventTimestamp: time.Now(),
+        Notification: notificationString,
+        NotificationHash: generateNotificationHash(business.BusinessId.String(), previous,
+            snapshot.ContentHash),
+        Metadata: map[string]interface{}{
+            "source": "content-change-detector",
+            "change_score": report.ChangeScore,
+            "keyword_hits": strings.Join(report.KeywordHits, ","),
+            "content_hash": snapshot.ContentHash,
+            "diff": diff,
+        },
+    }
+
+    // create new API accessor and send notification to API
+    accessor := apis.NewNotificationsApiAccessorFromConfig(detector.NotificationsAPIConfig)
+    if _, err := accessor.CreateNotification(notification); err != nil {
+        log.Error(fmt.Errorf("unable to send new notification: %+v", err))
+        return err
+    }
+    return nil
+}
+
+// function used to generate notification hash. hashes are generated
+// from the business ID, the previous snapshot and the new content hash
+// to ensure that only a single notification is sent for each change.
+// snapshots are identified by the time they were first seen, so pages
+// that change back to earlier content are notified again
+func generateNotificationHash(businessId string, previous TextSnapshot, contentHash string) string {
+    notifyString := fmt.Sprintf("%s:content-change:%s:%s:%s", businessId, previous.ContentHash,
+        previous.FirstSeen.UTC().Format(time.RFC3339Nano), contentHash)
+    notificationHash := sha256.Sum256([]byte(notifyString))
+    return hex.EncodeToString(notificationHash[0:])
+}
@@ -0,0 +1,33 @@
+package content_change
+
+import (
+    "time"
+    "testing"
+)
+
+func TestGenerateNotificationHash(t *testing.T) {
+    first := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    snapshotA := TextSnapshot{ContentHash: "a", FirstSeen: first}
+    snapshotB := TextSnapshot{ContentHash: "b", FirstSeen: first.Add(time.Hour)}
+    // page changed from A to B, back to A and then to B again
+    snapshotA2 := TextSnapshot{ContentHash: "a", FirstSeen: first.Add(2 * time.Hour)}
+
+    tests := []struct {
+        name     string
+        previous TextSnapshot
+        current  string
+        other    TextSnapshot
+        same     bool
+    }{
+        {"same change", snapshotA, "b", snapshotA, true},
+        {"change back", snapshotB, "a", snapshotA, false},
+        {"repeated change", snapshotA2, "b", snapshotA, false},
+    }
+    for _, test := range(tests) {
+        hash := generateNotificationHash("business", test.previous, test.current)
+        other := generateNotificationHash("business", test.other, "b")
+        if (hash == other) != test.same {
+            t.Errorf("%s: expected equal hashes %v, got %s and %s", test.name, test.same, hash, other)
+        }
+    }
+}
@@ -0,0 +1,206 @@
+package content_change
+
+import (
+    "fmt"
+)
+
+var (
+    // define maximum number of lines compared in a single diff. pages
+    // exceeding the limit are truncated before the diff is computed
+    MaxDiffLines = 5000
+)
+
+// struct used to store a single line of a text diff. the
+// operation is one of '+' (added), '-' (removed) or ' '
+// (unchanged)
+type DiffLine struct {
+    Operation string `json:"operation"`
+    Text      string `json:"text"`
+}
+
+func(line DiffLine) String() string {
+    return fmt.Sprintf("%s %s", line.Operation, line.Text)
+}
+
+// function used to compute a line based diff between two
+// collections of lines using the linear space variant of the
+// Myers diff algorithm
+func DiffLines(previous, current []string) []DiffLine {
+    if len(previous) > MaxDiffLines {
+        previous = previous[:MaxDiffLines]
+    }
+    if len(current) > MaxDiffLines {
+        current = current[:MaxDiffLines]
+    }
+
+    // paths are stored in two vectors that are reused for every
+    // bisection, meaning that memory is linear in the number of lines
+    size := len(previous) + len(current) + 3
+    differ := &lineDiffer{
+        previous: previous,
+        current: current,
+        forward: make([]int, size),
+        backward: make([]int, size),
+        diff: make([]DiffLine, 0, len(previous) + len(current)),
+    }
+    differ.compare(0, len(previous), 0, len(current))
+    return differ.diff
+}
+
+// struct used to store the state of a single diff
+type lineDiffer struct {
+    previous []string
+    current  []string
+    forward  []int
+    backward []int
+    diff     []DiffLine
+}
+
+// function used to diff the previous lines from a0 to a1 against the
+// current lines from b0 to b1. common prefixes and suffixes are removed
+// before the remaining lines are split at the middle of the shortest
+// edit path and compared recursively
+func(differ *lineDiffer) compare(a0, a1, b0, b1 int) {
+    for a0 < a1 && b0 < b1 && differ.previous[a0] == differ.current[b0] {
+        differ.diff = append(differ.diff, DiffLine{" ", differ.previous[a0]})
+        a0++; b0++
+    }
+    suffix := 0
+    for a1 > a0 && b1 > b0 && differ.previous[a1 - 1] == differ.current[b1 - 1] {
+        a1--; b1--; suffix++
+    }
+
+    switch {
+    case a0 == a1:
+        for ; b0 < b1; b0++ {
+            differ.diff = append(differ.diff, DiffLine{"+", differ.current[b0]})
+        }
+    case b0 == b1:
+        for ; a0 < a1; a0++ {
+            differ.diff = append(differ.diff, DiffLine{"-", differ.previous[a0]})
+        }
+    default:
+        if x, y, ok := differ.bisect(a0, a1, b0, b1); ok {
+            differ.compare(a0, x, b0, y)
+            differ.compare(x, a1, y, b1)
+        } else {
+            for i := a0; i < a1; i++ {
+                differ.diff = append(differ.diff, DiffLine{"-", differ.previous[i]})
+            }
+            for j := b0; j < b1; j++ {
+                differ.diff = append(differ.diff, DiffLine{"+", differ.current[j]})
+            }
+        }
+    }
+
+    for i := a1; i < a1 + suffix; i++ {
+        differ.diff = append(differ.diff, DiffLine{" ", differ.previous[i]})
+    }
+}
+
+// function used to find the point at which the forward and backward
+// shortest edit paths between two ranges of lines overlap. false is
+// returned if the ranges have no lines in common
+func(differ *lineDiffer) bisect(a0, a1, b0, b1 int) (int, int, bool) {
+    previous, current := differ.previous[a0:a1], differ.current[b0:b1]
+    n, m := len(previous), len(current)
+    maxD := (n + m + 1) / 2
+    offset := maxD
+    length := 2 * maxD + 2
+    forward, backward := differ.forward[:length], differ.backward[:length]
+    for i := range(forward) {
+        forward[i], backward[i] = -1, -1
+    }
+    forward[offset + 1], backward[offset + 1] = 0, 0
+
+    // paths of the forward and backward searches can only overlap on
+    // the forward search if the difference in lengths is odd
+    delta := n - m
+    front := delta % 2 != 0
+    // skip diagonals that have run past the end of either range
+    forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+
+    for d := 0; d < maxD; d++ {
+        for k := -d + forwardStart; k <= d - forwardEnd; k += 2 {
+            index := offset + k
+            var x int
+            if k == -d || (k != d && forward[index - 1] < forward[index + 1]) {
+                x = forward[index + 1]
+            } else {
+                x = forward[index - 1] + 1
+            }
+            y := x - k
+            for x < n && y < m && previous[x] == current[y] {
+                x++; y++
+            }
+            forward[index] = x
+            if x > n {
+                forwardEnd += 2
+            } else if y > m {
+                forwardStart += 2
+            } else if front {
+                reverse := offset + delta - k
+                if reverse >= 0 && reverse < length && backward[reverse] != -1 && x >= n - backward[reverse] {
+                    return a0 + x, b0 + y, true
+                }
+            }
+        }
+
+        for k := -d + backwardStart; k <= d - backwardEnd; k += 2 {
+            index := offset + k
+            var x int
+            if k == -d || (k != d && backward[index - 1] < backward[index + 1]) {
+                x = backward[index + 1]
+            } else {
+                x = backward[index - 1] + 1
+            }
+            y := x - k
+            for x < n && y < m && previous[n - x - 1] == current[m - y - 1] {
+                x++; y++
+            }
+            backward[index] = x
+            if x > n {
+                backwardEnd += 2
+            } else if y > m {
+                backwardStart += 2
+            } else if !front {
+                reverse := offset + delta - k
+                if reverse >= 0 && reverse < length && forward[reverse] != -1 {
+                    fx := forward[reverse]
+                    fy := offset + fx - reverse
+                    if fx >= n - x {
+                        return a0 + fx, b0 + fy, true
+                    }
+                }
+            }
+        }
+    }
+    return 0, 0, false
+}
+
+// function used to score the magnitude of a change. the score
+// is the fraction of lines that were added or removed, meaning
+// that 0 indicates no change and 1 indicates a complete rewrite
+func ScoreChange(diff []DiffLine) float64 {
+    if len(diff) == 0 {
+        return 0
+    }
+    changed := 0
+    for _, line := range(diff) {
+        if line.Operation != " " {
+            changed++
+        }
+    }
+    return float64(changed) / float64(len(diff))
+}
+
+// function used to reduce a diff to the changed lines only
+func ChangedLines(diff []DiffLine) []DiffLine {
+    changed := []DiffLine{}
+    for _, line := range(diff) {
+        if line.Operation != " " {
+            changed = append(changed, line)
+        }
+    }
+    return changed
+}
@@ -0,0 +1,160 @@
+package content_change
+
+import (
+    "strings"
+    "testing"
+    "math/rand"
+)
+
+// function used to split a string of single character lines
+func testLines(text string) []string {
+    if len(text) == 0 {
+        return []string{}
+    }
+    return strings.Split(text, "")
+}
+
+// function used to rebuild the previous and current lines from a diff
+func applyDiff(diff []DiffLine) ([]string, []string) {
+    previous, current := []string{}, []string{}
+    for _, line := range(diff) {
+        if line.Operation != "+" {
+            previous = append(previous, line.Text)
+        }
+        if line.Operation != "-" {
+            current = append(current, line.Text)
+        }
+    }
+    return previous, current
+}
+
+// function used to compute the length of the longest common subsequence
+// of two collections of lines
+func commonLines(a, b []string) int {
+    lengths := make([][]int, len(a) + 1)
+    for i := range(lengths) {
+        lengths[i] = make([]int, len(b) + 1)
+    }
+    for i := len(a) - 1; i >= 0; i-- {
+        for j := len(b) - 1; j >= 0; j-- {
+            switch {
+            case a[i] == b[j]:
+                lengths[i][j] = lengths[i + 1][j + 1] + 1
+            case lengths[i + 1][j] > lengths[i][j + 1]:
+                lengths[i][j] = lengths[i + 1][j]
+            default:
+                lengths[i][j] = lengths[i][j + 1]
+            }
+        }
+    }
+    return lengths[0][0]
+}
+
+// function used to check that a diff transforms the previous lines into the
+// current lines and keeps as many lines as possible unchanged
+func checkDiff(t *testing.T, previous, current []string, diff []DiffLine) {
+    before, after := applyDiff(diff)
+    if strings.Join(before, "\n") != strings.Join(previous, "\n") ||
+        strings.Join(after, "\n") != strings.Join(current, "\n") {
+        t.Fatalf("diff of %v and %v does not rebuild lines: %v", previous, current, diff)
+    }
+    unchanged := 0
+    for _, line := range(diff) {
+        if line.Operation == " " {
+            unchanged++
+        }
+    }
+    if expected := commonLines(previous, current); unchanged != expected {
+        t.Fatalf("diff of %v and %v keeps %d lines, expected %d", previous, current, unchanged, expected)
+    }
+}
+
+func TestDiffLines(t *testing.T) {
+    tests := []struct {
+        previous string
+        current  string
+        expected string
+    }{
+        {"", "", ""},
+        {"", "ab", "+a +b"},
+        {"ab", "", "-a -b"},
+        {"abc", "abc", " a  b  c"},
+        {"abc", "axc", " a -b +x  c"},
+        {"abcd", "acd", " a -b  c  d"},
+        {"abc", "zabc", "+z  a  b  c"},
+        {"abc", "abcz", " a  b  c +z"},
+        {"abcabba", "cbabac", ""},
+        {"abcdef", "fedcba", ""},
+    }
+    for _, test := range(tests) {
+        previous, current := testLines(test.previous), testLines(test.current)
+        diff := DiffLines(previous, current)
+        checkDiff(t, previous, current, diff)
+        if len(test.expected) == 0 {
+            continue
+        }
+        result := []string{}
+        for _, line := range(diff) {
+            result = append(result, line.Operation + line.Text)
+        }
+        if strings.Join(result, " ") != test.expected {
+            t.Errorf("diff of %q and %q: expected %q, got %q", test.previous, test.current,
+                test.expected, strings.Join(result, " "))
+        }
+    }
+}
+
+func TestDiffLinesRandom(t *testing.T) {
+    random := rand.New(rand.NewSource(1))
+    randomLines := func() []string {
+        lines := make([]string, random.Intn(30))
+        for i := range(lines) {
+            lines[i] = string(rune('a' + random.Intn(4)))
+        }
+        return lines
+    }
+    for i := 0; i < 2000; i++ {
+        previous, current := randomLines(), randomLines()
+        checkDiff(t, previous, current, DiffLines(previous, current))
+    }
+}
+
+func TestDiffLinesLimit(t *testing.T) {
+    previous, current := make([]string, MaxDiffLines + 10), make([]string, MaxDiffLines + 10)
+    for i := range(previous) {
+        previous[i] = strings.Repeat("a", i % 7)
+        current[i] = strings.Repeat("a", i % 5)
+    }
+    before, after := applyDiff(DiffLines(previous, current))
+    if len(before) != MaxDiffLines || len(after) != MaxDiffLines {
+        t.Errorf("expected diff of %d lines, got %d and %d", MaxDiffLines, len(before), len(after))
+    }
+}
+
+func TestFindKeywordHits(t *testing.T) {
+    tests := []struct {
+        name     string
+        diff     []DiffLine
+        keywords []string
+        expected []string
+    }{
+        {"whole word", []DiffLine{{"+", "We have MOVED to Main St."}}, ChangeKeywords,
+            []string{"moved"}},
+        {"partial word", []DiffLine{{"+", "Gluten removed from all bread"}}, ChangeKeywords,
+            []string{}},
+        {"removed lines", []DiffLine{{"-", "We have moved"}, {" ", "new hours"}}, ChangeKeywords,
+            []string{}},
+        {"multiple keywords", []DiffLine{{"+", "New hours: 9-5"}, {"+", "we're closing early, new hours"}},
+            ChangeKeywords, []string{"new hours", "we're closing"}},
+        {"punctuation", []DiffLine{{"+", "now open!"}, {"+", "now opening"}}, []string{"open!", "(new)"},
+            []string{"open!"}},
+        {"leading punctuation", []DiffLine{{"+", "menu (new)"}}, []string{"(new)"},
+            []string{"(new)"}},
+    }
+    for _, test := range(tests) {
+        hits := FindKeywordHits(test.diff, test.keywords)
+        if strings.Join(hits, ",") != strings.Join(test.expected, ",") {
+            t.Errorf("%s: expected %v, got %v", test.name, test.expected, hits)
+        }
+    }
+}
@@ -0,0 +1,82 @@
+package content_change
+
+import (
+    "regexp"
+    "strings"
+)
+
+var (
+    // define keywords that indicate important changes to a business
+    // i.e. closures, relocations or changes to opening hours. note
+    // that keywords are matched against lower case text, and only
+    // match whole words i.e. 'moved' does not match 'removed'
+    ChangeKeywords = []string{
+        "closed permanently",
+        "permanently closed",
+        "closing permanently",
+        "we're closing",
+        "we are closing",
+        "closing our doors",
+        "moved",
+        "we're moving",
+        "we are moving",
+        "relocating",
+        "new location",
+        "new address",
+        "new hours",
+        "updated hours",
+        "temporarily closed",
+    }
+
+    // define patterns used to check if keywords start or end with a
+    // word character
+    wordStart = regexp.MustCompile(`^\w`)
+    wordEnd = regexp.MustCompile(`\w$`)
+)
+
+// function used to find keyword hits in the lines added in a
+// given diff. note that only added lines are checked, meaning
+// that keywords already present on a page are not reported
+func FindKeywordHits(diff []DiffLine, keywords []string) []string {
+    patterns := make([]*regexp.Regexp, len(keywords))
+    for i, keyword := range(keywords) {
+        patterns[i] = keywordPattern(keyword)
+    }
+
+    hits := []string{}
+    for _, line := range(diff) {
+        if line.Operation != "+" {
+            continue
+        }
+        text := strings.ToLower(line.Text)
+        for i, keyword := range(keywords) {
+            if patterns[i].MatchString(text) && !stringSliceContains(hits, keyword) {
+                hits = append(hits, keyword)
+            }
+        }
+    }
+    return hits
+}
+
+// function used to generate a pattern matching a keyword on word
+// boundaries. boundaries are only required next to word characters so
+// that keywords starting or ending with punctuation still match
+func keywordPattern(keyword string) *regexp.Regexp {
+    pattern := regexp.QuoteMeta(strings.ToLower(keyword))
+    if wordStart.MatchString(keyword) {
+        pattern = `\b` + pattern
+    }
+    if wordEnd.MatchString(keyword) {
+        pattern = pattern + `\b`
+    }
+    return regexp.MustCompile(pattern)
+}
+
+func stringSliceContains(slice []string, element string) bool {
+    for _, entry := range(slice) {
+        if entry == element {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,28 @@
+package content_change
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// struct used to store the visible text extracted from a
+// business website. identical snapshots are only stored once,
+// with the last seen timestamp updated on each scrape
+type TextSnapshot struct {
+    BusinessId  uuid.UUID `json:"business_id"`
+    ContentHash string    `json:"content_hash"`
+    Content     []string  `json:"content"`
+    ChangeScore float64   `json:"change_score"`
+    FirstSeen   time.Time `json:"first_seen"`
+    LastSeen    time.Time `json:"last_seen"`
+}
+
+// struct used to store the results of comparing a new snapshot
+// to the previous snapshot for a given business
+type ChangeReport struct {
+    Changed     bool       `json:"changed"`
+    ChangeScore float64    `json:"change_score"`
+    KeywordHits []string   `json:"keyword_hits"`
+    Diff        []DiffLine `json:"diff"`
+}
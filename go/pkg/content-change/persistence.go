@@ -0,0 +1,94 @@
+package content_change
+
+import (
+    "fmt"
+    "time"
+    "errors"
+    "context"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+)
+
+var (
+    // define custom errors
+    ErrSnapshotNotFound = errors.New("Cannot find text snapshot")
+)
+
+type Persistence struct{
+    *utils.BasePostgresPersistence
+}
+
+func NewPersistence(url string) *Persistence {
+    // create instance of base persistence
+    basePersistence := utils.NewPersistence(url)
+    return &Persistence{
+        basePersistence,
+    }
+}
+
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to retrieve the most recent text snapshot for
+// a given business with business ID
+func(db *Persistence) GetLatestSnapshot(businessId uuid.UUID) (TextSnapshot, error) {
+    log.Debug(fmt.Sprintf("retrieving latest text snapshot for business %s", businessId))
+
+    snapshot := TextSnapshot{BusinessId: businessId}
+    query := `SELECT content_hash,content,change_score,first_seen,last_seen
+        FROM site_text_snapshots WHERE business_id=$1 ORDER BY first_seen DESC LIMIT 1`
+    err := db.Session.QueryRow(context.Background(), query, businessId).Scan(
+        &snapshot.ContentHash, &snapshot.Content, &snapshot.ChangeScore,
+        &snapshot.FirstSeen, &snapshot.LastSeen)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return snapshot, ErrSnapshotNotFound
+        default:
+            return snapshot, err
+        }
+    }
+    return snapshot, nil
+}
+
+// function used to insert a new text snapshot into the database
+func(db *Persistence) InsertSnapshot(snapshot TextSnapshot) error {
+    log.Debug(fmt.Sprintf("storing text snapshot %s for business %s",
+        snapshot.ContentHash, snapshot.BusinessId))
+
+    query := `INSERT INTO site_text_snapshots(business_id,content_hash,content,change_score,
+        first_seen,last_seen) VALUES($1,$2,$3,$4,$5,$6)`
+    _, err := db.Session.Exec(context.Background(), query, snapshot.BusinessId,
+        snapshot.ContentHash, snapshot.Content, snapshot.ChangeScore,
+        snapshot.FirstSeen, snapshot.LastSeen)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to insert text snapshot into database: %+v", err))
+        return err
+    }
+    return nil
+}
+
+// function used to update the last seen timestamp of an existing
+// snapshot. this is used when a scrape returns unchanged content
+func(db *Persistence) TouchSnapshot(snapshot TextSnapshot, ts time.Time) error {
+    log.Debug(fmt.Sprintf("updating last seen timestamp for business %s", snapshot.BusinessId))
+
+    query := `UPDATE site_text_snapshots SET last_seen=$1 WHERE business_id=$2 AND first_seen=$3`
+    _, err := db.Session.Exec(context.Background(), query, ts, snapshot.BusinessId,
+        snapshot.FirstSeen)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to update text snapshot: %+v", err))
+        return err
+    }
+    return nil
+}
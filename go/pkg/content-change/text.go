@@ -0,0 +1,98 @@
+package content_change
+
+import (
+    "bytes"
+    "strings"
+    "crypto/sha256"
+    "encoding/hex"
+
+    "golang.org/x/net/html"
+)
+
+var (
+    // define elements whose contents are never visible to the user
+    hiddenElements = map[string]bool{
+        "script": true,
+        "style": true,
+        "noscript": true,
+        "template": true,
+        "svg": true,
+        "head": true,
+        "iframe": true,
+    }
+    // define elements that force a line break in the extracted text
+    blockElements = map[string]bool{
+        "p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true,
+        "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+        "tr": true, "td": true, "th": true, "table": true, "section": true,
+        "article": true, "header": true, "footer": true, "nav": true, "main": true,
+        "aside": true, "address": true, "blockquote": true, "form": true, "hr": true,
+    }
+)
+
+// function used to extract the visible text from a HTML document.
+// text is returned as a series of normalized lines, with line
+// breaks inserted at the boundaries of block level elements
+func ExtractVisibleText(body []byte) []string {
+    tokenizer := html.NewTokenizer(bytes.NewReader(body))
+
+    var (current strings.Builder; hiddenDepth int)
+    lines := []string{}
+    // function used to flush current line into results
+    flush := func() {
+        if line := NormalizeLine(current.String()); len(line) > 0 {
+            lines = append(lines, line)
+        }
+        current.Reset()
+    }
+
+    for {
+        switch tokenizer.Next() {
+        case html.ErrorToken:
+            // error tokens are returned at the end of the document
+            flush()
+            return lines
+        case html.StartTagToken:
+            name, _ := tokenizer.TagName()
+            if hiddenElements[string(name)] {
+                hiddenDepth++
+            } else if blockElements[string(name)] {
+                flush()
+            }
+        case html.EndTagToken:
+            name, _ := tokenizer.TagName()
+            if hiddenElements[string(name)] && hiddenDepth > 0 {
+                hiddenDepth--
+            } else if blockElements[string(name)] {
+                flush()
+            }
+        case html.SelfClosingTagToken:
+            name, _ := tokenizer.TagName()
+            if blockElements[string(name)] {
+                flush()
+            }
+        case html.TextToken:
+            if hiddenDepth == 0 {
+                current.Write(tokenizer.Text())
+                current.WriteString(" ")
+            }
+        }
+    }
+}
+
+// function used to normalize a single line of text. whitespace
+// is collapsed and common typographic characters are replaced
+// to prevent cosmetic changes from registering as content changes
+func NormalizeLine(line string) string {
+    replacer := strings.NewReplacer(
+        "\u2019", "'", "\u2018", "'", "\u201c", "\"", "\u201d", "\"",
+        "\u00a0", " ", "\u2013", "-", "\u2014", "-",
+    )
+    return strings.Join(strings.Fields(replacer.Replace(line)), " ")
+}
+
+// function used to generate a hash of a collection of lines
+func HashLines(lines []string) string {
+    sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+    return hex.EncodeToString(sum[0:])
+}
@@ -18,7 +18,7 @@ var (
     // define global configuration for Utils API
     utilsAPIConfig utils.APIDependencyConfig
 
-    persistence *Persistence
+    persistence Repository
 )
 
 type MailChimpConfig struct {
@@ -30,7 +30,7 @@ type MailChimpConfig struct {
 
 // function used to generate new mail
 func NewMailRelay(cfg MailChimpConfig, utilsConfig utils.APIDependencyConfig,
-    db *Persistence) *gin.Engine {
+    db Repository) *gin.Engine {
     // set MailChimp and Utils API config settings globally
     utilsAPIConfig = utilsConfig
     mailChimpConfig = cfg
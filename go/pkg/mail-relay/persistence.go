@@ -9,6 +9,7 @@ import (
 
     "github.com/google/uuid"
     "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
@@ -30,6 +31,14 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
 func(db *Persistence) InsertMailEntry(request MailRelayRequest) (uuid.UUID, error) {
     log.Debug(fmt.Sprintf("inserting new mail entry %+v", request))
     entryId := uuid.New()
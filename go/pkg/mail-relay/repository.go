@@ -0,0 +1,13 @@
+package mail_relay
+
+import (
+    "github.com/google/uuid"
+)
+
+// interface used to store mail relay entries. postgres persistence
+// is the default implementation
+type Repository interface {
+    InsertMailEntry(request MailRelayRequest) (uuid.UUID, error)
+    UpdateMailEntry(entryId uuid.UUID, status string, completed bool) error
+    GetMailEntries() ([]MailRelayEntry, error)
+}
@@ -0,0 +1,410 @@
+package memory
+
+import (
+    "sort"
+    "time"
+    "encoding/json"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/api"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
+)
+
+// function used to generate business info from a stored business
+func(store *Store) businessInfo(businessId uuid.UUID, entry *business) api.BusinessInfo {
+    info := api.BusinessInfo{
+        BusinessId: businessId,
+        BusinessName: entry.Name,
+        BusinessURI: entry.URI,
+        Added: entry.Added,
+        LastUpdate: entry.LastUpdate,
+        Metadata: copyJSON(entry.Metadata),
+        Version: entry.Version,
+    }
+    if entry.DeletedAt != nil {
+        deletedAt := *entry.DeletedAt
+        info.DeletedAt = &deletedAt
+    }
+    return info
+}
+
+// function used to retrieve a business that has not been deleted
+func(store *Store) activeBusiness(businessId uuid.UUID) (*business, bool) {
+    entry, ok := store.businesses[businessId]
+    if !ok || entry.DeletedAt != nil {
+        return nil, false
+    }
+    return entry, true
+}
+
+// function to insert new business into the store
+func(store *Store) CreateBusiness(request api.NewBusinessRequest, actor api.Actor) error {
+    _, err := store.ImportBusinesses([]api.NewBusinessRequest{request}, actor)
+    return err
+}
+
+// function to insert multiple businesses into the store. businesses are
+// inserted under a single lock so that imports are applied atomically
+func(store *Store) ImportBusinesses(requests []api.NewBusinessRequest, actor api.Actor) ([]uuid.UUID, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    results := []uuid.UUID{}
+    now := time.Now()
+    for _, request := range(requests) {
+        businessId := uuid.New()
+        snapshot := snapshotOf(&business{Name: request.BusinessName, URI: request.BusinessURI,
+            Metadata: request.Metadata})
+        store.businesses[businessId] = &business{
+            Name: snapshot.BusinessName,
+            URI: snapshot.BusinessURI,
+            Added: now,
+            LastUpdate: now,
+            Metadata: snapshot.Metadata,
+            Version: 1,
+        }
+        entry := api.NewAuditEntry(businessId, api.AuditActionCreate, actor, nil, snapshot, nil)
+        entry.Version = 1
+        store.recordChange(entry)
+        results = append(results, businessId)
+    }
+    return results, nil
+}
+
+// function used to retrieve all businesses that have not been deleted
+// sorted by the time they were added. golden records are attached if
+// they have been computed
+func(store *Store) GetBusinesses() ([]api.BusinessInfo, error) {
+    return store.listBusinesses(false), nil
+}
+
+// function used to retrieve the businesses that have not been deleted
+// and are on one of the given domains or have one of the given names
+func(store *Store) GetImportCandidates(domains, names []string) ([]api.BusinessInfo, error) {
+    keys := map[string]bool{}
+    for _, domain := range(domains) {
+        keys["domain:" + domain] = true
+    }
+    for _, name := range(names) {
+        keys["name:" + name] = true
+    }
+    results := []api.BusinessInfo{}
+    for _, info := range(store.listBusinesses(false)) {
+        if keys["domain:" + api.NormaliseDomain(info.BusinessURI)] ||
+            keys["name:" + api.NormaliseName(info.BusinessName)] {
+            results = append(results, info)
+        }
+    }
+    return results, nil
+}
+
+// function used to retrieve all businesses sorted by the time they were
+// added, optionally including deleted businesses
+func(store *Store) listBusinesses(includeDeleted bool) []api.BusinessInfo {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []api.BusinessInfo{}
+    for businessId, entry := range(store.businesses) {
+        if entry.DeletedAt != nil && !includeDeleted {
+            continue
+        }
+        info := store.businessInfo(businessId, entry)
+        if record, ok := store.goldenRecords[businessId]; ok {
+            info.GoldenRecord = &record
+        }
+        results = append(results, info)
+    }
+    sort.Slice(results, func(i, j int) bool {
+        if results[i].Added.Equal(results[j].Added) {
+            return results[i].BusinessId.String() < results[j].BusinessId.String()
+        }
+        return results[i].Added.Before(results[j].Added)
+    })
+    return results
+}
+
+// function used to retrieve a single page of businesses matching
+// the filters of a query
+func(store *Store) ListBusinesses(query api.BusinessQuery) (api.BusinessPage, error) {
+    return query.Paginate(store.listBusinesses(query.Deleted)), nil
+}
+
+// function used to search businesses using the trigram similarity
+// of their name, URI and metadata
+func(store *Store) SearchBusinesses(query api.SearchQuery) ([]api.BusinessSearchResult, error) {
+    businesses, err := store.GetBusinesses()
+    if err != nil {
+        return []api.BusinessSearchResult{}, err
+    }
+    return query.Search(businesses), nil
+}
+
+// function used to retrieve a single business
+func(store *Store) GetBusinessById(businessId uuid.UUID) (api.BusinessInfo, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    entry, ok := store.activeBusiness(businessId)
+    if !ok {
+        return api.BusinessInfo{}, api.ErrBusinessNotFound
+    }
+    return store.businessInfo(businessId, entry), nil
+}
+
+// function used to update the URI of a business
+func(store *Store) UpdateBusinessURI(uri string, businessId uuid.UUID, actor api.Actor,
+    ifMatch api.Precondition) (api.AuditEntry, error) {
+    return store.changeBusiness(businessId, false, ifMatch, func(before api.BusinessSnapshot) (api.AuditEntry, error) {
+        after := api.NewBusinessSnapshot(before.BusinessName, uri, before.Metadata)
+        return api.NewAuditEntry(businessId, api.AuditActionUpdateURI, actor, &before, after, nil), nil
+    })
+}
+
+// function used to update the metadata of a business
+func(store *Store) UpdateBusinessMetadata(meta map[string]interface{}, operation []map[string]interface{},
+    businessId uuid.UUID, actor api.Actor, ifMatch api.Precondition) (api.AuditEntry, error) {
+    return store.changeBusiness(businessId, false, ifMatch, func(before api.BusinessSnapshot) (api.AuditEntry, error) {
+        after := api.NewBusinessSnapshot(before.BusinessName, before.BusinessURI, meta)
+        return api.NewAuditEntry(businessId, api.AuditActionUpdateMetadata, actor, &before, after,
+            api.MetadataPatch(operation)), nil
+    })
+}
+
+// function used to soft delete a business. source data and golden
+// records are retained so that the business can be restored
+func(store *Store) DeleteBusiness(businessId uuid.UUID, actor api.Actor, ifMatch api.Precondition) error {
+    _, err := store.changeBusiness(businessId, false, ifMatch, func(before api.BusinessSnapshot) (api.AuditEntry, error) {
+        return api.NewAuditEntry(businessId, api.AuditActionDelete, actor, &before, before, nil), nil
+    })
+    return err
+}
+
+// function used to restore a soft deleted business
+func(store *Store) RestoreBusiness(businessId uuid.UUID, actor api.Actor) error {
+    _, err := store.changeBusiness(businessId, true, nil, func(before api.BusinessSnapshot) (api.AuditEntry, error) {
+        return api.NewAuditEntry(businessId, api.AuditActionRestore, actor, &before, before, nil), nil
+    })
+    return err
+}
+
+// function used to restore the name, URI and metadata of a business to
+// those of a previous version
+func(store *Store) RevertBusiness(businessId uuid.UUID, version int, actor api.Actor) (api.AuditEntry, error) {
+    return store.changeBusiness(businessId, false, nil, func(before api.BusinessSnapshot) (api.AuditEntry, error) {
+        target, err := api.SnapshotAtVersion(store.history[businessId], version)
+        if err != nil {
+            return api.AuditEntry{}, err
+        }
+        entry := api.NewAuditEntry(businessId, api.AuditActionRevert, actor, &before,
+            copySnapshot(target), nil)
+        entry.RevertedVersion = &version
+        return entry, nil
+    })
+}
+
+// function used to retrieve the audit log of a business ordered by version
+func(store *Store) GetBusinessHistory(businessId uuid.UUID) ([]api.AuditEntry, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []api.AuditEntry{}
+    for _, entry := range(store.history[businessId]) {
+        results = append(results, copyAuditEntry(entry))
+    }
+    return results, nil
+}
+
+// function used to apply a change to a business under a single lock.
+// the change is generated from the current state of the business, after
+// which the business is updated and the change is added to its audit
+// log as the next version. deleted businesses can only be restored, and
+// businesses are marked as deleted by delete actions
+func(store *Store) changeBusiness(businessId uuid.UUID, deleted bool, ifMatch api.Precondition,
+    change func(before api.BusinessSnapshot) (api.AuditEntry, error)) (api.AuditEntry, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    entry, ok := store.businesses[businessId]
+    if !ok || (entry.DeletedAt != nil) != deleted {
+        return api.AuditEntry{}, api.ErrBusinessNotFound
+    }
+    if !ifMatch.Matches(entry.Version) {
+        return api.AuditEntry{}, api.ErrVersionConflict
+    }
+    audit, err := change(snapshotOf(entry))
+    if err != nil {
+        return audit, err
+    }
+    entry.Version++
+    audit.Version = entry.Version
+    entry.Name = audit.After.BusinessName
+    entry.URI = audit.After.BusinessURI
+    entry.Metadata = copyJSON(audit.After.Metadata)
+    entry.DeletedAt = nil
+    if audit.Action == api.AuditActionDelete {
+        deletedAt := audit.ChangedAt
+        entry.DeletedAt = &deletedAt
+    }
+    return copyAuditEntry(store.recordChange(audit)), nil
+}
+
+// function used to append an entry to the audit log of a business. the
+// version of the entry is the version of the business after the change
+func(store *Store) recordChange(entry api.AuditEntry) api.AuditEntry {
+    entry = copyAuditEntry(entry)
+    store.history[entry.BusinessId] = append(store.history[entry.BusinessId], entry)
+    return entry
+}
+
+// function used to generate a snapshot of a stored business
+func snapshotOf(entry *business) api.BusinessSnapshot {
+    return copySnapshot(api.NewBusinessSnapshot(entry.Name, entry.URI, entry.Metadata))
+}
+
+// function used to copy a snapshot
+func copySnapshot(snapshot api.BusinessSnapshot) api.BusinessSnapshot {
+    return api.NewBusinessSnapshot(snapshot.BusinessName, snapshot.BusinessURI, copyJSON(snapshot.Metadata))
+}
+
+// function used to copy an audit entry. entries are converted to and
+// from JSON to match the entries returned from postgres JSON columns
+func copyAuditEntry(entry api.AuditEntry) api.AuditEntry {
+    var result api.AuditEntry
+    data, err := json.Marshal(entry)
+    if err != nil {
+        return entry
+    }
+    if err := json.Unmarshal(data, &result); err != nil {
+        return entry
+    }
+    return result
+}
+
+// function used to store the latest data of a source along with a new
+// timeseries entry, and to set the last update of the business
+func(store *Store) insertData(businessId uuid.UUID, data connectors.BusinessData,
+    meta map[string]interface{}, collectedAt time.Time) {
+
+    data = copyData(data)
+    data.CollectedAt = collectedAt
+    if _, ok := store.sources[businessId]; !ok {
+        store.sources[businessId] = map[string]sourceEntry{}
+    }
+    store.sources[businessId][data.Source] = sourceEntry{Data: data, Meta: meta}
+    store.timeseries = append(store.timeseries, timeseriesEntry{
+        BusinessId: businessId,
+        EventTimestamp: time.Now(),
+        Data: copyData(data),
+    })
+    if entry, ok := store.businesses[businessId]; ok {
+        entry.LastUpdate = time.Now()
+    }
+}
+
+// function used to store manually entered data for a business
+func(store *Store) UpdateManualData(businessId uuid.UUID, data connectors.BusinessData,
+    fields []string) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    data.Source = reconciler.ManualSource
+    store.insertData(businessId, data, copyJSON(map[string]interface{}{"fields": fields}), time.Now())
+    return nil
+}
+
+// function used to store data collected by a connector
+func(store *Store) UpdateBusinessData(update connectors.BusinessUpdate) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    collectedAt := update.Data.CollectedAt
+    if collectedAt.IsZero() {
+        collectedAt = time.Now()
+    }
+    store.insertData(update.Meta.BusinessId, update.Data, map[string]interface{}{}, collectedAt)
+    return nil
+}
+
+// function used to retrieve the latest data of all sources for a business
+func(store *Store) GetStaticBusinessData(businessId uuid.UUID) ([]connectors.BusinessData, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []connectors.BusinessData{}
+    for _, entry := range(store.sources[businessId]) {
+        results = append(results, copyData(entry.Data))
+    }
+    sort.Slice(results, func(i, j int) bool {
+        return results[i].Source < results[j].Source
+    })
+    return results, nil
+}
+
+// function used to retrieve the latest data of all sources for multiple
+// businesses. data is grouped by business and sorted by source
+func(store *Store) GetLatestSourceData(businessIds []uuid.UUID) (map[uuid.UUID][]connectors.BusinessData, error) {
+    results := map[uuid.UUID][]connectors.BusinessData{}
+    for _, businessId := range(businessIds) {
+        data, err := store.GetStaticBusinessData(businessId)
+        if err != nil {
+            return results, err
+        }
+        if len(data) > 0 {
+            results[businessId] = data
+        }
+    }
+    return results, nil
+}
+
+// function used to retrieve the latest data of all sources for a business
+// as source values used to compute golden records
+func(store *Store) GetSourceValues(businessId uuid.UUID) ([]reconciler.SourceValue, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []reconciler.SourceValue{}
+    for source, entry := range(store.sources[businessId]) {
+        value := reconciler.SourceValue{
+            Source: source,
+            Data: copyData(entry.Data),
+            Timestamp: entry.Data.CollectedAt,
+        }
+        // sources can restrict the fields they provide via metadata
+        if fields, ok := entry.Meta["fields"].([]interface{}); ok {
+            for _, field := range(fields) {
+                if name, ok := field.(string); ok {
+                    value.Fields = append(value.Fields, name)
+                }
+            }
+        }
+        results = append(results, value)
+    }
+    sort.Slice(results, func(i, j int) bool {
+        return results[i].Source < results[j].Source
+    })
+    return results, nil
+}
+
+// function used to insert or update the golden record for a business
+func(store *Store) UpdateGoldenRecord(record reconciler.GoldenRecord) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    store.goldenRecords[record.BusinessId] = record
+    return nil
+}
+
+// function used to retrieve the golden record for a business
+func(store *Store) GetGoldenRecord(businessId uuid.UUID) (reconciler.GoldenRecord, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    record, ok := store.goldenRecords[businessId]
+    if !ok {
+        return reconciler.GoldenRecord{BusinessId: businessId}, reconciler.ErrGoldenRecordNotFound
+    }
+    return record, nil
+}
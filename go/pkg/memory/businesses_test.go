@@ -0,0 +1,351 @@
+package memory
+
+import (
+    "fmt"
+    "testing"
+    neturl "net/url"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/api"
+)
+
+// function used to create a store containing the given businesses.
+// the IDs of the businesses are returned in the order given
+func newTestStore(t *testing.T, requests ...api.NewBusinessRequest) (*Store, []uuid.UUID) {
+    store := New()
+    businessIds, err := store.ImportBusinesses(requests, api.Actor{ClaimedUser: "test"})
+    if err != nil {
+        t.Fatalf("unable to import businesses: %+v", err)
+    }
+    return store, businessIds
+}
+
+func TestListBusinessesPagination(t *testing.T) {
+    store, businessIds := newTestStore(t,
+        api.NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com"},
+        api.NewBusinessRequest{BusinessName: "Bakery", BusinessURI: "https://bakery.com",
+            Metadata: map[string]interface{}{"yelp_business_id": "bakery"}},
+        api.NewBusinessRequest{BusinessName: "Eggs", BusinessURI: "https://www.eggs.com/farm"},
+        api.NewBusinessRequest{BusinessName: "Apiary", BusinessURI: "https://apiary.com"},
+        api.NewBusinessRequest{BusinessName: "Cheese", BusinessURI: "https://cheese.com",
+            Metadata: map[string]interface{}{"yelp_business_id": "cheese"}},
+    )
+    if err := store.DeleteBusiness(businessIds[0], api.Actor{}, nil); err != nil {
+        t.Fatalf("unable to delete business: %+v", err)
+    }
+
+    tests := []struct {
+        name   string
+        params string
+        pages  [][]string
+    }{
+        {"name ascending", "sort=name&limit=2",
+            [][]string{{"Apiary", "Bakery"}, {"Cheese", "Eggs"}}},
+        {"name descending", "sort=name&order=desc&limit=3",
+            [][]string{{"Eggs", "Cheese", "Bakery"}, {"Apiary"}}},
+        {"single page", "sort=name&limit=4",
+            [][]string{{"Apiary", "Bakery", "Cheese", "Eggs"}}},
+        {"metadata filter", "sort=name&has=yelp_business_id&limit=1",
+            [][]string{{"Bakery"}, {"Cheese"}}},
+        {"domain filter", "sort=name&domain=http://eggs.com",
+            [][]string{{"Eggs"}}},
+        {"name filter", "sort=name&name=RY",
+            [][]string{{"Apiary", "Bakery"}}},
+        {"deleted", "sort=name&deleted=true",
+            [][]string{{"Dairy"}}},
+        {"no results", "sort=name&name=butcher",
+            [][]string{{}}},
+    }
+    for _, test := range(tests) {
+        t.Run(test.name, func(t *testing.T) {
+            values, _ := neturl.ParseQuery(test.params)
+            for i, expected := range(test.pages) {
+                query, err := api.ParseBusinessQuery(values)
+                if err != nil {
+                    t.Fatalf("unable to parse query: %+v", err)
+                }
+                page, err := store.ListBusinesses(query)
+                if err != nil {
+                    t.Fatalf("unable to list businesses: %+v", err)
+                }
+                names := []string{}
+                for _, info := range(page.Businesses) {
+                    names = append(names, info.BusinessName)
+                }
+                if !equalStrings(names, expected) {
+                    t.Fatalf("page %d: expected %v, got %v", i, expected, names)
+                }
+                last := i == len(test.pages) - 1
+                if last != (len(page.NextCursor) == 0) {
+                    t.Fatalf("page %d: unexpected next cursor '%s'", i, page.NextCursor)
+                }
+                values.Set("cursor", page.NextCursor)
+            }
+        })
+    }
+}
+
+func TestImportBusinessesPlan(t *testing.T) {
+    store, businessIds := newTestStore(t,
+        api.NewBusinessRequest{BusinessName: "Texas Honey Co.", BusinessURI: "https://texashoney.com"})
+
+    rows := []api.ImportRow{
+        {Row: 1, Request: api.NewBusinessRequest{BusinessName: "Austin Eggs", BusinessURI: "https://austineggs.com"}},
+        {Row: 2, Request: api.NewBusinessRequest{BusinessName: "Other Honey", BusinessURI: "http://www.TexasHoney.com/"}},
+        {Row: 3, Request: api.NewBusinessRequest{BusinessName: "texas honey co", BusinessURI: "https://honey.com"}},
+        {Row: 4, Request: api.NewBusinessRequest{BusinessName: "Austin Eggs!", BusinessURI: "https://eggs.com"}},
+        {Row: 5, Request: api.NewBusinessRequest{BusinessName: "No Scheme", BusinessURI: "noscheme.com"}},
+        {Row: 6, Request: api.NewBusinessRequest{BusinessURI: "https://noname.com"}},
+        {Row: 7, Errors: []string{"unable to parse row"}},
+    }
+    existing, _ := store.GetBusinesses()
+    results := api.PlanImport(rows, existing)
+
+    tests := []struct {
+        status       string
+        duplicateOf  *uuid.UUID
+        duplicateRow int
+    }{
+        {api.ImportStatusValid, nil, 0},
+        {api.ImportStatusDuplicate, &businessIds[0], 0},
+        {api.ImportStatusDuplicate, &businessIds[0], 0},
+        {api.ImportStatusDuplicate, nil, 1},
+        {api.ImportStatusInvalid, nil, 0},
+        {api.ImportStatusInvalid, nil, 0},
+        {api.ImportStatusInvalid, nil, 0},
+    }
+    if len(results) != len(tests) {
+        t.Fatalf("expected %d results, got %d", len(tests), len(results))
+    }
+    for i, test := range(tests) {
+        result := results[i]
+        if result.Row != rows[i].Row || result.Status != test.status {
+            t.Errorf("row %d: expected status %s, got %s", rows[i].Row, test.status, result.Status)
+        }
+        if (result.DuplicateOf == nil) != (test.duplicateOf == nil) ||
+            (test.duplicateOf != nil && *result.DuplicateOf != *test.duplicateOf) {
+            t.Errorf("row %d: expected duplicate of %v, got %v", rows[i].Row, test.duplicateOf, result.DuplicateOf)
+        }
+        if result.DuplicateRow != test.duplicateRow {
+            t.Errorf("row %d: expected duplicate row %d, got %d", rows[i].Row, test.duplicateRow, result.DuplicateRow)
+        }
+        if (result.Status == api.ImportStatusInvalid) != (len(result.Errors) > 0) {
+            t.Errorf("row %d: unexpected errors %v", rows[i].Row, result.Errors)
+        }
+    }
+
+    summary := api.SummariseImport(results, true)
+    if summary.Total != 7 || summary.Valid != 1 || summary.Duplicates != 3 || summary.Invalid != 3 {
+        t.Errorf("unexpected import summary %+v", summary)
+    }
+}
+
+func TestRevertBusiness(t *testing.T) {
+    store, businessIds := newTestStore(t,
+        api.NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com",
+            Metadata: map[string]interface{}{"yelp_business_id": "dairy"}})
+    businessId := businessIds[0]
+
+    if _, err := store.UpdateBusinessURI("https://dairy.farm", businessId, api.Actor{}, nil); err != nil {
+        t.Fatalf("unable to update business URI: %+v", err)
+    }
+    if _, err := store.UpdateBusinessMetadata(map[string]interface{}{}, nil, businessId, api.Actor{}, nil); err != nil {
+        t.Fatalf("unable to update business metadata: %+v", err)
+    }
+
+    tests := []struct {
+        name     string
+        version  int
+        err      error
+        uri      string
+        metadata int
+    }{
+        {"creation", 1, nil, "https://dairy.com", 1},
+        {"uri update", 2, nil, "https://dairy.farm", 1},
+        {"revert", 4, nil, "https://dairy.com", 1},
+        {"unknown version", 10, api.ErrVersionNotFound, "", 0},
+    }
+    for _, test := range(tests) {
+        t.Run(test.name, func(t *testing.T) {
+            before, _ := store.GetBusinessById(businessId)
+            entry, err := store.RevertBusiness(businessId, test.version, api.Actor{ClaimedUser: "test"})
+            if err != test.err {
+                t.Fatalf("expected error %v, got %v", test.err, err)
+            }
+            after, _ := store.GetBusinessById(businessId)
+            if err != nil {
+                if after.Version != before.Version {
+                    t.Fatalf("failed revert changed version from %d to %d", before.Version, after.Version)
+                }
+                return
+            }
+            if entry.Action != api.AuditActionRevert || *entry.RevertedVersion != test.version ||
+                entry.Version != before.Version + 1 || after.Version != entry.Version {
+                t.Fatalf("unexpected audit entry %+v", entry)
+            }
+            if after.BusinessURI != test.uri || len(after.Metadata) != test.metadata {
+                t.Fatalf("expected URI %s with %d metadata keys, got %s with %v",
+                    test.uri, test.metadata, after.BusinessURI, after.Metadata)
+            }
+        })
+    }
+
+    history, _ := store.GetBusinessHistory(businessId)
+    for i, entry := range(history) {
+        if entry.Version != i + 1 {
+            t.Errorf("expected version %d at position %d, got %d", i + 1, i, entry.Version)
+        }
+    }
+    if len(history) != 6 {
+        t.Errorf("expected 6 audit entries, got %d", len(history))
+    }
+}
+
+func TestIfMatchConflicts(t *testing.T) {
+    tests := []struct {
+        name    string
+        ifMatch string
+        err     error
+    }{
+        {"no precondition", "", nil},
+        {"wildcard", "*", nil},
+        {"current version", `"2"`, nil},
+        {"any of versions", `"1", W/"2"`, nil},
+        {"stale version", `"1"`, api.ErrVersionConflict},
+        {"future version", `"3"`, api.ErrVersionConflict},
+    }
+    changes := map[string]func(store *Store, businessId uuid.UUID, ifMatch api.Precondition) error{
+        "uri": func(store *Store, businessId uuid.UUID, ifMatch api.Precondition) error {
+            _, err := store.UpdateBusinessURI("https://other.com", businessId, api.Actor{}, ifMatch)
+            return err
+        },
+        "metadata": func(store *Store, businessId uuid.UUID, ifMatch api.Precondition) error {
+            _, err := store.UpdateBusinessMetadata(map[string]interface{}{"key": "value"}, nil,
+                businessId, api.Actor{}, ifMatch)
+            return err
+        },
+        "delete": func(store *Store, businessId uuid.UUID, ifMatch api.Precondition) error {
+            return store.DeleteBusiness(businessId, api.Actor{}, ifMatch)
+        },
+    }
+    for _, test := range(tests) {
+        for action, change := range(changes) {
+            t.Run(test.name + "/" + action, func(t *testing.T) {
+                // create business at version 2 so that stale versions exist
+                store, businessIds := newTestStore(t,
+                    api.NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com"})
+                businessId := businessIds[0]
+                if _, err := store.UpdateBusinessURI("https://dairy.farm", businessId, api.Actor{}, nil); err != nil {
+                    t.Fatalf("unable to update business URI: %+v", err)
+                }
+
+                ifMatch, err := api.ParseIfMatch(test.ifMatch)
+                if err != nil {
+                    t.Fatalf("unable to parse If-Match header: %+v", err)
+                }
+                if err := change(store, businessId, ifMatch); err != test.err {
+                    t.Fatalf("expected error %v, got %v", test.err, err)
+                }
+                history, _ := store.GetBusinessHistory(businessId)
+                expected := 3
+                if test.err != nil {
+                    expected = 2
+                }
+                if len(history) != expected {
+                    t.Fatalf("expected %d audit entries, got %d", expected, len(history))
+                }
+            })
+        }
+    }
+}
+
+func equalStrings(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range(a) {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestGetImportCandidates(t *testing.T) {
+    store, businessIds := newTestStore(t,
+        api.NewBusinessRequest{BusinessName: "Dairy", BusinessURI: "https://dairy.com"},
+        api.NewBusinessRequest{BusinessName: "Joe's Bakery", BusinessURI: "https://bakery.com"},
+        api.NewBusinessRequest{BusinessName: "Eggs", BusinessURI: "https://www.eggs.com/farm"},
+        api.NewBusinessRequest{BusinessName: "Apiary", BusinessURI: "https://apiary.com"},
+    )
+    if err := store.DeleteBusiness(businessIds[3], api.Actor{}, nil); err != nil {
+        t.Fatalf("unable to delete business: %+v", err)
+    }
+
+    rows := []api.ImportRow{
+        {Row: 1, Request: api.NewBusinessRequest{BusinessName: "Farm Eggs", BusinessURI: "http://EGGS.com/"}},
+        {Row: 2, Request: api.NewBusinessRequest{BusinessName: "joes bakery", BusinessURI: "https://joes.com"}},
+        {Row: 3, Request: api.NewBusinessRequest{BusinessName: "JOE'S BAKERY!", BusinessURI: "https://joes.com/shop"}},
+        {Row: 4, Request: api.NewBusinessRequest{BusinessName: "Apiary", BusinessURI: "https://apiary.com"}},
+    }
+    domains, names := api.ImportCandidateKeys(rows)
+    if len(domains) != 3 || len(names) != 4 {
+        t.Fatalf("expected 3 domains and 4 names, got %v and %v", domains, names)
+    }
+    candidates, err := store.GetImportCandidates(domains, names)
+    if err != nil {
+        t.Fatalf("unable to retrieve import candidates: %+v", err)
+    }
+    found := map[string]bool{}
+    for _, candidate := range(candidates) {
+        found[candidate.BusinessName] = true
+    }
+    if len(candidates) != 2 || !found["Eggs"] || !found["Joe's Bakery"] {
+        t.Errorf("expected candidates Eggs and Joe's Bakery, got %+v", candidates)
+    }
+
+    // candidates sharing only a domain are not duplicates of a different page
+    results := api.PlanImport(rows, candidates)
+    expected := []string{api.ImportStatusValid, api.ImportStatusValid, api.ImportStatusDuplicate,
+        api.ImportStatusValid}
+    for i, result := range(results) {
+        if result.Status != expected[i] {
+            t.Errorf("row %d: expected status %s, got %s", result.Row, expected[i], result.Status)
+        }
+    }
+}
+
+func TestListBusinessesUnpaginated(t *testing.T) {
+    requests := []api.NewBusinessRequest{}
+    for i := 0; i <= api.DefaultBusinessLimit; i++ {
+        requests = append(requests, api.NewBusinessRequest{BusinessName: fmt.Sprintf("Farm %d", i),
+            BusinessURI: fmt.Sprintf("https://farm%d.com", i)})
+    }
+    store, _ := newTestStore(t, requests...)
+
+    tests := []struct {
+        params   string
+        expected int
+        next     bool
+    }{
+        {"", api.DefaultBusinessLimit + 1, false},
+        {"sort=name&order=desc", api.DefaultBusinessLimit + 1, false},
+        {"limit=10", 10, true},
+    }
+    for _, test := range(tests) {
+        values, _ := neturl.ParseQuery(test.params)
+        query, err := api.ParseBusinessQuery(values)
+        if err != nil {
+            t.Fatalf("%q: unable to parse query: %+v", test.params, err)
+        }
+        page, err := store.ListBusinesses(query)
+        if err != nil {
+            t.Fatalf("%q: unable to list businesses: %+v", test.params, err)
+        }
+        if len(page.Businesses) != test.expected || (len(page.NextCursor) > 0) != test.next {
+            t.Errorf("%q: expected %d businesses and next page %v, got %d and cursor '%s'", test.params,
+                test.expected, test.next, len(page.Businesses), page.NextCursor)
+        }
+    }
+}
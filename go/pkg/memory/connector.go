@@ -0,0 +1,56 @@
+package memory
+
+import (
+    "time"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+// function used to generate a new static connector
+func NewStaticConnector(source string) *StaticConnector {
+    return &StaticConnector{Source: source}
+}
+
+// deterministic connector used to run the auto-updater without calling
+// external services. values are read from the metadata of businesses
+// using the keys 'phones', 'website_live' and 'open'. businesses are
+// reported as live and open with no phones if metadata is not set
+type StaticConnector struct {
+    Source string
+}
+
+// function used to return name of connector
+func(connector *StaticConnector) Name() string {
+    return connector.Source
+}
+
+// function used to generate updates for a list of businesses
+func(connector *StaticConnector) CollectData(businesses []connectors.BusinessMetadata) (
+    []connectors.BusinessUpdate, error) {
+
+    updates := []connectors.BusinessUpdate{}
+    for _, business := range(businesses) {
+        data := connectors.BusinessData{
+            BusinessPhones: []string{},
+            WebsiteLive: true,
+            BusinessOpen: true,
+            Source: connector.Source,
+            CollectedAt: time.Now(),
+        }
+        if phones, ok := business.Metadata["phones"].([]interface{}); ok {
+            for _, phone := range(phones) {
+                if value, ok := phone.(string); ok {
+                    data.BusinessPhones = append(data.BusinessPhones, value)
+                }
+            }
+        }
+        if live, ok := business.Metadata["website_live"].(bool); ok {
+            data.WebsiteLive = live
+        }
+        if open, ok := business.Metadata["open"].(bool); ok {
+            data.BusinessOpen = open
+        }
+        updates = append(updates, connectors.BusinessUpdate{Meta: business, Data: data})
+    }
+    return updates, nil
+}
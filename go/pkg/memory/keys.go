@@ -0,0 +1,35 @@
+package memory
+
+import (
+    "sort"
+)
+
+// function used to add an API key to the store. keys are
+// created directly in postgres when running against a database
+func(store *Store) AddAPIKey(key string) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    store.apiKeys[key] = true
+}
+
+// function used to retrieve all API keys
+func(store *Store) GetAPIKeys() ([]string, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []string{}
+    for key := range(store.apiKeys) {
+        results = append(results, key)
+    }
+    sort.Strings(results)
+    return results, nil
+}
+
+// function used to check if an API key is valid
+func(store *Store) IsValidApiKey(key string) (bool, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    return store.apiKeys[key], nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+    "time"
+    "encoding/json"
+
+    "github.com/google/uuid"
+
+    relay "texas_real_foods/pkg/mail-relay"
+)
+
+// function used to store a new mail relay entry
+func(store *Store) InsertMailEntry(request relay.MailRelayRequest) (uuid.UUID, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    entryId := uuid.New()
+    request.EntryId = entryId
+
+    // store request in the same format as the postgres JSON column
+    entryJson, err := json.Marshal(request)
+    if err != nil {
+        return entryId, relay.ErrInvalidJsonEntry
+    }
+    data := map[string]interface{}{}
+    if err := json.Unmarshal(entryJson, &data); err != nil {
+        return entryId, relay.ErrInvalidJsonEntry
+    }
+    store.mailEntries = append(store.mailEntries, relay.MailRelayEntry{
+        EntryId: entryId,
+        EventTimestamp: time.Now(),
+        Status: "in progress",
+        Completed: false,
+        Data: data,
+    })
+    return entryId, nil
+}
+
+// function used to update the status of a mail relay entry
+func(store *Store) UpdateMailEntry(entryId uuid.UUID, status string, completed bool) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    for i := range(store.mailEntries) {
+        if store.mailEntries[i].EntryId == entryId {
+            store.mailEntries[i].Status = status
+            store.mailEntries[i].Completed = completed
+        }
+    }
+    return nil
+}
+
+// function used to retrieve all mail relay entries
+func(store *Store) GetMailEntries() ([]relay.MailRelayEntry, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    entries := []relay.MailRelayEntry{}
+    for _, entry := range(store.mailEntries) {
+        entry.Data = copyJSON(entry.Data)
+        entries = append(entries, entry)
+    }
+    return entries, nil
+}
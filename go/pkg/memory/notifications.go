@@ -0,0 +1,144 @@
+package memory
+
+import (
+    "sort"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/notifications"
+    "texas_real_foods/pkg/alert-rules"
+)
+
+// function used to convert a stored notification. metadata defaults to
+// an empty object as in postgres
+func(entry storedNotification) toNotification() notifications.Notification {
+    notification := entry.Notification
+    notification.Metadata = copyJSON(notification.Metadata)
+    if notification.Metadata == nil {
+        notification.Metadata = map[string]interface{}{}
+    }
+    return notifications.Notification{
+        NotificationId: entry.NotificationId,
+        Notification: notification,
+    }
+}
+
+// function used to generate a new notification
+func(store *Store) CreateNotification(payload notifications.ChangeNotification) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    payload.Metadata = copyJSON(payload.Metadata)
+    store.notifications = append(store.notifications, storedNotification{
+        NotificationId: uuid.New(),
+        Notification: payload,
+    })
+    return nil
+}
+
+// function used to retrieve list of notifications
+func(store *Store) GetNotifications() ([]notifications.Notification, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []notifications.Notification{}
+    for _, entry := range(store.notifications) {
+        results = append(results, entry.toNotification())
+    }
+    return results, nil
+}
+
+// function used to retrieve list of notifications that are marked as unread
+func(store *Store) GetUnreadNotifications() ([]notifications.Notification, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []notifications.Notification{}
+    for _, entry := range(store.notifications) {
+        if !entry.Read {
+            results = append(results, entry.toNotification())
+        }
+    }
+    return results, nil
+}
+
+// function used to check if a notification with the given hash exists
+func(store *Store) NotificationHashExists(hashed string) (bool, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    for _, entry := range(store.notifications) {
+        if entry.Notification.NotificationHash == hashed {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+// function used to check if notification exists
+func(store *Store) NotificationExists(notificationId uuid.UUID) (bool, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    for _, entry := range(store.notifications) {
+        if entry.NotificationId == notificationId {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+// function used to set notification to read
+func(store *Store) UpdateNotification(notificationId uuid.UUID) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    for i := range(store.notifications) {
+        if store.notifications[i].NotificationId == notificationId {
+            store.notifications[i].Read = true
+        }
+    }
+    return nil
+}
+
+// function used to retrieve all alert rules sorted by rule ID
+func(store *Store) GetAlertRules() ([]alert_rules.Rule, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    rules := []alert_rules.Rule{}
+    for _, rule := range(store.alertRules) {
+        rules = append(rules, rule)
+    }
+    sort.Slice(rules, func(i, j int) bool {
+        return rules[i].RuleId < rules[j].RuleId
+    })
+    return rules, nil
+}
+
+// function used to check if an alert rule exists
+func(store *Store) AlertRuleExists(ruleId string) (bool, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    _, ok := store.alertRules[ruleId]
+    return ok, nil
+}
+
+// function used to insert or update an alert rule
+func(store *Store) UpdateAlertRule(rule alert_rules.Rule) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    store.alertRules[rule.RuleId] = rule
+    return nil
+}
+
+// function used to delete an alert rule
+func(store *Store) DeleteAlertRule(ruleId string) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    delete(store.alertRules, ruleId)
+    return nil
+}
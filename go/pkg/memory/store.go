@@ -0,0 +1,125 @@
+package memory
+
+import (
+    "sync"
+    "time"
+    "encoding/json"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/api"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
+    "texas_real_foods/pkg/syncer"
+    "texas_real_foods/pkg/notifications"
+    "texas_real_foods/pkg/authenticator"
+    "texas_real_foods/pkg/alert-rules"
+    updater "texas_real_foods/pkg/auto-updater"
+    relay "texas_real_foods/pkg/mail-relay"
+)
+
+var (
+    // ensure that the store implements all repositories
+    _ api.Repository = (*Store)(nil)
+    _ reconciler.Repository = (*Store)(nil)
+    _ updater.Repository = (*Store)(nil)
+    _ syncer.Repository = (*Store)(nil)
+    _ notifications.Repository = (*Store)(nil)
+    _ relay.Repository = (*Store)(nil)
+    _ authenticator.Repository = (*Store)(nil)
+)
+
+// struct used to store a business and its metadata
+type business struct {
+    Name       string
+    URI        string
+    Added      time.Time
+    LastUpdate time.Time
+    Metadata   map[string]interface{}
+    DeletedAt  *time.Time
+    Version    int
+}
+
+// struct used to store the latest data reported by a source. the
+// metadata of manual entries contains the fields that were set
+type sourceEntry struct {
+    Data connectors.BusinessData
+    Meta map[string]interface{}
+}
+
+// struct used to store a single timeseries entry
+type timeseriesEntry struct {
+    BusinessId     uuid.UUID
+    EventTimestamp time.Time
+    Data           connectors.BusinessData
+}
+
+// struct used to store a notification along with its read status
+type storedNotification struct {
+    NotificationId uuid.UUID
+    Notification   notifications.ChangeNotification
+    Read           bool
+}
+
+// in-memory implementation of all repositories. the store can be
+// shared by the API, updaters, syncer and notification services to
+// run all components in a single process without postgres. all
+// data is lost when the process exits
+type Store struct {
+    mutex         sync.RWMutex
+    businesses    map[uuid.UUID]*business
+    history       map[uuid.UUID][]api.AuditEntry
+    sources       map[uuid.UUID]map[string]sourceEntry
+    timeseries    []timeseriesEntry
+    goldenRecords map[uuid.UUID]reconciler.GoldenRecord
+    watermarks    map[string]time.Time
+    notifications []storedNotification
+    alertRules    map[string]alert_rules.Rule
+    mailEntries   []relay.MailRelayEntry
+    apiKeys       map[string]bool
+}
+
+// function used to generate a new empty store
+func New() *Store {
+    return &Store{
+        businesses: map[uuid.UUID]*business{},
+        history: map[uuid.UUID][]api.AuditEntry{},
+        sources: map[uuid.UUID]map[string]sourceEntry{},
+        timeseries: []timeseriesEntry{},
+        goldenRecords: map[uuid.UUID]reconciler.GoldenRecord{},
+        watermarks: map[string]time.Time{},
+        notifications: []storedNotification{},
+        alertRules: map[string]alert_rules.Rule{},
+        mailEntries: []relay.MailRelayEntry{},
+        apiKeys: map[string]bool{},
+    }
+}
+
+// function used to copy a JSON object. values are converted to and
+// from JSON to match the values returned from postgres JSON columns
+// and to prevent callers from modifying stored values
+func copyJSON(value map[string]interface{}) map[string]interface{} {
+    if value == nil {
+        return nil
+    }
+    copied := map[string]interface{}{}
+    encoded, err := json.Marshal(value)
+    if err != nil {
+        return copied
+    }
+    json.Unmarshal(encoded, &copied)
+    return copied
+}
+
+// function used to copy a list of phone numbers
+func copyPhones(phones []string) []string {
+    copied := make([]string, len(phones))
+    copy(copied, phones)
+    return copied
+}
+
+// function used to copy business data
+func copyData(data connectors.BusinessData) connectors.BusinessData {
+    data.BusinessPhones = copyPhones(data.BusinessPhones)
+    return data
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+    "sort"
+    "time"
+    "strings"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/syncer"
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/reconciler"
+)
+
+// function used to retrieve the IDs of businesses updated after a given
+// time whose sources disagree on any field. deleted businesses are excluded. phone numbers are compared
+// as sets and empty phone lists are treated as missing values
+func(store *Store) GetConflictCandidates(since time.Time) ([]uuid.UUID, time.Time, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    // note that update times are set by the store, so the watermark
+    // is taken from the same clock
+    watermark := time.Now()
+    results := []uuid.UUID{}
+    for businessId, entry := range(store.businesses) {
+        if !entry.LastUpdate.After(since) || entry.DeletedAt != nil {
+            continue
+        }
+        live, open, phones := map[bool]bool{}, map[bool]bool{}, map[string]bool{}
+        for source, data := range(store.sources[businessId]) {
+            if source == reconciler.ManualSource {
+                continue
+            }
+            live[data.Data.WebsiteLive] = true
+            open[data.Data.BusinessOpen] = true
+            if key := phoneSetKey(data.Data.BusinessPhones); len(key) > 0 {
+                phones[key] = true
+            }
+        }
+        if len(live) > 1 || len(open) > 1 || len(phones) > 1 {
+            results = append(results, businessId)
+        }
+    }
+    return results, watermark, nil
+}
+
+// function used to generate a key for a set of phone numbers
+func phoneSetKey(phones []string) string {
+    unique := map[string]bool{}
+    for _, phone := range(phones) {
+        unique[phone] = true
+    }
+    sorted := []string{}
+    for phone := range(unique) {
+        sorted = append(sorted, phone)
+    }
+    sort.Strings(sorted)
+    return strings.Join(sorted, "\x00")
+}
+
+// function to retrieve business metadata for a batch of businesses
+func(store *Store) GetMetadataByIds(businessIds []uuid.UUID) (
+    map[uuid.UUID]connectors.BusinessMetadata, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := map[uuid.UUID]connectors.BusinessMetadata{}
+    for _, businessId := range(businessIds) {
+        entry, ok := store.activeBusiness(businessId)
+        if !ok {
+            continue
+        }
+        results[businessId] = connectors.BusinessMetadata{
+            BusinessId: businessId,
+            BusinessName: entry.Name,
+            BusinessURI: entry.URI,
+            Metadata: copyJSON(entry.Metadata),
+        }
+    }
+    return results, nil
+}
+
+// function used to retrieve data for a batch of businesses grouped by
+// business ID. manually entered data is excluded
+func(store *Store) GetDataByBusinessIds(businessIds []uuid.UUID) (
+    map[uuid.UUID][]connectors.BusinessUpdate, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := map[uuid.UUID][]connectors.BusinessUpdate{}
+    for _, businessId := range(businessIds) {
+        for source, entry := range(store.sources[businessId]) {
+            if source == reconciler.ManualSource {
+                continue
+            }
+            results[businessId] = append(results[businessId], connectors.BusinessUpdate{
+                Meta: connectors.BusinessMetadata{BusinessId: businessId},
+                Data: copyData(entry.Data),
+            })
+        }
+    }
+    return results, nil
+}
+
+// function used to retrieve the IDs of businesses whose most recent
+// data was collected before a given time. deleted businesses are excluded
+func(store *Store) GetStaleCandidates(before time.Time) ([]uuid.UUID, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []uuid.UUID{}
+    for businessId, sources := range(store.sources) {
+        if _, ok := store.activeBusiness(businessId); !ok {
+            continue
+        }
+        var latest *time.Time
+        for source, entry := range(sources) {
+            if source == reconciler.ManualSource {
+                continue
+            }
+            if collectedAt := entry.Data.CollectedAt; latest == nil || collectedAt.After(*latest) {
+                latest = &collectedAt
+            }
+        }
+        if latest != nil && latest.Before(before) {
+            results = append(results, businessId)
+        }
+    }
+    return results, nil
+}
+
+// function used to retrieve the watermark of the last successful
+// sync job with a given name
+func(store *Store) GetWatermark(name string) (time.Time, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    watermark, ok := store.watermarks[name]
+    if !ok {
+        return watermark, syncer.ErrWatermarkNotFound
+    }
+    return watermark, nil
+}
+
+// function used to update the watermark of a sync job with a given name
+func(store *Store) UpdateWatermark(name string, watermark time.Time) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    store.watermarks[name] = watermark
+    return nil
+}
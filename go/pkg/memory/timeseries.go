@@ -0,0 +1,97 @@
+package memory
+
+import (
+    "sort"
+    "time"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/api"
+)
+
+// function used to convert a stored timeseries entry. rollups are not
+// computed by the store so all entries contain raw data
+func(entry timeseriesEntry) toTimeSeriesData() api.TimeSeriesData {
+    data := copyData(entry.Data)
+    data.CollectedAt = time.Time{}
+    return api.TimeSeriesData{EventTimestamp: entry.EventTimestamp, BusinessData: data}
+}
+
+// function used to retrieve timeseries data for a business in the
+// given time range sorted by timestamp
+func(store *Store) GetTimeSeriesData(businessId uuid.UUID, start,
+    end time.Time) ([]api.TimeSeriesData, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []api.TimeSeriesData{}
+    for _, entry := range(store.timeseries) {
+        if entry.BusinessId != businessId || entry.EventTimestamp.Before(start) ||
+            !entry.EventTimestamp.Before(end) {
+            continue
+        }
+        results = append(results, entry.toTimeSeriesData())
+    }
+    return results, nil
+}
+
+// function used to retrieve the latest timeseries entries of each
+// source for a business, limited to a number of points per source
+func(store *Store) GetTimeSeriesDataLimited(businessId uuid.UUID, limit int) ([]api.TimeSeriesData, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    results := []api.TimeSeriesData{}
+    counts := map[string]int{}
+    // entries are appended in order of time so iterate in reverse
+    // to retrieve the latest entries of each source first
+    for i := len(store.timeseries) - 1; i >= 0; i-- {
+        entry := store.timeseries[i]
+        if entry.BusinessId != businessId || counts[entry.Data.Source] >= limit {
+            continue
+        }
+        counts[entry.Data.Source]++
+        results = append(results, entry.toTimeSeriesData())
+    }
+    sort.SliceStable(results, func(i, j int) bool {
+        return results[i].Source < results[j].Source
+    })
+    return results, nil
+}
+
+// function used to compute the availability of all businesses that
+// have not been deleted for a single source
+func(store *Store) GetDirectoryAvailability(source string, start,
+    end time.Time) ([]api.BusinessAvailability, error) {
+    businesses := store.listBusinesses(false)
+
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+    data := map[uuid.UUID][]api.TimeSeriesData{}
+    for _, entry := range(store.timeseries) {
+        if entry.Data.Source != source || entry.EventTimestamp.Before(start) ||
+            !entry.EventTimestamp.Before(end) {
+            continue
+        }
+        data[entry.BusinessId] = append(data[entry.BusinessId], entry.toTimeSeriesData())
+    }
+    return api.ComputeDirectoryAvailability(businesses, data, source, end), nil
+}
+
+// function used to retrieve all sources that have reported data
+func(store *Store) GetUniqueSources() ([]string, error) {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+
+    sources := []string{}
+    seen := map[string]bool{}
+    for _, entry := range(store.timeseries) {
+        if seen[entry.Data.Source] {
+            continue
+        }
+        seen[entry.Data.Source] = true
+        sources = append(sources, entry.Data.Source)
+    }
+    sort.Strings(sources)
+    return sources, nil
+}
@@ -0,0 +1,99 @@
+package migrations
+
+import (
+    "fmt"
+    "sort"
+    "embed"
+    "errors"
+    "regexp"
+    "strconv"
+    "crypto/sha256"
+    "encoding/hex"
+)
+
+var (
+    // define custom errors
+    ErrInvalidMigration = errors.New("Invalid migration")
+    ErrChecksumMismatch = errors.New("Applied migration does not match embedded migration")
+    ErrSchemaOutdated = errors.New("Database schema is older than required schema")
+
+    // define pattern used to name migration files i.e.
+    // 0002_add_alert_rules.up.sql and 0002_add_alert_rules.down.sql
+    migrationPattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+    // define embedded migration files
+    //go:embed sql/*.sql
+    migrationFiles embed.FS
+)
+
+// struct used to store a single versioned migration. the down
+// script is used to revert the changes made by the up script
+type Migration struct {
+    Version int
+    Name    string
+    Up      string
+    Down    string
+}
+
+// function used to generate the checksum of a migration. only the up
+// script is included as it defines the resulting schema
+func(migration Migration) Checksum() string {
+    hash := sha256.Sum256([]byte(migration.Up))
+    return hex.EncodeToString(hash[:])
+}
+
+// function used to load all embedded migrations sorted by version
+func Load() ([]Migration, error) {
+    entries, err := migrationFiles.ReadDir("sql")
+    if err != nil {
+        return []Migration{}, err
+    }
+
+    versions := map[int]*Migration{}
+    for _, entry := range(entries) {
+        match := migrationPattern.FindStringSubmatch(entry.Name())
+        if match == nil {
+            return []Migration{}, fmt.Errorf("%w: invalid file name '%s'", ErrInvalidMigration, entry.Name())
+        }
+        version, _ := strconv.Atoi(match[1])
+        content, err := migrationFiles.ReadFile("sql/" + entry.Name())
+        if err != nil {
+            return []Migration{}, err
+        }
+
+        migration, ok := versions[version]
+        if !ok {
+            migration = &Migration{Version: version, Name: match[2]}
+            versions[version] = migration
+        } else if migration.Name != match[2] {
+            return []Migration{}, fmt.Errorf("%w: version %d has multiple names", ErrInvalidMigration, version)
+        }
+        if match[3] == "up" {
+            migration.Up = string(content)
+        } else {
+            migration.Down = string(content)
+        }
+    }
+
+    migrations := []Migration{}
+    for _, migration := range(versions) {
+        if len(migration.Up) == 0 || len(migration.Down) == 0 {
+            return []Migration{}, fmt.Errorf("%w: version %d requires up and down scripts",
+                ErrInvalidMigration, migration.Version)
+        }
+        migrations = append(migrations, *migration)
+    }
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+    return migrations, nil
+}
+
+// function used to retrieve the latest embedded schema version
+func Latest() (int, error) {
+    migrations, err := Load()
+    if err != nil || len(migrations) == 0 {
+        return 0, err
+    }
+    return migrations[len(migrations) - 1].Version, nil
+}
@@ -0,0 +1,242 @@
+package migrations
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+)
+
+// function used to generate a new migrator using the given
+// connection pool
+func New(pool *pgxpool.Pool) *Migrator {
+    return &Migrator{
+        DB: NewPersistenceWithPool(pool),
+    }
+}
+
+// struct used to apply and revert embedded migrations. migrations
+// are run on a single connection holding an advisory lock so that
+// only one migrator can modify the schema at any time
+type Migrator struct {
+    DB *Persistence
+}
+
+// struct used to store the status of a migration. migrations that
+// have been applied by a newer version of the migrator are unknown
+type MigrationStatus struct {
+    Version       int        `json:"version"`
+    Name          string     `json:"name"`
+    Applied       *time.Time `json:"applied"`
+    ChecksumValid bool       `json:"checksum_valid"`
+    Unknown       bool       `json:"unknown"`
+}
+
+// function used to ensure that applied migrations have not been
+// modified since they were applied
+func validateChecksums(migrations []Migration, applied []AppliedMigration) error {
+    checksums := map[int]string{}
+    for _, migration := range(applied) {
+        checksums[migration.Version] = migration.Checksum
+    }
+    for _, migration := range(migrations) {
+        checksum, ok := checksums[migration.Version]
+        if ok && checksum != migration.Checksum() {
+            return fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, migration.Version, migration.Name)
+        }
+    }
+    return nil
+}
+
+// function used to apply all pending migrations in order of version.
+// the versions of applied migrations are returned
+func(migrator *Migrator) Up() ([]int, error) {
+    versions := []int{}
+    migrations, err := Load()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to load migrations: %+v", err))
+        return versions, err
+    }
+
+    db := migrator.DB
+
+    conn, release, err := db.acquireLock()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to acquire migration lock: %+v", err))
+        return versions, err
+    }
+    defer release()
+
+    if err := db.ensureMigrationsTable(conn); err != nil {
+        log.Error(fmt.Errorf("unable to create migrations table: %+v", err))
+        return versions, err
+    }
+    // retrieve applied migrations once the lock is held so that
+    // migrations applied by concurrent migrators are skipped
+    applied, err := db.GetAppliedMigrations()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve applied migrations: %+v", err))
+        return versions, err
+    }
+    if err := validateChecksums(migrations, applied); err != nil {
+        log.Error(err)
+        return versions, err
+    }
+
+    done := map[int]bool{}
+    for _, migration := range(applied) {
+        done[migration.Version] = true
+    }
+    for _, migration := range(migrations) {
+        if done[migration.Version] {
+            continue
+        }
+        log.Info(fmt.Sprintf("applying migration %04d_%s", migration.Version, migration.Name))
+        if err := applyMigration(conn, migration); err != nil {
+            log.Error(fmt.Errorf("unable to apply migration %d: %+v", migration.Version, err))
+            return versions, err
+        }
+        versions = append(versions, migration.Version)
+    }
+    return versions, nil
+}
+
+// function used to revert the given number of most recently applied
+// migrations. the versions of reverted migrations are returned
+func(migrator *Migrator) Down(steps int) ([]int, error) {
+    versions := []int{}
+    migrations, err := Load()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to load migrations: %+v", err))
+        return versions, err
+    }
+    known := map[int]Migration{}
+    for _, migration := range(migrations) {
+        known[migration.Version] = migration
+    }
+
+    db := migrator.DB
+
+    conn, release, err := db.acquireLock()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to acquire migration lock: %+v", err))
+        return versions, err
+    }
+    defer release()
+
+    applied, err := db.GetAppliedMigrations()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve applied migrations: %+v", err))
+        return versions, err
+    }
+    if err := validateChecksums(migrations, applied); err != nil {
+        log.Error(err)
+        return versions, err
+    }
+
+    for i := len(applied) - 1; i >= 0 && len(versions) < steps; i-- {
+        migration, ok := known[applied[i].Version]
+        if !ok {
+            return versions, fmt.Errorf("%w: version %d is not embedded and cannot be reverted",
+                ErrInvalidMigration, applied[i].Version)
+        }
+        log.Info(fmt.Sprintf("reverting migration %04d_%s", migration.Version, migration.Name))
+        if err := revertMigration(conn, migration); err != nil {
+            log.Error(fmt.Errorf("unable to revert migration %d: %+v", migration.Version, err))
+            return versions, err
+        }
+        versions = append(versions, migration.Version)
+    }
+    return versions, nil
+}
+
+// function used to retrieve the status of all embedded and applied
+// migrations sorted by version
+func(migrator *Migrator) Status() ([]MigrationStatus, error) {
+    statuses := []MigrationStatus{}
+    migrations, err := Load()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to load migrations: %+v", err))
+        return statuses, err
+    }
+
+    db := migrator.DB
+
+    applied, err := db.GetAppliedMigrations()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve applied migrations: %+v", err))
+        return statuses, err
+    }
+    return migrationStatuses(migrations, applied), nil
+}
+
+// function used to merge embedded and applied migrations into a
+// single status list sorted by version
+func migrationStatuses(migrations []Migration, applied []AppliedMigration) []MigrationStatus {
+    statuses := []MigrationStatus{}
+    appliedVersions := map[int]AppliedMigration{}
+    for _, migration := range(applied) {
+        appliedVersions[migration.Version] = migration
+    }
+
+    known := map[int]bool{}
+    for _, migration := range(migrations) {
+        known[migration.Version] = true
+        status := MigrationStatus{Version: migration.Version, Name: migration.Name, ChecksumValid: true}
+        if entry, ok := appliedVersions[migration.Version]; ok {
+            ts := entry.Applied
+            status.Applied = &ts
+            status.ChecksumValid = entry.Checksum == migration.Checksum()
+        }
+        statuses = append(statuses, status)
+    }
+    // include migrations applied by newer versions after known migrations
+    for _, migration := range(applied) {
+        if known[migration.Version] {
+            continue
+        }
+        ts := migration.Applied
+        statuses = append(statuses, MigrationStatus{Version: migration.Version, Name: migration.Name,
+            Applied: &ts, ChecksumValid: true, Unknown: true})
+    }
+    return statuses
+}
+
+// function used to ensure that all embedded migrations have been applied
+// to the database. services call this at startup so that they refuse
+// to run against an older schema. migrations applied by newer versions
+// are allowed so that services can be upgraded after the schema. the
+// schema is verified using the connection pool of the service
+func VerifySchema(pool *pgxpool.Pool) error {
+    migrations, err := Load()
+    if err != nil {
+        return err
+    }
+
+    db := NewPersistenceWithPool(pool)
+    applied, err := db.GetAppliedMigrations()
+    if err != nil {
+        return err
+    }
+    for _, status := range(migrationStatuses(migrations, applied)) {
+        if status.Applied == nil {
+            return fmt.Errorf("%w: migration %d (%s) has not been applied", ErrSchemaOutdated,
+                status.Version, status.Name)
+        }
+        if !status.ChecksumValid {
+            return fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, status.Version, status.Name)
+        }
+    }
+    log.Debug(fmt.Sprintf("verified database schema with %d applied migrations", len(applied)))
+    return nil
+}
+
+// function used to verify the database schema when a service starts.
+// services refuse to start against a database schema older than the
+// embedded migrations
+func MustVerifySchema(pool *pgxpool.Pool) {
+    if err := VerifySchema(pool); err != nil {
+        panic(fmt.Sprintf("unable to verify database schema: %+v", err))
+    }
+}
@@ -0,0 +1,156 @@
+package migrations
+
+import (
+    "fmt"
+    "time"
+    "context"
+
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+)
+
+var (
+    // define key of advisory lock held while migrations are run. the
+    // lock prevents services started at the same time from applying
+    // migrations concurrently
+    migrationLockKey int64 = 7310451209
+)
+
+// struct used to store a migration that has been applied to the database
+type AppliedMigration struct {
+    Version  int       `json:"version"`
+    Name     string    `json:"name"`
+    Checksum string    `json:"checksum"`
+    Applied  time.Time `json:"applied"`
+}
+
+type Persistence struct{
+    *utils.BasePostgresPersistence
+}
+
+func NewPersistence(url string) *Persistence {
+    // create instance of base persistence
+    basePersistence := utils.NewPersistence(url)
+    return &Persistence{
+        basePersistence,
+    }
+}
+
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to create the table used to track applied migrations
+func(db *Persistence) ensureMigrationsTable(conn *pgxpool.Conn) error {
+    query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version integer NOT NULL PRIMARY KEY,
+        name text NOT NULL,
+        checksum text NOT NULL,
+        applied timestamp without time zone DEFAULT now() NOT NULL
+    )`
+    _, err := conn.Exec(context.Background(), query)
+    return err
+}
+
+// function used to retrieve all applied migrations sorted by version.
+// no migrations are returned if the migrations table does not exist
+func(db *Persistence) GetAppliedMigrations() ([]AppliedMigration, error) {
+    migrations := []AppliedMigration{}
+    var exists bool
+    query := `SELECT to_regclass('schema_migrations') IS NOT NULL`
+    if err := db.Session.QueryRow(context.Background(), query).Scan(&exists); err != nil {
+        return migrations, err
+    }
+    if !exists {
+        return migrations, nil
+    }
+
+    query = `SELECT version,name,checksum,applied FROM schema_migrations ORDER BY version`
+    rows, err := db.Session.Query(context.Background(), query)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return migrations, nil
+        default:
+            return migrations, err
+        }
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var migration AppliedMigration
+        if err := rows.Scan(&migration.Version, &migration.Name, &migration.Checksum,
+            &migration.Applied); err != nil {
+            return migrations, err
+        }
+        migrations = append(migrations, migration)
+    }
+    return migrations, rows.Err()
+}
+
+// function used to acquire a dedicated connection holding the migration
+// lock. the lock is released when the returned function is called
+func(db *Persistence) acquireLock() (*pgxpool.Conn, func(), error) {
+    conn, err := db.Session.Acquire(context.Background())
+    if err != nil {
+        return nil, nil, err
+    }
+    log.Debug("waiting for migration lock...")
+    if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+        conn.Release()
+        return nil, nil, err
+    }
+    release := func() {
+        if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+            log.Error(fmt.Errorf("unable to release migration lock: %+v", err))
+        }
+        conn.Release()
+    }
+    return conn, release, nil
+}
+
+// function used to apply a migration and record it in the migrations
+// table in a single transaction
+func applyMigration(conn *pgxpool.Conn, migration Migration) error {
+    tx, err := conn.Begin(context.Background())
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(context.Background())
+
+    if _, err := tx.Exec(context.Background(), migration.Up); err != nil {
+        return err
+    }
+    query := `INSERT INTO schema_migrations(version,name,checksum) VALUES($1,$2,$3)`
+    if _, err := tx.Exec(context.Background(), query, migration.Version, migration.Name,
+        migration.Checksum()); err != nil {
+        return err
+    }
+    return tx.Commit(context.Background())
+}
+
+// function used to revert a migration and remove it from the migrations
+// table in a single transaction
+func revertMigration(conn *pgxpool.Conn, migration Migration) error {
+    tx, err := conn.Begin(context.Background())
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(context.Background())
+
+    if _, err := tx.Exec(context.Background(), migration.Down); err != nil {
+        return err
+    }
+    query := `DELETE FROM schema_migrations WHERE version = $1`
+    if _, err := tx.Exec(context.Background(), query, migration.Version); err != nil {
+        return err
+    }
+    return tx.Commit(context.Background())
+}
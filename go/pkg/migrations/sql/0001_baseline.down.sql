@@ -0,0 +1,10 @@
+-- removes the entire schema. all data is lost
+
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_metadata;
+DROP TABLE IF EXISTS mail_relay_entries;
+DROP TABLE IF EXISTS asset_updates;
+DROP TABLE IF EXISTS asset_metadata;
+DROP TABLE IF EXISTS asset_data_timeseries;
+DROP TABLE IF EXISTS asset_data;
+DROP TABLE IF EXISTS access_keys;
@@ -0,0 +1,67 @@
+-- baseline schema matching the original postgres.sql dump. tables are
+-- created only if they do not exist so that databases created by hand
+-- from the dump can be brought under version control by running the
+-- migration. all later changes are made by subsequent migrations, which
+-- must also apply to databases created from the dump
+
+CREATE TABLE IF NOT EXISTS access_keys (
+    key text NOT NULL PRIMARY KEY,
+    description text
+);
+
+CREATE TABLE IF NOT EXISTS asset_data (
+    business_id uuid NOT NULL,
+    source text NOT NULL,
+    website_live boolean DEFAULT true,
+    phone text[] DEFAULT '{}'::text[] NOT NULL,
+    meta json DEFAULT '{}'::json NOT NULL,
+    open boolean DEFAULT true NOT NULL,
+    PRIMARY KEY (business_id, source)
+);
+
+CREATE TABLE IF NOT EXISTS asset_data_timeseries (
+    business_id uuid NOT NULL,
+    event_timestamp timestamp without time zone DEFAULT now() NOT NULL,
+    source text NOT NULL,
+    website_live boolean,
+    phone text[] DEFAULT '{}'::text[] NOT NULL,
+    meta json DEFAULT '{}'::json NOT NULL,
+    open boolean DEFAULT true NOT NULL,
+    PRIMARY KEY (business_id, source, event_timestamp)
+);
+
+CREATE TABLE IF NOT EXISTS asset_metadata (
+    business_id uuid NOT NULL PRIMARY KEY,
+    business_name text NOT NULL,
+    last_update timestamp without time zone DEFAULT now() NOT NULL,
+    added timestamp without time zone DEFAULT now() NOT NULL,
+    metadata json DEFAULT '{}'::json,
+    uri text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS asset_updates (
+    business_id uuid NOT NULL,
+    fields_updated text[] NOT NULL,
+    "timestamp" timestamp without time zone NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mail_relay_entries (
+    entry_id uuid NOT NULL PRIMARY KEY,
+    event_timestamp timestamp without time zone DEFAULT now() NOT NULL,
+    status text NOT NULL,
+    completed boolean DEFAULT false NOT NULL,
+    data json DEFAULT '{}'::json NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS notification_metadata (
+    notification_id uuid NOT NULL PRIMARY KEY,
+    metadata json DEFAULT '{}'::json
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id uuid NOT NULL PRIMARY KEY,
+    event_timestamp timestamp without time zone DEFAULT now() NOT NULL,
+    notification json NOT NULL,
+    read boolean DEFAULT false,
+    hash text
+);
@@ -0,0 +1,11 @@
+-- cache of fetched pages shared by the scraper and the parked domain
+-- checker
+
+CREATE TABLE IF NOT EXISTS page_snapshots (
+    uri text NOT NULL PRIMARY KEY,
+    final_url text NOT NULL,
+    status_code integer NOT NULL,
+    headers json DEFAULT '{}'::json NOT NULL,
+    body bytea,
+    fetched_at timestamp without time zone DEFAULT now() NOT NULL
+);
@@ -0,0 +1,12 @@
+-- snapshots of the visible text of business websites used to detect
+-- content changes
+
+CREATE TABLE IF NOT EXISTS site_text_snapshots (
+    business_id uuid NOT NULL,
+    content_hash text NOT NULL,
+    content text[] DEFAULT '{}'::text[] NOT NULL,
+    change_score double precision DEFAULT 0 NOT NULL,
+    first_seen timestamp without time zone DEFAULT now() NOT NULL,
+    last_seen timestamp without time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY (business_id, first_seen)
+);
@@ -0,0 +1,7 @@
+-- reconciled values of each field of a business across all sources
+
+CREATE TABLE IF NOT EXISTS golden_records (
+    business_id uuid NOT NULL PRIMARY KEY,
+    fields json DEFAULT '{}'::json NOT NULL,
+    computed_at timestamp without time zone DEFAULT now() NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS asset_metadata_last_update_idx;
+DROP TABLE IF EXISTS syncer_watermarks;
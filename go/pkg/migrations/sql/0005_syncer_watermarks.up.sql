@@ -0,0 +1,9 @@
+-- watermarks used by the syncer to only compare businesses updated since
+-- the previous sync
+
+CREATE TABLE IF NOT EXISTS syncer_watermarks (
+    name text NOT NULL PRIMARY KEY,
+    watermark timestamp without time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_last_update_idx ON asset_metadata USING btree (last_update);
@@ -0,0 +1 @@
+ALTER TABLE asset_data DROP COLUMN IF EXISTS collected_at;
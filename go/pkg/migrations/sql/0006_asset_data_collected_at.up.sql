@@ -0,0 +1,4 @@
+-- time at which the data of each source was last collected. existing
+-- rows are marked as collected when the migration is applied
+
+ALTER TABLE asset_data ADD COLUMN IF NOT EXISTS collected_at timestamp without time zone DEFAULT now() NOT NULL;
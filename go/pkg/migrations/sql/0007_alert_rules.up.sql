@@ -0,0 +1,8 @@
+-- declarative alert rules evaluated by the timeseries analyser
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    rule_id text NOT NULL PRIMARY KEY,
+    rule jsonb NOT NULL,
+    created timestamp without time zone DEFAULT now() NOT NULL,
+    updated timestamp without time zone DEFAULT now() NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS asset_data_rollup_daily;
+DROP TABLE IF EXISTS asset_data_rollup_hourly;
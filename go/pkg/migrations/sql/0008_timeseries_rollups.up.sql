@@ -0,0 +1,24 @@
+-- hourly and daily rollups of timeseries data retained after raw data
+-- has expired
+
+CREATE TABLE IF NOT EXISTS asset_data_rollup_hourly (
+    business_id uuid NOT NULL,
+    source text NOT NULL,
+    bucket timestamp without time zone NOT NULL,
+    fraction_live double precision NOT NULL,
+    fraction_open double precision NOT NULL,
+    phones text[] DEFAULT '{}'::text[] NOT NULL,
+    samples integer NOT NULL,
+    PRIMARY KEY (business_id, source, bucket)
+);
+
+CREATE TABLE IF NOT EXISTS asset_data_rollup_daily (
+    business_id uuid NOT NULL,
+    source text NOT NULL,
+    bucket timestamp without time zone NOT NULL,
+    fraction_live double precision NOT NULL,
+    fraction_open double precision NOT NULL,
+    phones text[] DEFAULT '{}'::text[] NOT NULL,
+    samples integer NOT NULL,
+    PRIMARY KEY (business_id, source, bucket)
+);
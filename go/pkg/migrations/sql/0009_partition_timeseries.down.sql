@@ -0,0 +1,22 @@
+-- copies all rows of the partitioned table into a single table. monthly
+-- partitions are dropped along with the partitioned table
+
+CREATE TABLE asset_data_timeseries_unpartitioned (
+    business_id uuid NOT NULL,
+    event_timestamp timestamp without time zone DEFAULT now() NOT NULL,
+    source text NOT NULL,
+    website_live boolean,
+    phone text[] DEFAULT '{}'::text[] NOT NULL,
+    meta json DEFAULT '{}'::json NOT NULL,
+    open boolean DEFAULT true NOT NULL
+);
+
+INSERT INTO asset_data_timeseries_unpartitioned SELECT business_id, event_timestamp, source,
+    website_live, phone, meta, open FROM asset_data_timeseries;
+
+DROP TABLE asset_data_timeseries;
+
+ALTER TABLE asset_data_timeseries_unpartitioned RENAME TO asset_data_timeseries;
+
+ALTER TABLE asset_data_timeseries
+    ADD CONSTRAINT asset_data_timeseries_pkey PRIMARY KEY (business_id, source, event_timestamp);
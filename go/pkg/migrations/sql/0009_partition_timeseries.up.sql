@@ -0,0 +1,32 @@
+-- partitions the timeseries table by month. the existing table is attached
+-- as the default partition, so no rows are copied and no CHECK constraint
+-- is required. the cleaner moves rows of the current and upcoming months
+-- into monthly partitions as they are created, and rows of earlier months
+-- are removed from the default partition once they have expired
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_partitioned_table
+        INNER JOIN pg_class ON pg_partitioned_table.partrelid = pg_class.oid
+        WHERE pg_class.relname = 'asset_data_timeseries') THEN
+        ALTER TABLE asset_data_timeseries RENAME TO asset_data_timeseries_default;
+        ALTER TABLE asset_data_timeseries_default
+            RENAME CONSTRAINT asset_data_timeseries_pkey TO asset_data_timeseries_default_pkey;
+
+        CREATE TABLE asset_data_timeseries (
+            business_id uuid NOT NULL,
+            event_timestamp timestamp without time zone DEFAULT now() NOT NULL,
+            source text NOT NULL,
+            website_live boolean,
+            phone text[] DEFAULT '{}'::text[] NOT NULL,
+            meta json DEFAULT '{}'::json NOT NULL,
+            open boolean DEFAULT true NOT NULL,
+            PRIMARY KEY (business_id, source, event_timestamp)
+        ) PARTITION BY RANGE (event_timestamp);
+
+        ALTER TABLE asset_data_timeseries ATTACH PARTITION asset_data_timeseries_default DEFAULT;
+    END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS asset_data_timeseries_default PARTITION OF asset_data_timeseries DEFAULT;
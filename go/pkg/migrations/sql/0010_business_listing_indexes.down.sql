@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS asset_metadata_last_update_id_idx;
+DROP INDEX IF EXISTS asset_metadata_added_id_idx;
+DROP INDEX IF EXISTS asset_metadata_name_id_idx;
@@ -0,0 +1,9 @@
+-- indexes used to page through businesses sorted by name, time added
+-- or time of last update. the business ID is included as businesses
+-- are ordered by ID when sort values are equal
+
+CREATE INDEX IF NOT EXISTS asset_metadata_name_id_idx ON asset_metadata USING btree (business_name, business_id);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_added_id_idx ON asset_metadata USING btree (added, business_id);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_last_update_id_idx ON asset_metadata USING btree (last_update, business_id);
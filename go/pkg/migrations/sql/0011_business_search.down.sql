@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS asset_metadata_metadata_trgm_idx;
+DROP INDEX IF EXISTS asset_metadata_address_trgm_idx;
+DROP INDEX IF EXISTS asset_metadata_uri_trgm_idx;
+DROP INDEX IF EXISTS asset_metadata_name_trgm_idx;
+DROP EXTENSION IF EXISTS pg_trgm;
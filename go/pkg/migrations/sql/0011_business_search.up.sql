@@ -0,0 +1,13 @@
+-- trigram indexes used to search businesses by name, URI, address and
+-- metadata with typo-tolerant matching. every field matched by the search
+-- is indexed so that matches can be combined without scanning the table
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS asset_metadata_name_trgm_idx ON asset_metadata USING gin (business_name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_uri_trgm_idx ON asset_metadata USING gin (uri gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_address_trgm_idx ON asset_metadata USING gin ((metadata->>'address') gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS asset_metadata_metadata_trgm_idx ON asset_metadata USING gin ((metadata::text) gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS asset_metadata_deleted_at_idx;
+ALTER TABLE asset_metadata DROP COLUMN IF EXISTS deleted_at;
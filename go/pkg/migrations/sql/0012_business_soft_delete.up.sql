@@ -0,0 +1,6 @@
+-- businesses are soft deleted by setting deleted_at, and purged along with
+-- all related rows once the grace period has passed
+
+ALTER TABLE asset_metadata ADD COLUMN IF NOT EXISTS deleted_at timestamp without time zone;
+
+CREATE INDEX IF NOT EXISTS asset_metadata_deleted_at_idx ON asset_metadata USING btree (deleted_at) WHERE deleted_at IS NOT NULL;
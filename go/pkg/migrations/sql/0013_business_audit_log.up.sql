@@ -0,0 +1,31 @@
+-- audit log of all changes made to businesses. each change is stored as
+-- a new version of the business along with the actor, the business before
+-- and after the change and the JSON patch applied
+
+CREATE TABLE IF NOT EXISTS business_audit_log (
+    business_id uuid NOT NULL,
+    version integer NOT NULL,
+    action text NOT NULL,
+    actor_key text DEFAULT ''::text NOT NULL,
+    actor_user text DEFAULT ''::text NOT NULL,
+    changed_at timestamp without time zone DEFAULT now() NOT NULL,
+    before json,
+    after json NOT NULL,
+    patch json DEFAULT '[]'::json NOT NULL,
+    reverted_version integer,
+    PRIMARY KEY (business_id, version)
+);
+
+-- existing businesses are recorded as created with their current state
+-- so that every business can be reverted to its first version
+
+INSERT INTO business_audit_log(business_id,version,action,changed_at,after,patch)
+    SELECT business_id, 1, 'create', added,
+        json_build_object('business_name', business_name, 'business_uri', uri, 'metadata', metadata),
+        json_build_array(
+            json_build_object('op', 'add', 'path', '/business_name', 'value', business_name),
+            json_build_object('op', 'add', 'path', '/business_uri', 'value', uri),
+            json_build_object('op', 'add', 'path', '/metadata', 'value', metadata))
+    FROM (SELECT business_id, added, business_name, uri, COALESCE(metadata, '{}'::json) AS metadata
+        FROM asset_metadata) AS businesses
+    ON CONFLICT DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE asset_metadata DROP COLUMN IF EXISTS version;
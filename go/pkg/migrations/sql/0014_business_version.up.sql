@@ -0,0 +1,9 @@
+-- version of each business used as the entity tag of the business. the
+-- version is incremented by every change and matches the latest version
+-- of the audit log of the business
+
+ALTER TABLE asset_metadata ADD COLUMN IF NOT EXISTS version integer DEFAULT 1 NOT NULL;
+
+UPDATE asset_metadata SET version = latest.version
+    FROM (SELECT business_id, MAX(version) AS version FROM business_audit_log GROUP BY business_id) AS latest
+    WHERE asset_metadata.business_id = latest.business_id;
@@ -0,0 +1 @@
+ALTER TABLE business_audit_log RENAME COLUMN actor_claimed_user TO actor_user;
@@ -0,0 +1,4 @@
+-- the user recorded in the audit log is taken from a header set by the
+-- client and is never verified, so the column is named as client-asserted
+
+ALTER TABLE business_audit_log RENAME COLUMN actor_user TO actor_claimed_user;
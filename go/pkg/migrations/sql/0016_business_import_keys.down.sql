@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS asset_metadata_normalised_name_idx;
+
+DROP INDEX IF EXISTS asset_metadata_domain_idx;
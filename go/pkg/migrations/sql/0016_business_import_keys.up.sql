@@ -0,0 +1,9 @@
+-- indexes on the domain and normalised name of businesses used to find
+-- existing businesses that may be duplicates of imported businesses. the
+-- expressions must match the import candidate query of the API
+
+CREATE INDEX IF NOT EXISTS asset_metadata_domain_idx ON asset_metadata (
+    (regexp_replace(lower(substring(uri from '^(?:[A-Za-z][A-Za-z0-9+.-]*://)?(?:[^@/]*@)?([^/:?#]+)')), '^www\.', '')));
+
+CREATE INDEX IF NOT EXISTS asset_metadata_normalised_name_idx ON asset_metadata (
+    (btrim(regexp_replace(lower(business_name), '[^[:alnum:]]+', ' ', 'g'))));
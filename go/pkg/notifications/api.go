@@ -9,11 +9,13 @@ import (
     "github.com/gin-contrib/cors"
     "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/alert-rules"
 )
 
 // function to generate a new gin router with the
 // relevant routes set
-func NewNotificationService(postgresUrl string) *gin.Engine {
+func NewNotificationService(repo Repository) *gin.Engine {
     router := gin.Default()
     router.Use(cors.New(cors.Config{
         AllowOrigins:     []string{"*"},
@@ -22,8 +24,8 @@ func NewNotificationService(postgresUrl string) *gin.Engine {
         AllowHeaders:     []string{"*"},
     }))
 
-    // add postgres session middleware to API
-    router.Use(PostgresSessionMiddleware(postgresUrl))
+    // add repository middleware to API
+    router.Use(RepositoryMiddleware(repo))
 
     router.GET("/notifications/all", getNotificationsHandler)
     router.GET("/notifications/unread", getUnreadNotificationsHandler)
@@ -32,6 +34,11 @@ func NewNotificationService(postgresUrl string) *gin.Engine {
 
     router.PATCH("/notifications/update/:notificationId", updateNotificationHandler)
     router.PATCH("/notifications/update-batch", updateNotificationBatchHandler)
+
+    router.GET("/notifications/rules", getAlertRulesHandler)
+    router.POST("/notifications/rules", createAlertRuleHandler)
+    router.PUT("/notifications/rules/:ruleId", updateAlertRuleHandler)
+    router.DELETE("/notifications/rules/:ruleId", deleteAlertRuleHandler)
     return router
 }
 
@@ -49,7 +56,7 @@ func getNotificationsHandler(ctx *gin.Context) {
         return
     }
 
-    db, _ := ctx.MustGet("persistence").(*Persistence)
+    db, _ := ctx.MustGet("persistence").(Repository)
     notifications, err := db.GetNotifications()
     if err != nil {
         log.Error(fmt.Errorf("unable to retrieve notifications: %+v", err))
@@ -94,7 +101,7 @@ func getUnreadNotificationsHandler(ctx *gin.Context) {
         return
     }
 
-    db, _ := ctx.MustGet("persistence").(*Persistence)
+    db, _ := ctx.MustGet("persistence").(Repository)
     notifications, err := db.GetUnreadNotifications()
     if err != nil {
         log.Error(fmt.Errorf("unable to retrieve notifications: %+v", err))
@@ -137,7 +144,7 @@ func updateNotificationHandler(ctx *gin.Context) {
         return
     }
     // check if notification exists or not
-    db, _ := ctx.MustGet("persistence").(*Persistence)
+    db, _ := ctx.MustGet("persistence").(Repository)
     exists, err := db.NotificationExists(notificationId)
     if err != nil {
         log.Error(fmt.Errorf("unable to check existing notifications: %+v", err))
@@ -174,7 +181,7 @@ func createNotificationHandler(ctx *gin.Context) {
     }
 
     // extract persistence from context and create new notification
-    db, _ := ctx.MustGet("persistence").(*Persistence)
+    db, _ := ctx.MustGet("persistence").(Repository)
 
     exists, err := db.NotificationHashExists(request.NotificationHash)
     if err != nil {
@@ -212,7 +219,7 @@ func updateNotificationBatchHandler(ctx *gin.Context) {
     }
 
     // extract persistence from context and create new notification
-    db, _ := ctx.MustGet("persistence").(*Persistence)
+    db, _ := ctx.MustGet("persistence").(Repository)
     for _, notificationId := range(request.Notifications) {
         // check if notification exists
         exists, err := db.NotificationExists(notificationId)
@@ -234,3 +241,128 @@ func updateNotificationBatchHandler(ctx *gin.Context) {
     ctx.JSON(http.StatusAccepted, gin.H{"http_code": http.StatusAccepted,
         "message": "Successfully updated notifications"})
 }
+
+// API handler used to retrieve all alert rules
+func getAlertRulesHandler(ctx *gin.Context) {
+    log.Info("received request to retrieve alert rules")
+    db, _ := ctx.MustGet("persistence").(Repository)
+    rules, err := db.GetAlertRules()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve alert rules: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    }
+    ctx.JSON(http.StatusOK, gin.H{"http_code": http.StatusOK,
+        "count": len(rules), "rules": rules})
+}
+
+// API handler used to create a new alert rule
+func createAlertRuleHandler(ctx *gin.Context) {
+    log.Info("received request to create new alert rule")
+    var request alert_rules.Rule
+    // parse request body and validate
+    if err := ctx.ShouldBind(&request); err != nil {
+        log.Error(fmt.Errorf("unable to parse request body: %+v", err))
+        ctx.JSON(http.StatusBadRequest, gin.H{"http_code": http.StatusBadRequest,
+            "message": "Invalid request body"})
+        return
+    }
+    if err := request.Validate(); err != nil {
+        log.Error(fmt.Errorf("received invalid alert rule: %+v", err))
+        ctx.JSON(http.StatusBadRequest, gin.H{"http_code": http.StatusBadRequest,
+            "message": err.Error()})
+        return
+    }
+
+    db, _ := ctx.MustGet("persistence").(Repository)
+    exists, err := db.AlertRuleExists(request.RuleId)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to check existing alert rules: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    } else if exists {
+        ctx.JSON(http.StatusConflict, gin.H{"http_code": http.StatusConflict,
+            "message": "Alert rule already exists"})
+        return
+    }
+
+    if err := db.UpdateAlertRule(request); err != nil {
+        log.Error(fmt.Errorf("unable to create alert rule: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    }
+    ctx.JSON(http.StatusCreated, gin.H{"http_code": http.StatusCreated,
+        "message": "Successfully created alert rule"})
+}
+
+// API handler used to update an existing alert rule
+func updateAlertRuleHandler(ctx *gin.Context) {
+    log.Info(fmt.Sprintf("received request to update alert rule %s", ctx.Param("ruleId")))
+    var request alert_rules.Rule
+    // parse request body and validate
+    if err := ctx.ShouldBind(&request); err != nil {
+        log.Error(fmt.Errorf("unable to parse request body: %+v", err))
+        ctx.JSON(http.StatusBadRequest, gin.H{"http_code": http.StatusBadRequest,
+            "message": "Invalid request body"})
+        return
+    }
+    // rule ID is always taken from path
+    request.RuleId = ctx.Param("ruleId")
+    if err := request.Validate(); err != nil {
+        log.Error(fmt.Errorf("received invalid alert rule: %+v", err))
+        ctx.JSON(http.StatusBadRequest, gin.H{"http_code": http.StatusBadRequest,
+            "message": err.Error()})
+        return
+    }
+
+    db, _ := ctx.MustGet("persistence").(Repository)
+    exists, err := db.AlertRuleExists(request.RuleId)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to check existing alert rules: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    } else if !exists {
+        ctx.JSON(http.StatusNotFound, gin.H{"http_code": http.StatusNotFound,
+            "message": "Invalid rule ID"})
+        return
+    }
+
+    if err := db.UpdateAlertRule(request); err != nil {
+        log.Error(fmt.Errorf("unable to update alert rule: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    }
+    ctx.JSON(http.StatusOK, gin.H{"http_code": http.StatusOK,
+        "message": "Successfully updated alert rule"})
+}
+
+// API handler used to delete an alert rule
+func deleteAlertRuleHandler(ctx *gin.Context) {
+    log.Info(fmt.Sprintf("received request to delete alert rule %s", ctx.Param("ruleId")))
+    db, _ := ctx.MustGet("persistence").(Repository)
+    exists, err := db.AlertRuleExists(ctx.Param("ruleId"))
+    if err != nil {
+        log.Error(fmt.Errorf("unable to check existing alert rules: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    } else if !exists {
+        ctx.JSON(http.StatusNotFound, gin.H{"http_code": http.StatusNotFound,
+            "message": "Invalid rule ID"})
+        return
+    }
+
+    if err := db.DeleteAlertRule(ctx.Param("ruleId")); err != nil {
+        log.Error(fmt.Errorf("unable to delete alert rule: %+v", err))
+        ctx.JSON(http.StatusInternalServerError, gin.H{"http_code": http.StatusInternalServerError,
+            "message": "Internal server error"})
+        return
+    }
+    ctx.JSON(http.StatusOK, gin.H{"http_code": http.StatusOK,
+        "message": "Successfully deleted alert rule"})
+}
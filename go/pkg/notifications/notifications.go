@@ -28,24 +28,18 @@ type NotificationEngine interface {
     SendNotification(notification ChangeNotification) error
 }
 
-func NewDefaultNotificationEngine(postgresUrl string) *DefaultNotificationEngine {
-    return &DefaultNotificationEngine{postgresUrl}
+func NewDefaultNotificationEngine(repo Repository) *DefaultNotificationEngine {
+    return &DefaultNotificationEngine{repo}
 }
 
+// notification engine used to store notifications directly in the
+// notifications repository of the service
 type DefaultNotificationEngine struct {
-    PostgresURL string
+    Repository Repository
 }
 
 func(e *DefaultNotificationEngine) SendNotification(notification ChangeNotification) error {
-    // establish new connection to postgres persistence
-    db := NewPersistence(e.PostgresURL)
-    conn, err := db.Connect()
-    if err != nil {
-        log.Error(fmt.Errorf("unable to connect notification engine to postgres: %+v", err))
-        return err
-    }
-    defer conn.Close()
-
+    db := e.Repository
     // check if notification hash already exists to prevent duplicate notifications
     exists, err := db.NotificationHashExists(notification.NotificationHash)
     if err != nil {
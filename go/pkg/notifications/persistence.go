@@ -6,10 +6,12 @@ import (
     "encoding/json"
 
     "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
     "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
+    "texas_real_foods/pkg/alert-rules"
 )
 
 
@@ -25,6 +27,14 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
 // function used to generate a new notification
 func(db *Persistence) CreateNotification(payload ChangeNotification) error {
     log.Debug(fmt.Sprintf("storing notification %+v", payload))
@@ -201,4 +211,73 @@ func(db *Persistence) UpdateNotification(notificationId uuid.UUID) error {
         return err
     }
     return nil
-}
\ No newline at end of file
+}
+
+// function used to retrieve all alert rules
+func(db *Persistence) GetAlertRules() ([]alert_rules.Rule, error) {
+    log.Debug("retrieving alert rules from database...")
+
+    rules := []alert_rules.Rule{}
+    query := `SELECT rule FROM alert_rules ORDER BY rule_id`
+    rows, err := db.Session.Query(context.Background(), query)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return rules, nil
+        default:
+            return rules, err
+        }
+    }
+
+    for rows.Next() {
+        var rule alert_rules.Rule
+        if err := rows.Scan(&rule); err != nil {
+            log.Error(fmt.Errorf("unable to retrieve alert rule from database: %+v", err))
+            continue
+        }
+        rules = append(rules, rule)
+    }
+    return rules, nil
+}
+
+// function used to check if an alert rule exists
+func(db *Persistence) AlertRuleExists(ruleId string) (bool, error) {
+    log.Debug(fmt.Sprintf("checking if alert rule %s exists...", ruleId))
+    query := `SELECT rule_id FROM alert_rules WHERE rule_id=$1`
+    row := db.Session.QueryRow(context.Background(), query, ruleId)
+    var existing string
+    if err := row.Scan(&existing); err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return false, nil
+        default:
+            return true, err
+        }
+    }
+    return true, nil
+}
+
+// function used to insert or update an alert rule
+func(db *Persistence) UpdateAlertRule(rule alert_rules.Rule) error {
+    log.Debug(fmt.Sprintf("storing alert rule %+v", rule))
+    query := `INSERT INTO alert_rules(rule_id,rule) VALUES($1,$2)
+        ON CONFLICT (rule_id) DO UPDATE SET rule=$2, updated=NOW()`
+    _, err := db.Session.Exec(context.Background(), query, rule.RuleId, rule)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to store alert rule: %+v", err))
+        return err
+    }
+    return nil
+}
+
+// function used to delete an alert rule
+func(db *Persistence) DeleteAlertRule(ruleId string) error {
+    log.Debug(fmt.Sprintf("deleting alert rule %s...", ruleId))
+    query := `DELETE FROM alert_rules WHERE rule_id=$1`
+    _, err := db.Session.Exec(context.Background(), query, ruleId)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to delete alert rule: %+v", err))
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,22 @@
+package notifications
+
+import (
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/alert-rules"
+)
+
+// interface used to store notifications and alert rules. postgres
+// persistence is the default implementation
+type Repository interface {
+    CreateNotification(payload ChangeNotification) error
+    GetNotifications() ([]Notification, error)
+    GetUnreadNotifications() ([]Notification, error)
+    NotificationHashExists(hashed string) (bool, error)
+    NotificationExists(notificationId uuid.UUID) (bool, error)
+    UpdateNotification(notificationId uuid.UUID) error
+    GetAlertRules() ([]alert_rules.Rule, error)
+    AlertRuleExists(ruleId string) (bool, error)
+    UpdateAlertRule(rule alert_rules.Rule) error
+    DeleteAlertRule(ruleId string) error
+}
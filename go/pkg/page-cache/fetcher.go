@@ -0,0 +1,101 @@
+package page_cache
+
+import (
+    "io"
+    "fmt"
+    "time"
+    "net/http"
+    "io/ioutil"
+
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define maximum number of bytes stored for a single page
+    MaxBodyBytes int64 = 5 * 1024 * 1024
+    // define timeout used when fetching pages
+    FetchTimeout = 30 * time.Second
+)
+
+// function used to create a new page fetcher. the page fetcher
+// is shared between all components that need to retrieve a
+// business website (the web scraper, the parked domain checker
+// etc.). pages are only fetched if no snapshot younger than the
+// given TTL exists, ensuring that each site is only fetched once
+// per collection cycle regardless of the number of consumers
+func NewPageFetcher(db *Persistence, ttlMinutes int) *PageFetcher {
+    return &PageFetcher{
+        Persistence: db,
+        TTL: time.Duration(ttlMinutes) * time.Minute,
+    }
+}
+
+type PageFetcher struct {
+    Persistence *Persistence
+    TTL         time.Duration
+}
+
+// function used to retrieve a page snapshot for a given URI.
+// cached snapshots are returned if they have not yet expired,
+// else the page is fetched and the cache is updated
+func(fetcher *PageFetcher) Fetch(uri string) (PageSnapshot, error) {
+    snapshot, err := fetcher.Persistence.GetSnapshot(uri, time.Now().Add(-fetcher.TTL))
+    switch err {
+    case nil:
+        log.Debug(fmt.Sprintf("using cached snapshot for %s fetched at %s", uri, snapshot.FetchedAt))
+        return snapshot, nil
+    case ErrSnapshotNotFound:
+        log.Debug(fmt.Sprintf("no valid snapshot found for %s. fetching page...", uri))
+    default:
+        // failures in the cache should not prevent pages from
+        // being fetched, so errors are logged and ignored
+        log.Warn(fmt.Sprintf("unable to retrieve cached snapshot for %s: %+v", uri, err))
+    }
+
+    snapshot, err = fetcher.FetchPage(uri)
+    if err != nil {
+        return snapshot, err
+    }
+    if err := fetcher.Persistence.UpdateSnapshot(snapshot); err != nil {
+        log.Warn(fmt.Sprintf("unable to store snapshot for %s: %+v", uri, err))
+    }
+    return snapshot, nil
+}
+
+// function used to fetch a page from a given URI without
+// consulting the cache. note that pages returning non-200
+// status codes are still returned as valid snapshots
+func(fetcher *PageFetcher) FetchPage(uri string) (PageSnapshot, error) {
+    var snapshot PageSnapshot
+    // generate new HTTP request with given settings
+    req, err := http.NewRequest("GET", uri, nil)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to generate new HTTP Request: %+v", err))
+        return snapshot, err
+    }
+
+    client := &http.Client{Timeout: FetchTimeout}
+    resp, err := client.Do(req)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to execute HTTP request: %+v", err))
+        return snapshot, err
+    }
+    defer resp.Body.Close()
+
+    // read response body, limiting the number of bytes stored
+    body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxBodyBytes))
+    if err != nil {
+        log.Error(fmt.Errorf("unable to read response body: %+v", err))
+        return snapshot, err
+    }
+
+    snapshot = PageSnapshot{
+        URI: uri,
+        FinalURL: resp.Request.URL.String(),
+        StatusCode: resp.StatusCode,
+        Headers: resp.Header,
+        Body: body,
+        FetchedAt: time.Now(),
+    }
+    return snapshot, nil
+}
@@ -0,0 +1,17 @@
+package page_cache
+
+import (
+    "time"
+)
+
+// struct used to store a snapshot of a fetched page. note that
+// the final URL is the URL of the page after all redirects have
+// been followed, and may differ from the URI that was requested
+type PageSnapshot struct {
+    URI        string              `json:"uri"`
+    FinalURL   string              `json:"final_url"`
+    StatusCode int                 `json:"status_code"`
+    Headers    map[string][]string `json:"headers"`
+    Body       []byte              `json:"body"`
+    FetchedAt  time.Time           `json:"fetched_at"`
+}
@@ -0,0 +1,91 @@
+package page_cache
+
+import (
+    "fmt"
+    "time"
+    "errors"
+    "context"
+    "encoding/json"
+
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+)
+
+var (
+    // define custom errors
+    ErrSnapshotNotFound = errors.New("Cannot find page snapshot")
+)
+
+type Persistence struct{
+    *utils.BasePostgresPersistence
+}
+
+func NewPersistence(url string) *Persistence {
+    // create instance of base persistence
+    basePersistence := utils.NewPersistence(url)
+    return &Persistence{
+        basePersistence,
+    }
+}
+
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to retrieve the most recent snapshot for a given
+// URI. snapshots fetched before the given timestamp are treated
+// as expired, in which case an ErrSnapshotNotFound is returned
+func(db *Persistence) GetSnapshot(uri string, fetchedAfter time.Time) (PageSnapshot, error) {
+    log.Debug(fmt.Sprintf("retrieving page snapshot for uri %s", uri))
+
+    snapshot := PageSnapshot{URI: uri}
+    query := `SELECT final_url,status_code,headers,body,fetched_at FROM page_snapshots
+        WHERE uri=$1 AND fetched_at > $2`
+
+    var headers []byte
+    err := db.Session.QueryRow(context.Background(), query, uri, fetchedAfter).Scan(
+        &snapshot.FinalURL, &snapshot.StatusCode, &headers, &snapshot.Body, &snapshot.FetchedAt)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return snapshot, ErrSnapshotNotFound
+        default:
+            return snapshot, err
+        }
+    }
+    // convert headers from JSON into map
+    if err := json.Unmarshal(headers, &snapshot.Headers); err != nil {
+        log.Warn(fmt.Errorf("unable to parse snapshot headers for %s: %+v", uri, err))
+    }
+    return snapshot, nil
+}
+
+// function used to store a new snapshot for a given URI. note that
+// only the most recent snapshot is kept for each URI
+func(db *Persistence) UpdateSnapshot(snapshot PageSnapshot) error {
+    log.Debug(fmt.Sprintf("storing page snapshot for uri %s", snapshot.URI))
+
+    headers, err := json.Marshal(snapshot.Headers)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to convert snapshot headers to JSON: %+v", err))
+        return err
+    }
+
+    query := `INSERT INTO page_snapshots(uri,final_url,status_code,headers,body,fetched_at)
+        VALUES($1,$2,$3,$4,$5,$6) ON CONFLICT (uri) DO UPDATE
+        SET final_url=$2, status_code=$3, headers=$4, body=$5, fetched_at=$6`
+    _, err = db.Session.Exec(context.Background(), query, snapshot.URI, snapshot.FinalURL,
+        snapshot.StatusCode, headers, snapshot.Body, snapshot.FetchedAt)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to insert page snapshot into database: %+v", err))
+        return err
+    }
+    return nil
+}
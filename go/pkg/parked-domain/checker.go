@@ -4,14 +4,13 @@ import (
     "fmt"
     "time"
     "sync"
-    "net/http"
-    "io/ioutil"
 
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
     "texas_real_foods/pkg/connectors"
     "texas_real_foods/pkg/notifications"
+    "texas_real_foods/pkg/page-cache"
     apis "texas_real_foods/pkg/utils/api_accessors"
 )
 
@@ -20,14 +19,20 @@ type ParkedDomainChecker struct {
     TexasRealFoodsAPIConfig utils.APIDependencyConfig
     NotificationsAPIConfig utils.APIDependencyConfig
     CheckIntervalMinutes int
+    Fetcher *page_cache.PageFetcher
 }
 
+// function used to generate a new parked domain checker. note that
+// pages are retrieved using the shared page fetcher, meaning that
+// sites already fetched by the web scraper are not fetched again
 func NewDomainChecker(texasRealFoodsAPIConfig utils.APIDependencyConfig,
-    notifyAPIConfig utils.APIDependencyConfig, interval int) *ParkedDomainChecker {
+    notifyAPIConfig utils.APIDependencyConfig, interval int,
+    fetcher *page_cache.PageFetcher) *ParkedDomainChecker {
     return &ParkedDomainChecker{
         TexasRealFoodsAPIConfig: texasRealFoodsAPIConfig,
         NotificationsAPIConfig: notifyAPIConfig,
         CheckIntervalMinutes: interval,
+        Fetcher: fetcher,
     }
 }
 
@@ -46,33 +51,20 @@ func(checker *ParkedDomainChecker) GetCurrentBusinesses() ([]connectors.Business
 
 // function used to check for parked domains
 func(checker *ParkedDomainChecker) IsDomainParked(business connectors.BusinessMetadata) (bool, error) {
-    // generate new HTTP request
-    request, err := http.NewRequest("GET", business.BusinessURI, nil)
+    // retrieve site data from shared page cache
+    snapshot, err := checker.Fetcher.Fetch(business.BusinessURI)
     if err != nil {
-        log.Error(fmt.Errorf("unable to generate new HTTP request for %s: %+v",
+        log.Error(fmt.Errorf("unable to fetch site data for %s: %+v",
             business.BusinessName, err))
         return false, err
     }
-    // generate new client instance and retrieve site data
-    client := http.Client{}
-    response, err := client.Do(request)
-    if err != nil {
-        log.Error(fmt.Errorf("unable to execute HTTP request: %+v", err))
-        return false, err
-    }
-    defer response.Body.Close()
 
-    switch response.StatusCode {
+    switch snapshot.StatusCode {
     case 200:
         log.Debug("successfully retrieved site data. checking for parked domain...")
-        data, err := ioutil.ReadAll(response.Body)
-        if err != nil {
-            log.Error(fmt.Sprintf("unable to read response body: %+v", err))
-            return false, err
-        }
         // iterate over conditions and check for expired domains
         for _, condition := range(ParkedDomainConditions) {
-            if condition(string(data)) {
+            if condition(string(snapshot.Body)) {
                 log.Info(fmt.Sprintf("parked domain condition met for %s at %s", business.BusinessName,
                     business.BusinessURI))
                 return true, nil
@@ -80,7 +72,7 @@ func(checker *ParkedDomainChecker) IsDomainParked(business connectors.BusinessMe
         }
     default:
         log.Error(fmt.Errorf("unable to get data for URI %s: received response code %d",
-            business.BusinessURI, response.StatusCode))
+            business.BusinessURI, snapshot.StatusCode))
         return false, err
     }
     return false, nil
@@ -0,0 +1,199 @@
+package phone
+
+import (
+    "fmt"
+    "errors"
+    "regexp"
+    "strings"
+
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define custom errors
+    ErrInvalidNumber     = errors.New("Invalid phone number")
+    ErrInvalidAreaCode   = errors.New("Invalid NANP area code")
+    ErrInvalidExchange   = errors.New("Invalid NANP exchange code")
+    ErrUnsupportedRegion = errors.New("Unsupported phone region")
+
+    // define expression used to separate extensions from numbers
+    extensionRegex = regexp.MustCompile(`(?i)\s*(?:ext\.?|extension|x|#)\s*(\d{1,6})\s*$`)
+
+    // define NANP toll free and premium rate area codes
+    tollFreeAreaCodes = []string{"800", "833", "844", "855", "866", "877", "888"}
+    premiumAreaCodes  = []string{"900"}
+)
+
+type NumberType string
+
+const (
+    TypeStandard    NumberType = "standard"
+    TypeTollFree    NumberType = "toll-free"
+    TypePremiumRate NumberType = "premium-rate"
+)
+
+// struct used to store a parsed phone number
+type Number struct {
+    CallingCode    string `json:"calling_code"`
+    NationalNumber string `json:"national_number"`
+    Extension      string `json:"extension"`
+    Region         string `json:"region"`
+}
+
+// function used to format number in E.164 format i.e. +15125550100.
+// note that extensions are not part of the E.164 format
+func(number Number) E164() string {
+    return fmt.Sprintf("+%s%s", number.CallingCode, number.NationalNumber)
+}
+
+// function used to classify number. numbers that are not NANP
+// numbers, or are too short to contain an area code, are standard
+func(number Number) Type() NumberType {
+    if number.CallingCode != "1" || len(number.NationalNumber) < 3 {
+        return TypeStandard
+    }
+    areaCode := number.NationalNumber[:3]
+    switch {
+    case stringSliceContains(tollFreeAreaCodes, areaCode):
+        return TypeTollFree
+    case stringSliceContains(premiumAreaCodes, areaCode):
+        return TypePremiumRate
+    }
+    return TypeStandard
+}
+
+func(number Number) IsTollFree() bool {
+    return number.Type() == TypeTollFree
+}
+
+// function used to parse a phone number into its components. numbers
+// without an international prefix are parsed using the given default
+// region. an error is returned if the number cannot be parsed or is
+// not valid for the region it belongs to
+func Parse(raw string, defaultRegion string) (Number, error) {
+    var number Number
+    // separate extension from number if present
+    text := strings.TrimSpace(raw)
+    if match := extensionRegex.FindStringSubmatch(text); match != nil {
+        number.Extension = match[1]
+        text = text[:len(text) - len(match[0])]
+    }
+
+    digits := extractDigits(text)
+    international := strings.HasPrefix(strings.TrimSpace(text), "+")
+
+    region, err := GetRegion(defaultRegion)
+    if err != nil {
+        return number, err
+    }
+
+    // convert international dialling prefixes into international format
+    switch {
+    case international:
+    case region.IsNANP() && strings.HasPrefix(digits, "011"):
+        digits, international = digits[3:], true
+    case !region.IsNANP() && strings.HasPrefix(digits, "00"):
+        digits, international = digits[2:], true
+    }
+
+    if international {
+        // determine region based on calling code
+        matched := false
+        for _, code := range([]string{"1", "44"}) {
+            if strings.HasPrefix(digits, code) {
+                region, matched = regionForCallingCode(code)
+                digits = digits[len(code):]
+                break
+            }
+        }
+        if !matched {
+            return number, ErrUnsupportedRegion
+        }
+    } else {
+        // remove trunk and calling code prefixes from national numbers
+        switch {
+        case len(digits) > region.MaxLength && strings.HasPrefix(digits, region.CallingCode):
+            digits = digits[len(region.CallingCode):]
+        case len(digits) > region.MinLength && strings.HasPrefix(digits, region.TrunkPrefix):
+            digits = digits[len(region.TrunkPrefix):]
+        }
+    }
+
+    if len(digits) < region.MinLength || len(digits) > region.MaxLength {
+        return number, ErrInvalidNumber
+    }
+    if region.IsNANP() {
+        if err := validateNANP(digits); err != nil {
+            return number, err
+        }
+    } else if strings.HasPrefix(digits, "0") {
+        return number, ErrInvalidNumber
+    }
+
+    number.CallingCode = region.CallingCode
+    number.NationalNumber = digits
+    number.Region = region.Code
+    return number, nil
+}
+
+// function used to normalize a phone number into E.164 format
+func Normalize(raw string, defaultRegion string) (string, error) {
+    number, err := Parse(raw, defaultRegion)
+    if err != nil {
+        return "", err
+    }
+    return number.E164(), nil
+}
+
+// function used to normalize a collection of phone numbers into
+// E.164 format. invalid numbers are dropped from the results and
+// duplicate numbers are only returned once
+func NormalizeAll(numbers []string, defaultRegion string) []string {
+    normalized := []string{}
+    for _, raw := range(numbers) {
+        number, err := Normalize(raw, defaultRegion)
+        if err != nil {
+            log.Debug(fmt.Sprintf("unable to normalize phone number '%s': %+v", raw, err))
+            continue
+        }
+        if !stringSliceContains(normalized, number) {
+            normalized = append(normalized, number)
+        }
+    }
+    return normalized
+}
+
+// function used to validate a ten digit NANP number. area codes
+// and exchange codes must both start with digits 2-9 and cannot
+// be N11 service codes. area codes with a 9 as the second digit
+// are reserved for expansion of the plan
+func validateNANP(digits string) error {
+    areaCode, exchange := digits[:3], digits[3:6]
+    if areaCode[0] < '2' || areaCode[1] == '9' || areaCode[1:] == "11" {
+        return ErrInvalidAreaCode
+    }
+    if exchange[0] < '2' || exchange[1:] == "11" {
+        return ErrInvalidExchange
+    }
+    return nil
+}
+
+// function used to remove all non-digit characters from a string
+func extractDigits(text string) string {
+    var digits strings.Builder
+    for _, char := range(text) {
+        if char >= '0' && char <= '9' {
+            digits.WriteRune(char)
+        }
+    }
+    return digits.String()
+}
+
+func stringSliceContains(slice []string, element string) bool {
+    for _, entry := range(slice) {
+        if entry == element {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,83 @@
+package phone
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestParse(t *testing.T) {
+    tests := []struct {
+        raw        string
+        region     string
+        e164       string
+        extension  string
+        numberType NumberType
+        err        error
+    }{
+        {"(512) 555-0100", "US", "+15125550100", "", TypeStandard, nil},
+        {"512.555.0100 ext. 42", "US", "+15125550100", "42", TypeStandard, nil},
+        {"1-512-555-0100 x7", "US", "+15125550100", "7", TypeStandard, nil},
+        {"+1 800 555 0100", "GB", "+18005550100", "", TypeTollFree, nil},
+        {"011 44 20 7946 0018", "US", "+442079460018", "", TypeStandard, nil},
+        {"020 7946 0018", "UK", "+442079460018", "", TypeStandard, nil},
+        {"0044 20 7946 0018", "GB", "+442079460018", "", TypeStandard, nil},
+        {"900-555-0100", "CA", "+19005550100", "", TypePremiumRate, nil},
+        {"555-0100", "US", "", "", "", ErrInvalidNumber},
+        {"(112) 555-0100", "US", "", "", "", ErrInvalidAreaCode},
+        {"(512) 155-0100", "US", "", "", "", ErrInvalidExchange},
+        {"(512) 911-0100", "US", "", "", "", ErrInvalidExchange},
+        {"(592) 555-0100", "US", "", "", "", ErrInvalidAreaCode},
+        {"+33 1 23 45 67 89", "US", "", "", "", ErrUnsupportedRegion},
+        {"512-555-0100", "FR", "", "", "", ErrUnsupportedRegion},
+    }
+    for _, test := range(tests) {
+        number, err := Parse(test.raw, test.region)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.raw, test.err, err)
+            continue
+        }
+        if err != nil {
+            continue
+        }
+        if number.E164() != test.e164 || number.Extension != test.extension || number.Type() != test.numberType {
+            t.Errorf("%q: expected %s ext %q (%s), got %s ext %q (%s)", test.raw, test.e164, test.extension,
+                test.numberType, number.E164(), number.Extension, number.Type())
+        }
+    }
+}
+
+func TestNormalizeAll(t *testing.T) {
+    tests := []struct {
+        numbers  []string
+        expected []string
+    }{
+        {[]string{}, []string{}},
+        {[]string{"512-555-0100", "(512) 555 0100", "+1 512 555 0100 ext 3"}, []string{"+15125550100"}},
+        {[]string{"invalid", "512-555-0101", "123"}, []string{"+15125550101"}},
+    }
+    for _, test := range(tests) {
+        result := NormalizeAll(test.numbers, DefaultRegion)
+        if strings.Join(result, ",") != strings.Join(test.expected, ",") {
+            t.Errorf("%v: expected %v, got %v", test.numbers, test.expected, result)
+        }
+    }
+}
+
+func TestNumberType(t *testing.T) {
+    tests := []struct {
+        number   Number
+        expected NumberType
+    }{
+        {Number{CallingCode: "1", NationalNumber: "8885550100"}, TypeTollFree},
+        {Number{CallingCode: "1", NationalNumber: "9005550100"}, TypePremiumRate},
+        {Number{CallingCode: "1", NationalNumber: "5125550100"}, TypeStandard},
+        {Number{CallingCode: "44", NationalNumber: "8005550100"}, TypeStandard},
+        {Number{CallingCode: "1", NationalNumber: "80"}, TypeStandard},
+        {Number{CallingCode: "1"}, TypeStandard},
+    }
+    for _, test := range(tests) {
+        if result := test.number.Type(); result != test.expected {
+            t.Errorf("%+v: expected %s, got %s", test.number, test.expected, result)
+        }
+    }
+}
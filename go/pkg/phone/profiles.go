@@ -0,0 +1,110 @@
+package phone
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+
+    log "github.com/sirupsen/logrus"
+)
+
+var (
+    // define metadata key used to set the extraction profile of a business
+    ProfileMetadataKey = "phone_profile"
+
+    // define extraction profiles keyed by country. each profile
+    // determines the regexes that are run against scraped pages
+    // and the region used to normalize and validate matches
+    Profiles = map[string]ExtractionProfile{
+        "US": ExtractionProfile{
+            Country: "US",
+            Patterns: map[string]*regexp.Regexp{
+                "us-1": regexp.MustCompile(`[2-9]\d{2}-\d{3}-\d{4}`),
+                "us-2": regexp.MustCompile(`((\(\d{3}\)?)|(\d{3}))([\s-./]?)(\d{3})([\s-./]?)(\d{4})`),
+                "us-3": regexp.MustCompile(`\(?[\d]{3}\)?[\s-]?[\d]{3}[\s-]?[\d]{4}`),
+            },
+        },
+        "GB": ExtractionProfile{
+            Country: "GB",
+            Patterns: map[string]*regexp.Regexp{
+                "uk-1": regexp.MustCompile(`((\(?0\d{4}\)?\s?\d{3}\s?\d{3})|(\(?0\d{3}\)?\s?\d{3}\s?\d{4})|(\(?0\d{2}\)?\s?\d{4}\s?\d{4}))(\s?\#(\d{4}|\d{3}))?`),
+                "uk-2": regexp.MustCompile(`(\+44\s?7\d{3}|\(?07\d{3}\)?)\s?\d{3}\s?\d{3}`),
+                "uk-3": regexp.MustCompile(`(((\+44\s?\d{4}|\(?0\d{4}\)?)\s?\d{3}\s?\d{3})|((\+44\s?\d{3}|\(?0\d{3}\)?)\s?\d{3}\s?\d{4})|((\+44\s?\d{2}|\(?0\d{2}\)?)\s?\d{4}\s?\d{4}))(\s?\#(\d{4}|\d{3}))?`),
+            },
+        },
+    }
+)
+
+// struct used to store a phone extraction profile
+type ExtractionProfile struct {
+    // ISO 3166 code of the country i.e. 'US' or 'GB'. the code is
+    // used as the default region when normalizing numbers and is
+    // sent to the validation API as the country code
+    Country  string
+    Patterns map[string]*regexp.Regexp
+}
+
+// function used to retrieve an extraction profile with a given
+// country code. codes are matched using the same rules as regions
+func GetProfile(code string) (ExtractionProfile, error) {
+    region, err := GetRegion(code)
+    if err != nil {
+        return ExtractionProfile{}, err
+    }
+    if profile, ok := Profiles[region.Code]; ok {
+        return profile, nil
+    }
+    return ExtractionProfile{}, ErrUnsupportedRegion
+}
+
+// function used to determine the extraction profile for a business.
+// the profile can be set in the business metadata, else the given
+// default profile is used. note that unsupported profiles set in
+// metadata are logged and ignored in favour of the default
+func ProfileForBusiness(metadata map[string]interface{},
+    defaultProfile string) (ExtractionProfile, error) {
+    if value, ok := metadata[ProfileMetadataKey].(string); ok && len(strings.TrimSpace(value)) > 0 {
+        profile, err := GetProfile(value)
+        if err == nil {
+            return profile, nil
+        }
+        log.Warn(fmt.Sprintf("ignoring unsupported phone profile '%s' in business metadata", value))
+    }
+    return GetProfile(defaultProfile)
+}
+
+// function used to extract phone numbers from text using the
+// patterns in the profile. matches are normalized into E.164
+// format, and matches that are not valid numbers are dropped
+func(profile ExtractionProfile) ExtractNumbers(text string) []string {
+    matches := []string{}
+    // iterate over regexes and find matches
+    for code, exp := range(profile.Patterns) {
+        log.Debug(fmt.Sprintf("checking regex match for code '%s'", code))
+        for _, match := range(exp.FindAllString(text, -1)) {
+            // normalize phone numbers to remove duplicates
+            cleaned, err := Normalize(match, profile.Country)
+            if err != nil {
+                log.Debug(fmt.Sprintf("discarding invalid phone number match %s: %+v", match, err))
+                continue
+            }
+            if !stringSliceContains(matches, cleaned) {
+                log.Debug(fmt.Sprintf("found phone number match %s", cleaned))
+                matches = append(matches, cleaned)
+            }
+        }
+    }
+    log.Debug(fmt.Sprintf("found phone number matches for numbers %+v", matches))
+    return matches
+}
+
+// function used to determine the region used to normalize numbers
+// for a business. the country of the business extraction profile
+// is used if set, else the given default region is returned
+func RegionFromMetadata(metadata map[string]interface{}, defaultRegion string) string {
+    profile, err := ProfileForBusiness(metadata, defaultRegion)
+    if err != nil {
+        return defaultRegion
+    }
+    return profile.Country
+}
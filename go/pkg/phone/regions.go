@@ -0,0 +1,64 @@
+package phone
+
+import (
+    "strings"
+)
+
+// struct used to store the dialing settings for a given region
+type Region struct {
+    // ISO 3166 code of the region i.e. 'US' or 'GB'
+    Code        string
+    // country calling code used in E.164 formatted numbers
+    CallingCode string
+    // trunk prefix used when dialling national numbers
+    TrunkPrefix string
+    // number of digits in national significant numbers
+    MinLength   int
+    MaxLength   int
+}
+
+var (
+    // define default region used when parsing numbers
+    DefaultRegion = "US"
+
+    // define supported regions. note that all regions that are part
+    // of the north american numbering plan share calling code 1 and
+    // are validated using the NANP rules
+    Regions = map[string]Region{
+        "US": Region{"US", "1", "1", 10, 10},
+        "CA": Region{"CA", "1", "1", 10, 10},
+        "PR": Region{"PR", "1", "1", 10, 10},
+        "GB": Region{"GB", "44", "0", 9, 10},
+    }
+)
+
+// function used to retrieve a region with a given code. codes
+// are matched case insensitively and 'UK' is treated as 'GB'
+func GetRegion(code string) (Region, error) {
+    code = strings.ToUpper(strings.TrimSpace(code))
+    if code == "UK" {
+        code = "GB"
+    }
+    if region, ok := Regions[code]; ok {
+        return region, nil
+    }
+    return Region{}, ErrUnsupportedRegion
+}
+
+// function used to determine if a region is part of the north
+// american numbering plan
+func(region Region) IsNANP() bool {
+    return region.CallingCode == "1"
+}
+
+// function used to retrieve the primary region for a given calling
+// code. calling code 1 is mapped to the US
+func regionForCallingCode(callingCode string) (Region, bool) {
+    switch callingCode {
+    case "1":
+        return Regions["US"], true
+    case "44":
+        return Regions["GB"], true
+    }
+    return Region{}, false
+}
@@ -0,0 +1,49 @@
+package reconciler
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+// struct used to store the latest data reported by a single
+// source for a given business. fields can be used to restrict
+// the fields a source provides values for (i.e. manual entries
+// that only set phone numbers). if no fields are set, values
+// are used for all fields
+type SourceValue struct {
+    Source    string
+    Data      connectors.BusinessData
+    Fields    []string
+    Timestamp time.Time
+}
+
+// struct used to store the reconciled value of a single field.
+// confidence is the share of the total (weighted) vote received
+// by the selected value, and sources contains the provenance of
+// the value i.e. all sources that agree with the selected value
+type FieldValue struct {
+    Value          interface{} `json:"value"`
+    Confidence     float64     `json:"confidence"`
+    SelectedSource string      `json:"selected_source"`
+    Sources        []string    `json:"sources"`
+    UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// struct used to store the golden record for a business i.e.
+// the canonical set of values believed to be true
+type GoldenRecord struct {
+    BusinessId uuid.UUID             `json:"business_id"`
+    Fields     map[string]FieldValue `json:"fields"`
+    ComputedAt time.Time             `json:"computed_at"`
+}
+
+// struct used to store reconciliation settings. source priorities
+// are used as the base weight of each source vote, and weights
+// decay with the age of the data using the given half life
+type Config struct {
+    SourcePriority       map[string]float64
+    RecencyHalfLifeHours float64
+}
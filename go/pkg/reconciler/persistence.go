@@ -0,0 +1,114 @@
+package reconciler
+
+import (
+    "fmt"
+    "errors"
+    "context"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/utils"
+)
+
+var (
+    // define custom errors
+    ErrGoldenRecordNotFound = errors.New("Cannot find golden record")
+)
+
+type Persistence struct{
+    *utils.BasePostgresPersistence
+}
+
+func NewPersistence(url string) *Persistence {
+    // create instance of base persistence
+    basePersistence := utils.NewPersistence(url)
+    return &Persistence{
+        basePersistence,
+    }
+}
+
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to retrieve the latest data from all sources for
+// a given business. the timestamp of each source is the time at
+// which the data was last collected
+func(db *Persistence) GetSourceValues(businessId uuid.UUID) ([]SourceValue, error) {
+    log.Debug(fmt.Sprintf("retrieving source values for business %s", businessId))
+
+    results := []SourceValue{}
+    query := `SELECT source,phone,website_live,open,meta,collected_at
+        FROM asset_data WHERE business_id=$1`
+    rows, err := db.Session.Query(context.Background(), query, businessId)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return results, nil
+        default:
+            return results, err
+        }
+    }
+
+    for rows.Next() {
+        var (value SourceValue; meta map[string]interface{})
+        if err := rows.Scan(&value.Source, &value.Data.BusinessPhones, &value.Data.WebsiteLive,
+            &value.Data.BusinessOpen, &meta, &value.Timestamp); err != nil {
+            log.Warn(fmt.Errorf("unable to scan data into local variables: %+v", err))
+            continue
+        }
+        value.Data.Source = value.Source
+        value.Data.CollectedAt = value.Timestamp
+        // sources can restrict the fields they provide via metadata
+        if fields, ok := meta["fields"].([]interface{}); ok {
+            for _, field := range(fields) {
+                if name, ok := field.(string); ok {
+                    value.Fields = append(value.Fields, name)
+                }
+            }
+        }
+        results = append(results, value)
+    }
+    return results, nil
+}
+
+// function used to insert or update the golden record for a business
+func(db *Persistence) UpdateGoldenRecord(record GoldenRecord) error {
+    log.Debug(fmt.Sprintf("updating golden record for business %s", record.BusinessId))
+
+    query := `INSERT INTO golden_records(business_id,fields,computed_at) VALUES($1,$2,$3)
+        ON CONFLICT (business_id) DO UPDATE SET fields=$2, computed_at=$3`
+    _, err := db.Session.Exec(context.Background(), query, record.BusinessId,
+        record.Fields, record.ComputedAt)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to update golden record: %+v", err))
+        return err
+    }
+    return nil
+}
+
+// function used to retrieve the golden record for a given business
+func(db *Persistence) GetGoldenRecord(businessId uuid.UUID) (GoldenRecord, error) {
+    log.Debug(fmt.Sprintf("retrieving golden record for business %s", businessId))
+
+    record := GoldenRecord{BusinessId: businessId}
+    query := `SELECT fields,computed_at FROM golden_records WHERE business_id=$1`
+    err := db.Session.QueryRow(context.Background(), query, businessId).Scan(
+        &record.Fields, &record.ComputedAt)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return record, ErrGoldenRecordNotFound
+        default:
+            return record, err
+        }
+    }
+    return record, nil
+}
@@ -0,0 +1,267 @@
+package reconciler
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "time"
+    "errors"
+    "strings"
+    "strconv"
+
+    "github.com/google/uuid"
+    log "github.com/sirupsen/logrus"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+var (
+    // define custom errors
+    ErrInvalidSourcePriority = errors.New("Invalid source priority")
+    ErrInvalidHalfLife       = errors.New("Invalid recency half life")
+
+    // define source name used for manually entered data
+    ManualSource = "manual"
+
+    // define default weight used for sources without a priority
+    DefaultSourceWeight = 0.5
+
+    // define default source priorities. manual entries outweigh
+    // all automated sources combined
+    DefaultSourcePriority = map[string]float64{
+        ManualSource: 5.0,
+        "google-api-connector": 0.8,
+        "yelp-api-connector": 0.7,
+        "web-scraper": 0.6,
+    }
+
+    // define reconciled fields. note that the yelp and google connectors
+    // do not check websites and always report them as live, so they
+    // are not considered for the website_live field
+    Fields = []Field{
+        Field{
+            Name: "website_live",
+            IgnoredSources: []string{"yelp-api-connector", "google-api-connector"},
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                return data.WebsiteLive, true
+            },
+        },
+        Field{
+            Name: "business_phones",
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                phones := append([]string{}, data.BusinessPhones...)
+                sort.Strings(phones)
+                return phones, len(phones) > 0
+            },
+        },
+        Field{
+            Name: "business_open",
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                return data.BusinessOpen, true
+            },
+        },
+    }
+)
+
+// struct used to define a reconciled field. the extract function
+// returns the value of the field for a given source, and false
+// if the source did not provide a value
+type Field struct {
+    Name           string
+    IgnoredSources []string
+    Extract        func(data connectors.BusinessData) (interface{}, bool)
+}
+
+// function used to parse source priorities from a configuration
+// string of the form 'manual:5,google-api-connector:0.8'. the
+// default priorities are returned if the string is empty
+func ParseSourcePriority(value string) (map[string]float64, error) {
+    if len(strings.TrimSpace(value)) == 0 {
+        return DefaultSourcePriority, nil
+    }
+    priorities := map[string]float64{}
+    for _, entry := range(strings.Split(value, ",")) {
+        parts := strings.Split(strings.TrimSpace(entry), ":")
+        if len(parts) != 2 {
+            return priorities, ErrInvalidSourcePriority
+        }
+        weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+        if err != nil || weight < 0 {
+            return priorities, ErrInvalidSourcePriority
+        }
+        priorities[strings.TrimSpace(parts[0])] = weight
+    }
+    return priorities, nil
+}
+
+// function used to generate reconciliation settings from configuration
+// strings. the half life is given in hours, and a half life of zero
+// disables recency weighting
+func NewConfig(sourcePriority, halfLifeHours string) (Config, error) {
+    priorities, err := ParseSourcePriority(sourcePriority)
+    if err != nil {
+        return Config{}, err
+    }
+    halfLife, err := strconv.ParseFloat(halfLifeHours, 64)
+    if err != nil || halfLife < 0 {
+        return Config{}, ErrInvalidHalfLife
+    }
+    return Config{
+        SourcePriority: priorities,
+        RecencyHalfLifeHours: halfLife,
+    }, nil
+}
+
+// function used to generate a new reconciler
+func New(db Repository, config Config) *Reconciler {
+    return &Reconciler{
+        Repository: db,
+        Config: config,
+    }
+}
+
+// struct used to reconcile data across sources into golden records
+type Reconciler struct {
+    Repository Repository
+    Config     Config
+}
+
+// function used to recompute and store the golden record for a
+// given business using the latest data from all sources
+func(reconciler *Reconciler) Reconcile(businessId uuid.UUID) (GoldenRecord, error) {
+    values, err := reconciler.Repository.GetSourceValues(businessId)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve source values for business %s: %+v", businessId, err))
+        return GoldenRecord{}, err
+    }
+
+    record := Merge(businessId, values, reconciler.Config, time.Now())
+    if err := reconciler.Repository.UpdateGoldenRecord(record); err != nil {
+        log.Error(fmt.Errorf("unable to store golden record for business %s: %+v", businessId, err))
+        return record, err
+    }
+    return record, nil
+}
+
+// function used to merge values from multiple sources into a
+// single golden record. each source votes for its value with a
+// weight determined by the source priority and the age of the
+// data, and the value with the highest total weight is selected
+func Merge(businessId uuid.UUID, values []SourceValue, config Config,
+    now time.Time) GoldenRecord {
+    record := GoldenRecord{
+        BusinessId: businessId,
+        Fields: map[string]FieldValue{},
+        ComputedAt: now,
+    }
+    for _, field := range(Fields) {
+        if value, ok := mergeField(field, values, config, now); ok {
+            record.Fields[field.Name] = value
+        }
+    }
+    return record
+}
+
+// struct used to store the votes cast for a single value
+type candidate struct {
+    value     interface{}
+    weight    float64
+    sources   []string
+    best      string
+    bestScore float64
+    updatedAt time.Time
+}
+
+// function used to merge a single field across sources. false
+// is returned if no source provides a value for the field
+func mergeField(field Field, values []SourceValue, config Config,
+    now time.Time) (FieldValue, bool) {
+
+    candidates := map[string]*candidate{}
+    total := 0.0
+    for _, source := range(values) {
+        if stringSliceContains(field.IgnoredSources, source.Source) {
+            continue
+        }
+        if len(source.Fields) > 0 && !stringSliceContains(source.Fields, field.Name) {
+            continue
+        }
+        value, ok := field.Extract(source.Data)
+        if !ok {
+            continue
+        }
+
+        weight := sourceWeight(source, config, now)
+        key := fmt.Sprintf("%v", value)
+        if _, ok := candidates[key]; !ok {
+            candidates[key] = &candidate{value: value}
+        }
+        c := candidates[key]
+        c.weight += weight
+        c.sources = append(c.sources, source.Source)
+        if weight > c.bestScore || len(c.best) == 0 {
+            c.best, c.bestScore = source.Source, weight
+        }
+        if source.Timestamp.After(c.updatedAt) {
+            c.updatedAt = source.Timestamp
+        }
+        total += weight
+    }
+    if len(candidates) == 0 {
+        return FieldValue{}, false
+    }
+
+    // select candidate with highest total weight. ties are broken
+    // using the weight of the strongest individual source
+    keys := []string{}
+    for key := range(candidates) {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    var selected *candidate
+    for _, key := range(keys) {
+        c := candidates[key]
+        if selected == nil || c.weight > selected.weight ||
+            (c.weight == selected.weight && c.bestScore > selected.bestScore) {
+            selected = c
+        }
+    }
+
+    confidence := 0.0
+    if total > 0 {
+        confidence = selected.weight / total
+    }
+    sort.Strings(selected.sources)
+    return FieldValue{
+        Value: selected.value,
+        Confidence: confidence,
+        SelectedSource: selected.best,
+        Sources: selected.sources,
+        UpdatedAt: selected.updatedAt,
+    }, true
+}
+
+// function used to determine the weight of a source vote. weights
+// are halved for every half life that has passed since the source
+// last reported data
+func sourceWeight(source SourceValue, config Config, now time.Time) float64 {
+    weight, ok := config.SourcePriority[source.Source]
+    if !ok {
+        weight = DefaultSourceWeight
+    }
+    if config.RecencyHalfLifeHours > 0 && !source.Timestamp.IsZero() {
+        age := math.Max(now.Sub(source.Timestamp).Hours(), 0)
+        weight = weight * math.Pow(0.5, age / config.RecencyHalfLifeHours)
+    }
+    return weight
+}
+
+// helper function used to check if a string slice contains a value
+func stringSliceContains(slice []string, element string) bool {
+    for _, item := range(slice) {
+        if item == element {
+            return true
+        }
+    }
+    return false
+}
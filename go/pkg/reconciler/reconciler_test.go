@@ -0,0 +1,111 @@
+package reconciler
+
+import (
+    "fmt"
+    "math"
+    "time"
+    "testing"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+// function used to generate a value reported by a source
+func testValue(source string, open bool, timestamp time.Time, fields ...string) SourceValue {
+    return SourceValue{Source: source, Fields: fields, Timestamp: timestamp,
+        Data: connectors.BusinessData{Source: source, BusinessOpen: open, WebsiteLive: open,
+            BusinessPhones: []string{"+15125550100"}}}
+}
+
+func TestMerge(t *testing.T) {
+    now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    defaults := Config{SourcePriority: DefaultSourcePriority}
+    custom := Config{SourcePriority: map[string]float64{"x": 0.5, "y": 0.25, "z": 0.75}}
+
+    tests := []struct {
+        name       string
+        config     Config
+        values     []SourceValue
+        field      string
+        expected   string
+        source     string
+        confidence float64
+    }{
+        {"manual outweighs all sources", defaults, []SourceValue{
+            testValue(ManualSource, false, now),
+            testValue("google-api-connector", true, now),
+            testValue("yelp-api-connector", true, now),
+            testValue("web-scraper", true, now),
+        }, "business_open", "false", ManualSource, 5 / 7.1},
+        {"weighted majority", defaults, []SourceValue{
+            testValue("google-api-connector", true, now),
+            testValue("yelp-api-connector", false, now),
+            testValue("web-scraper", false, now),
+        }, "business_open", "false", "yelp-api-connector", 1.3 / 2.1},
+        {"stale source decays", Config{SourcePriority: DefaultSourcePriority, RecencyHalfLifeHours: 24},
+            []SourceValue{
+                testValue("google-api-connector", true, now.Add(-48 * time.Hour)),
+                testValue("yelp-api-connector", false, now),
+            }, "business_open", "false", "yelp-api-connector", 0.7 / 0.9},
+        {"tie broken by strongest source", custom, []SourceValue{
+            testValue("x", false, now),
+            testValue("y", false, now),
+            testValue("z", true, now),
+        }, "business_open", "true", "z", 0.5},
+        {"restricted fields", defaults, []SourceValue{
+            testValue(ManualSource, false, now, "business_phones"),
+            testValue("web-scraper", true, now),
+        }, "business_open", "true", "web-scraper", 1},
+        {"ignored sources", defaults, []SourceValue{
+            testValue("google-api-connector", true, now),
+            testValue("web-scraper", false, now),
+        }, "website_live", "false", "web-scraper", 1},
+        {"unknown source", defaults, []SourceValue{
+            testValue("new-connector", true, now),
+        }, "business_open", "true", "new-connector", 1},
+        {"no values", defaults, []SourceValue{
+            testValue("google-api-connector", true, now),
+        }, "website_live", "", "", 0},
+    }
+    for _, test := range(tests) {
+        record := Merge(uuid.New(), test.values, test.config, now)
+        value, ok := record.Fields[test.field]
+        if ok != (len(test.expected) > 0) {
+            t.Errorf("%s: unexpected fields %+v", test.name, record.Fields)
+            continue
+        }
+        if !ok {
+            continue
+        }
+        if fmt.Sprintf("%v", value.Value) != test.expected || value.SelectedSource != test.source ||
+            math.Abs(value.Confidence - test.confidence) > 1e-9 {
+            t.Errorf("%s: expected %s from %s (%.3f), got %+v", test.name, test.expected,
+                test.source, test.confidence, value)
+        }
+    }
+}
+
+func TestParseSourcePriority(t *testing.T) {
+    tests := []struct {
+        value    string
+        expected map[string]float64
+        err      error
+    }{
+        {"", DefaultSourcePriority, nil},
+        {"manual:5, web-scraper: 0.6", map[string]float64{"manual": 5, "web-scraper": 0.6}, nil},
+        {"manual", nil, ErrInvalidSourcePriority},
+        {"manual:high", nil, ErrInvalidSourcePriority},
+        {"manual:-1", nil, ErrInvalidSourcePriority},
+    }
+    for _, test := range(tests) {
+        priorities, err := ParseSourcePriority(test.value)
+        if err != test.err {
+            t.Errorf("%q: expected error %v, got %v", test.value, test.err, err)
+            continue
+        }
+        if err == nil && fmt.Sprintf("%v", priorities) != fmt.Sprintf("%v", test.expected) {
+            t.Errorf("%q: expected %v, got %v", test.value, test.expected, priorities)
+        }
+    }
+}
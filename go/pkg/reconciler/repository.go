@@ -0,0 +1,13 @@
+package reconciler
+
+import (
+    "github.com/google/uuid"
+)
+
+// interface used to read source values and store golden records.
+// postgres persistence is the default implementation
+type Repository interface {
+    GetSourceValues(businessId uuid.UUID) ([]SourceValue, error)
+    UpdateGoldenRecord(record GoldenRecord) error
+    GetGoldenRecord(businessId uuid.UUID) (GoldenRecord, error)
+}
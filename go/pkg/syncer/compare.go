@@ -0,0 +1,190 @@
+package syncer
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/phone"
+)
+
+var (
+    // define default rules used to compare fields across sources. note
+    // that the yelp and google connectors do not check websites and
+    // always report them as live, so they are ignored for website_live
+    DefaultFieldRules = []FieldRule{
+        FieldRule{
+            Field: "website_live",
+            AuthoritativeSources: []string{"web-scraper"},
+            IgnoredSources: []string{"yelp-api-connector", "google-api-connector"},
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                return data.WebsiteLive, true
+            },
+            Equal: boolsEqual,
+        },
+        FieldRule{
+            Field: "business_phones",
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                return data.BusinessPhones, len(data.BusinessPhones) > 0
+            },
+            Equal: phoneSetsEqual,
+        },
+        FieldRule{
+            Field: "business_open",
+            AuthoritativeSources: []string{"google-api-connector", "yelp-api-connector"},
+            Extract: func(data connectors.BusinessData) (interface{}, bool) {
+                return data.BusinessOpen, true
+            },
+            Equal: boolsEqual,
+        },
+    }
+)
+
+// function used to compare the data entries of a single business
+// across all sources using the given field rules. phone numbers are
+// normalized before being compared, and sources that did not provide
+// a value for a field are reported as missing instead of conflicting
+func CompareEntries(entries []connectors.BusinessUpdate, rules []FieldRule,
+    phoneRegion string) ComparisonResult {
+
+    // map entries by source and sort sources to ensure that
+    // the comparison results are deterministic
+    mappedValues := map[string]connectors.BusinessData{}
+    sources := []string{}
+    for _, entry := range(entries) {
+        data := entry.Data
+        data.BusinessPhones = phone.NormalizeAll(data.BusinessPhones, phoneRegion)
+        mappedValues[data.Source] = data
+        sources = append(sources, data.Source)
+    }
+    sort.Strings(sources)
+
+    result := ComparisonResult{Differences: []FieldDifference{}}
+    for _, rule := range(rules) {
+        values := map[string]interface{}{}
+        present, missing := []string{}, []string{}
+        for _, source := range(sources) {
+            if stringSliceContains(rule.IgnoredSources, source) {
+                continue
+            }
+            if value, ok := rule.Extract(mappedValues[source]); ok {
+                values[source] = value
+                present = append(present, source)
+            } else {
+                missing = append(missing, source)
+            }
+        }
+
+        if conflicting := conflictingSources(rule, present, values); len(conflicting) > 0 {
+            conflictValues := map[string]interface{}{}
+            for _, source := range(conflicting) {
+                conflictValues[source] = values[source]
+            }
+            result.Differences = append(result.Differences, FieldDifference{
+                Field: rule.Field,
+                Type: DifferenceConflict,
+                Sources: conflicting,
+                Values: conflictValues,
+            })
+        }
+        // missing values are only reported if at least one
+        // other source was able to provide a value
+        if len(missing) > 0 && len(present) > 0 {
+            result.Differences = append(result.Differences, FieldDifference{
+                Field: rule.Field,
+                Type: DifferenceMissing,
+                Sources: missing,
+            })
+        }
+    }
+    return result
+}
+
+// function used to determine which sources conflict for a given
+// field. if an authoritative source provided a value, all sources
+// are compared against it and the authoritative source is returned
+// along with any sources that disagree. else all sources are
+// returned if any of the values differ
+func conflictingSources(rule FieldRule, present []string,
+    values map[string]interface{}) []string {
+    if len(present) < 2 {
+        return []string{}
+    }
+
+    reference := present[0]
+    for _, source := range(rule.AuthoritativeSources) {
+        if _, ok := values[source]; ok {
+            reference = source
+            break
+        }
+    }
+
+    differing := []string{}
+    for _, source := range(present) {
+        if source != reference && !rule.Equal(values[reference], values[source]) {
+            differing = append(differing, source)
+        }
+    }
+    switch {
+    case len(differing) == 0:
+        return differing
+    case stringSliceContains(rule.AuthoritativeSources, reference):
+        return append([]string{reference}, differing...)
+    default:
+        return present
+    }
+}
+
+// function used to determine if any fields conflict across sources
+func(result ComparisonResult) HasConflicts() bool {
+    for _, difference := range(result.Differences) {
+        if difference.Type == DifferenceConflict {
+            return true
+        }
+    }
+    return false
+}
+
+// function used to generate a human readable summary of the
+// differences found i.e. 'business_phones (conflict: a, b)'
+func(result ComparisonResult) Summary() string {
+    summaries := []string{}
+    for _, difference := range(result.Differences) {
+        summaries = append(summaries, fmt.Sprintf("%s (%s: %s)", difference.Field,
+            difference.Type, strings.Join(difference.Sources, ", ")))
+    }
+    return strings.Join(summaries, "; ")
+}
+
+// function used to compare two boolean values
+func boolsEqual(a, b interface{}) bool {
+    return a.(bool) == b.(bool)
+}
+
+// function used to compare two lists of phone numbers as sets.
+// note that numbers are expected to already be normalized
+func phoneSetsEqual(a, b interface{}) bool {
+    first, second := a.([]string), b.([]string)
+    for _, number := range(first) {
+        if !stringSliceContains(second, number) {
+            return false
+        }
+    }
+    for _, number := range(second) {
+        if !stringSliceContains(first, number) {
+            return false
+        }
+    }
+    return true
+}
+
+// helper function used to check if a string slice contains a value
+func stringSliceContains(slice []string, element string) bool {
+    for _, item := range(slice) {
+        if item == element {
+            return true
+        }
+    }
+    return false
+}
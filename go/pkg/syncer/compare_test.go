@@ -0,0 +1,85 @@
+package syncer
+
+import (
+    "testing"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/phone"
+)
+
+// function used to generate an update reported by a source
+func testUpdate(source string, live, open bool, phones ...string) connectors.BusinessUpdate {
+    return connectors.BusinessUpdate{Data: connectors.BusinessData{Source: source, WebsiteLive: live,
+        BusinessOpen: open, BusinessPhones: phones}}
+}
+
+func TestPhoneSetsEqual(t *testing.T) {
+    tests := []struct {
+        a        []string
+        b        []string
+        expected bool
+    }{
+        {[]string{}, []string{}, true},
+        {[]string{"+15125550100"}, []string{"+15125550100"}, true},
+        {[]string{"+15125550100", "+15125550101"}, []string{"+15125550101", "+15125550100"}, true},
+        {[]string{"+15125550100", "+15125550100"}, []string{"+15125550100"}, true},
+        {[]string{"+15125550100"}, []string{"+15125550100", "+15125550101"}, false},
+        {[]string{"+15125550100"}, []string{}, false},
+    }
+    for _, test := range(tests) {
+        if result := phoneSetsEqual(test.a, test.b); result != test.expected {
+            t.Errorf("%v and %v: expected %v, got %v", test.a, test.b, test.expected, result)
+        }
+    }
+}
+
+func TestCompareEntries(t *testing.T) {
+    tests := []struct {
+        name      string
+        entries   []connectors.BusinessUpdate
+        expected  string
+        conflicts bool
+    }{
+        {"no differences", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", true, true, "512-555-0100"),
+            testUpdate("yelp-api-connector", true, true, "(512) 555 0100"),
+        }, "", false},
+        {"ignored website source", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", false, true, "512-555-0100"),
+            testUpdate("yelp-api-connector", true, true, "512-555-0100"),
+        }, "", false},
+        {"authoritative source disagrees", []connectors.BusinessUpdate{
+            testUpdate("google-api-connector", true, false, "512-555-0100"),
+            testUpdate("web-scraper", true, true, "512-555-0100"),
+            testUpdate("yelp-api-connector", true, false, "512-555-0100"),
+        }, "business_open (conflict: google-api-connector, web-scraper)", true},
+        {"conflicting phones", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", true, true, "512-555-0100"),
+            testUpdate("yelp-api-connector", true, true, "512-555-0101"),
+        }, "business_phones (conflict: web-scraper, yelp-api-connector)", true},
+        {"missing phones", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", true, true),
+            testUpdate("yelp-api-connector", true, true, "512-555-0100"),
+        }, "business_phones (missing: web-scraper)", false},
+        {"invalid phones are missing", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", true, true, "555-0100"),
+            testUpdate("yelp-api-connector", true, true, "512-555-0100"),
+        }, "business_phones (missing: web-scraper)", false},
+        {"no source with phones", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", true, true),
+            testUpdate("yelp-api-connector", true, true),
+        }, "", false},
+        {"single source", []connectors.BusinessUpdate{
+            testUpdate("web-scraper", false, false, "512-555-0100"),
+        }, "", false},
+    }
+    for _, test := range(tests) {
+        result := CompareEntries(test.entries, DefaultFieldRules, phone.DefaultRegion)
+        if summary := result.Summary(); summary != test.expected {
+            t.Errorf("%s: expected differences %q, got %q", test.name, test.expected, summary)
+        }
+        if result.HasConflicts() != test.conflicts {
+            t.Errorf("%s: expected conflicts %v, got %+v", test.name, test.conflicts, result)
+        }
+    }
+}
@@ -1,10 +1,48 @@
 package syncer
 
 import (
+    "texas_real_foods/pkg/connectors"
+)
 
+var (
+    // define types of field differences
+    DifferenceConflict = "conflict"
+    DifferenceMissing  = "missing"
 )
 
-type ReducedBusinessData struct{
-    WebsiteLive    bool     `json:"website_live"`
-    BusinessPhones []string `json:"business_phones"`
-}
\ No newline at end of file
+// struct used to define how a single field is compared across
+// data sources. if authoritative sources are set, the values of
+// all other sources are compared against the authoritative value.
+// ignored sources are not considered when comparing the field
+type FieldRule struct {
+    Field                string
+    AuthoritativeSources []string
+    IgnoredSources       []string
+    // function used to extract the value of the field from a data
+    // entry. the second return value is false if the source did not
+    // provide a value for the field
+    Extract func(data connectors.BusinessData) (interface{}, bool)
+    // function used to determine if two values are equal
+    Equal   func(a, b interface{}) bool
+}
+
+// struct used to store a difference in a single field
+type FieldDifference struct {
+    Field    string                 `json:"field"`
+    Type     string                 `json:"type"`
+    Sources  []string               `json:"sources"`
+    Values   map[string]interface{} `json:"values,omitempty"`
+}
+
+// struct used to store the results of a comparison between
+// the data entries of a single business
+type ComparisonResult struct {
+    Differences []FieldDifference `json:"differences"`
+}
+
+// struct used to store the conflicts found for a single business
+type BusinessConflict struct {
+    Business connectors.BusinessMetadata
+    Entries  []connectors.BusinessUpdate
+    Result   ComparisonResult
+}
@@ -2,16 +2,23 @@ package syncer
 
 import (
     "fmt"
+    "time"
+    "errors"
     "context"
 
     "github.com/google/uuid"
     "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/connectors"
     "texas_real_foods/pkg/utils"
 )
 
+var (
+    // define custom errors
+    ErrWatermarkNotFound = errors.New("Cannot find sync watermark")
+)
 
 type Persistence struct{
     *utils.BasePostgresPersistence
@@ -25,14 +32,81 @@ func NewPersistence(url string) *Persistence {
     }
 }
 
-// function to retrieve all business metadata from the postgres
-// database
-func(db *Persistence) GetAllMetadata() ([]connectors.BusinessMetadata, error) {
-    log.Debug("retrieving business metadata")
+// function used to generate a new persistence using the shared
+// connection pool of the service
+func NewPersistenceWithPool(pool *pgxpool.Pool) *Persistence {
+    return &Persistence{
+        utils.NewPersistenceWithPool(pool),
+    }
+}
+
+// function used to retrieve the IDs of businesses whose sources
+// disagree on at least one field. the check is performed in a single
+// set-based query over the asset_data table and acts as a pre-filter
+// for the comparison engine, so may return businesses that are later
+// found to be in sync (i.e. sources that are ignored for a field).
+// only businesses updated after the given watermark are returned, and
+// deleted businesses are excluded. the time of the database at the
+// start of the query is returned as the next watermark, so that the
+// watermark is compared against update times from the same clock
+func(db *Persistence) GetConflictCandidates(since time.Time) ([]uuid.UUID, time.Time, error) {
+    log.Debug(fmt.Sprintf("retrieving conflict candidates updated since %s", since))
+
+    results := []uuid.UUID{}
+    var watermark time.Time
+    tx, err := db.Session.Begin(context.Background())
+    if err != nil {
+        log.Error(fmt.Errorf("unable to start transaction: %+v", err))
+        return results, watermark, err
+    }
+    defer tx.Rollback(context.Background())
+
+    // note that now() returns the start time of the transaction
+    if err := tx.QueryRow(context.Background(), `SELECT now()::timestamp`).Scan(&watermark); err != nil {
+        return results, watermark, err
+    }
+    // note that phone numbers are sorted and de-duplicated before being
+    // compared to ensure that lists are compared as sets, and empty phone
+    // lists are treated as missing values instead of conflicts
+    query := `WITH entries AS (
+            SELECT d.business_id, d.website_live, d.open,
+                ARRAY(SELECT DISTINCT p FROM unnest(d.phone) AS p ORDER BY p) AS phones
+            FROM asset_data d JOIN asset_metadata m ON m.business_id = d.business_id
+            WHERE d.source <> 'manual' AND m.last_update > $1 AND m.deleted_at IS NULL
+        )
+        SELECT business_id FROM entries GROUP BY business_id
+        HAVING COUNT(DISTINCT website_live) > 1 OR COUNT(DISTINCT open) > 1
+            OR COUNT(DISTINCT phones) FILTER (WHERE cardinality(phones) > 0) > 1`
+    rows, err := tx.Query(context.Background(), query, since)
+    if err != nil {
+        return results, watermark, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var businessId uuid.UUID
+        if err := rows.Scan(&businessId); err != nil {
+            log.Warn(fmt.Errorf("unable to scan values into local variables: %+v", err))
+            continue
+        }
+        results = append(results, businessId)
+    }
+    if err := rows.Err(); err != nil {
+        return results, watermark, err
+    }
+    rows.Close()
+    return results, watermark, tx.Commit(context.Background())
+}
+
+// function to retrieve business metadata for a batch of businesses
+func(db *Persistence) GetMetadataByIds(businessIds []uuid.UUID) (
+    map[uuid.UUID]connectors.BusinessMetadata, error) {
+    log.Debug(fmt.Sprintf("retrieving business metadata for %d businesses", len(businessIds)))
 
-    results := []connectors.BusinessMetadata{}
-    query := `SELECT business_id,business_name,metadata,uri FROM asset_metadata`
-    rows, err := db.Session.Query(context.Background(), query)
+    results := map[uuid.UUID]connectors.BusinessMetadata{}
+    query := `SELECT business_id,business_name,metadata,uri FROM asset_metadata
+        WHERE business_id = ANY($1) AND deleted_at IS NULL`
+    rows, err := db.Session.Query(context.Background(), query, businessIds)
     if err != nil {
         switch err {
         case pgx.ErrNoRows:
@@ -41,6 +115,7 @@ func(db *Persistence) GetAllMetadata() ([]connectors.BusinessMetadata, error) {
             return results, err
         }
     }
+    defer rows.Close()
 
     for rows.Next() {
         var (businessId uuid.UUID; businessName, businessUri string)
@@ -51,26 +126,29 @@ func(db *Persistence) GetAllMetadata() ([]connectors.BusinessMetadata, error) {
             log.Warn(fmt.Errorf("unable to scan values into local variables: %+v", err))
             continue
         }
-        results = append(results, connectors.BusinessMetadata{
+        results[businessId] = connectors.BusinessMetadata{
             BusinessId: businessId,
             BusinessName: businessName,
             BusinessURI: businessUri,
             Metadata: meta,
-        })
+        }
     }
-    return results, nil
+    return results, rows.Err()
 }
 
-// function used to retrieve data for a given business with business ID
-func(db *Persistence) GetDataByBusinessId(businessId uuid.UUID) (
-    []connectors.BusinessUpdate, error) {
-    log.Debug(fmt.Sprintf("retrieving data for business '%s'", businessId))
+// function used to retrieve data for a batch of businesses. results are
+// grouped by business ID. note that manually entered data is excluded
+// since manual entries only set a subset of fields and are used to
+// override sources
+func(db *Persistence) GetDataByBusinessIds(businessIds []uuid.UUID) (
+    map[uuid.UUID][]connectors.BusinessUpdate, error) {
+    log.Debug(fmt.Sprintf("retrieving data for %d businesses", len(businessIds)))
 
-    data := []connectors.BusinessUpdate{}
-    query := `SELECT source,website_live,phone FROM asset_data
-    WHERE business_id=$1`
+    data := map[uuid.UUID][]connectors.BusinessUpdate{}
+    query := `SELECT business_id,source,website_live,phone,open,collected_at FROM asset_data
+        WHERE business_id = ANY($1) AND source <> 'manual'`
 
-    rows, err := db.Session.Query(context.Background(), query, businessId)
+    rows, err := db.Session.Query(context.Background(), query, businessIds)
     if err != nil {
         switch err {
         case pgx.ErrNoRows:
@@ -79,25 +157,90 @@ func(db *Persistence) GetDataByBusinessId(businessId uuid.UUID) (
             return data, err
         }
     }
+    defer rows.Close()
 
     for rows.Next() {
         // read variables into local scope
-        var (source string; websiteLive bool; phones []string;)
-        if err := rows.Scan(&source, &websiteLive, &phones); err != nil {
+        var (businessId uuid.UUID; source string; websiteLive, open bool; phones []string;)
+        var collectedAt time.Time
+        if err := rows.Scan(&businessId, &source, &websiteLive, &phones, &open,
+            &collectedAt); err != nil {
             log.Warn(fmt.Errorf("unable to read data into local variables: %+v", err))
             continue
         }
-        // append new entry to results slice
-        data = append(data, connectors.BusinessUpdate{
+        // append new entry to results for business
+        data[businessId] = append(data[businessId], connectors.BusinessUpdate{
             Meta: connectors.BusinessMetadata{
                 BusinessId: businessId,
             },
             Data: connectors.BusinessData{
                 BusinessPhones: phones,
                 WebsiteLive: websiteLive,
+                BusinessOpen: open,
                 Source: source,
+                CollectedAt: collectedAt,
             },
         })
     }
-    return data, nil
-}
\ No newline at end of file
+    return data, rows.Err()
+}
+
+// function used to retrieve the IDs of businesses whose most recent
+// data was collected before a given time i.e. businesses that may
+// not have any fresh sources. deleted businesses are excluded
+func(db *Persistence) GetStaleCandidates(before time.Time) ([]uuid.UUID, error) {
+    log.Debug(fmt.Sprintf("retrieving businesses without data collected since %s", before))
+
+    results := []uuid.UUID{}
+    query := `SELECT d.business_id FROM asset_data d JOIN asset_metadata m
+        ON m.business_id = d.business_id WHERE d.source <> 'manual' AND m.deleted_at IS NULL
+        GROUP BY d.business_id HAVING MAX(d.collected_at) < $1`
+    rows, err := db.Session.Query(context.Background(), query, before)
+    if err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return results, nil
+        default:
+            return results, err
+        }
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var businessId uuid.UUID
+        if err := rows.Scan(&businessId); err != nil {
+            log.Warn(fmt.Errorf("unable to scan values into local variables: %+v", err))
+            continue
+        }
+        results = append(results, businessId)
+    }
+    return results, rows.Err()
+}
+
+// function used to retrieve the watermark of the last successful
+// sync job with a given name
+func(db *Persistence) GetWatermark(name string) (time.Time, error) {
+    log.Debug(fmt.Sprintf("retrieving sync watermark '%s'", name))
+
+    var watermark time.Time
+    query := `SELECT watermark FROM syncer_watermarks WHERE name=$1`
+    if err := db.Session.QueryRow(context.Background(), query, name).Scan(&watermark); err != nil {
+        switch err {
+        case pgx.ErrNoRows:
+            return watermark, ErrWatermarkNotFound
+        default:
+            return watermark, err
+        }
+    }
+    return watermark, nil
+}
+
+// function used to update the watermark of a sync job with a given name
+func(db *Persistence) UpdateWatermark(name string, watermark time.Time) error {
+    log.Debug(fmt.Sprintf("updating sync watermark '%s' to %s", name, watermark))
+
+    query := `INSERT INTO syncer_watermarks(name,watermark) VALUES($1,$2)
+        ON CONFLICT (name) DO UPDATE SET watermark=$2`
+    _, err := db.Session.Exec(context.Background(), query, name, watermark)
+    return err
+}
@@ -0,0 +1,20 @@
+package syncer
+
+import (
+    "time"
+
+    "github.com/google/uuid"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+// interface used to read business data and store sync watermarks.
+// postgres persistence is the default implementation
+type Repository interface {
+    GetConflictCandidates(since time.Time) ([]uuid.UUID, time.Time, error)
+    GetMetadataByIds(businessIds []uuid.UUID) (map[uuid.UUID]connectors.BusinessMetadata, error)
+    GetDataByBusinessIds(businessIds []uuid.UUID) (map[uuid.UUID][]connectors.BusinessUpdate, error)
+    GetStaleCandidates(before time.Time) ([]uuid.UUID, error)
+    GetWatermark(name string) (time.Time, error)
+    UpdateWatermark(name string, watermark time.Time) error
+}
@@ -5,7 +5,6 @@ import (
     "time"
     "sync"
     "errors"
-    "reflect"
 
     log "github.com/sirupsen/logrus"
 
@@ -15,116 +14,254 @@ import (
 
 var (
     ErrDataSyncError = errors.New("Error syncing data tables")
+
+    // define name of watermark used to track incremental syncs
+    WatermarkName = "syncer"
 )
 
 type Syncer struct{
-    PostgresURL   string
+    Repository    Repository
     Notifications notifications.NotificationEngine
     CollectionPeriodMinutes int
+    PhoneRegion   string
+    FieldRules    []FieldRule
+    BatchSize     int
+    Incremental   bool
+    FreshnessSLAs connectors.FreshnessSLAs
 }
 
-func NewSyncer(postgresUrl string, collectionPeriodMinutes int,
-    notifier notifications.NotificationEngine) *Syncer {
+func NewSyncer(repo Repository, collectionPeriodMinutes int,
+    notifier notifications.NotificationEngine, phoneRegion string,
+    batchSize int, incremental bool, slas connectors.FreshnessSLAs) *Syncer {
     return &Syncer{
-        PostgresURL: postgresUrl,
+        Repository: repo,
         Notifications: notifier,
         CollectionPeriodMinutes: collectionPeriodMinutes,
+        PhoneRegion: phoneRegion,
+        FieldRules: DefaultFieldRules,
+        BatchSize: batchSize,
+        Incremental: incremental,
+        FreshnessSLAs: slas,
     }
 }
 
+// function used to sync data across sources. businesses with conflicting
+// sources are found using a set-based query, and notifications are sent
+// for each conflict. if incremental syncs are enabled, only businesses
+// updated since the last successful sync are processed
 func(syncer *Syncer) SyncData() error {
-    // establish new connection to postgres persistence
-    db := NewPersistence(syncer.PostgresURL)
-    conn, err := db.Connect()
+    db := syncer.Repository
+
+    start := time.Now()
+    since := time.Time{}
+    if syncer.Incremental {
+        watermark, err := db.GetWatermark(WatermarkName)
+        switch err {
+        case nil:
+            since = watermark
+        case ErrWatermarkNotFound:
+            log.Info("no sync watermark found. processing all businesses")
+        default:
+            log.Error(fmt.Errorf("unable to retrieve sync watermark: %+v", err))
+            return err
+        }
+    }
+
+    // note that the watermark is the time of the database when the
+    // candidates are retrieved, ensuring that updates made during the
+    // sync are not skipped regardless of clock skew between hosts
+    conflicts, watermark, err := syncer.FindConflicts(db, since)
     if err != nil {
-        log.Error(fmt.Errorf("unable to connect to postgres: %+v", err))
+        log.Error(fmt.Errorf("unable to retrieve conflicts: %+v", err))
         return err
     }
-    defer conn.Close()
-    // get all businesses from database and return
-    businesses, err := db.GetAllMetadata()
-    if err != nil {
-        log.Error(fmt.Errorf("unable to retrieve business metadata: %+v", err))
+    log.Info(fmt.Sprintf("found %d businesses with conflicting data since %s", len(conflicts), since))
+
+    for _, conflict := range(conflicts) {
+        if err := syncer.NotifyConflict(conflict); err != nil {
+            log.Error(fmt.Errorf("unable to send change notification: %+v", err))
+        }
+    }
+
+    // note that stale businesses are never updated, so the check
+    // for stale data always runs over the entire directory
+    if err := syncer.CheckStaleData(db, start); err != nil {
+        log.Error(fmt.Errorf("unable to check for stale data: %+v", err))
+        return err
+    }
+
+    if err := db.UpdateWatermark(WatermarkName, watermark); err != nil {
+        log.Error(fmt.Errorf("unable to update sync watermark: %+v", err))
         return err
     }
+    return nil
+}
 
-    // iterate over retrieved businesses and compare data values
-    for _, business := range(businesses) {
-        log.Debug(fmt.Sprintf("processing changes for business %+v", business))
-        // retrieve all data values/source data for a given business
-        data, err := db.GetDataByBusinessId(business.BusinessId)
+// function used to find businesses with conflicting data across sources.
+// candidates are retrieved using a single set-based query, and the data
+// for candidates are then retrieved in batches and compared using the
+// configured field rules. only businesses with conflicts are returned,
+// along with the watermark to use for the next incremental sync
+func(syncer *Syncer) FindConflicts(db Repository, since time.Time) ([]BusinessConflict, time.Time, error) {
+    conflicts := []BusinessConflict{}
+    candidates, watermark, err := db.GetConflictCandidates(since)
+    if err != nil {
+        return conflicts, watermark, err
+    }
+    log.Debug(fmt.Sprintf("comparing data for %d candidate businesses", len(candidates)))
+
+    now := time.Now()
+    for _, batch := range(batchIds(candidates, syncer.BatchSize)) {
+        metadata, err := db.GetMetadataByIds(batch)
         if err != nil {
-            log.Error(fmt.Errorf("unable to retrieve data for business %s: %+v",
-            business.BusinessId, err))
-            continue
+            return conflicts, watermark, err
+        }
+        data, err := db.GetDataByBusinessIds(batch)
+        if err != nil {
+            return conflicts, watermark, err
         }
 
-        log.Debug(fmt.Sprintf("comparing %d entries for %s", len(data), business.BusinessName))
-        if syncer.DataEntriesDiffer(data) {
-            log.Info(fmt.Sprintf("found data differences in updates. sending notification..."))
-
-            // convert entries into JSON string and hash. notifications are all
-            // stored against a hash of the values that generated the notifications
-            // to ensure that notifications from a given set of data values are
-            // only generated once
-            mapValues := map[string][]connectors.BusinessUpdate{ "entries": data }
-            hashed, err := HashMap(mapValues)
-            if err != nil {
-                log.Error(fmt.Errorf("unable to generate hash from business update(s): %+v", err))
+        for _, businessId := range(batch) {
+            business := metadata[businessId]
+            // stale sources are excluded from the comparison
+            entries := syncer.FreshEntries(data[businessId], now)
+            result := syncer.CompareEntries(entries)
+            if !result.HasConflicts() {
+                log.Debug(fmt.Sprintf("data entries for '%s' in sync", business.BusinessName))
                 continue
             }
+            conflicts = append(conflicts, BusinessConflict{
+                Business: business,
+                Entries: entries,
+                Result: result,
+            })
+        }
+    }
+    return conflicts, watermark, nil
+}
 
-            log.Debug(fmt.Sprintf("successfully hashed business update(s) into '%s'", hashed))
+// function used to check for businesses without any fresh sources.
+// a stale data notification is sent for each business found
+func(syncer *Syncer) CheckStaleData(db Repository, now time.Time) error {
+    candidates, err := db.GetStaleCandidates(now.Add(-syncer.FreshnessSLAs.Min()))
+    if err != nil {
+        return err
+    }
+    log.Debug(fmt.Sprintf("checking freshness for %d candidate businesses", len(candidates)))
 
-            // construt message for notification
-            sources := []string{}
-            for _, entry := range(data) {
-                sources = append(sources, entry.Data.Source)
-            }
-            message := fmt.Sprintf("found differences in data sources %+v for business %s",
-                sources, business.BusinessName)
+    for _, batch := range(batchIds(candidates, syncer.BatchSize)) {
+        metadata, err := db.GetMetadataByIds(batch)
+        if err != nil {
+            return err
+        }
+        data, err := db.GetDataByBusinessIds(batch)
+        if err != nil {
+            return err
+        }
 
-            // send notification
-            if err := syncer.SendNotification(message, hashed, business); err != nil {
-                log.Error(fmt.Errorf("unable to send change notification: %+v", err))
+        for _, businessId := range(batch) {
+            if len(data[businessId]) == 0 || len(syncer.FreshEntries(data[businessId], now)) > 0 {
                 continue
             }
-        } else {
-            log.Info(fmt.Sprintf("data entries for '%s' in sync", business.BusinessName))
+            if err := syncer.NotifyStaleData(metadata[businessId], data[businessId]); err != nil {
+                log.Error(fmt.Errorf("unable to send stale data notification: %+v", err))
+            }
         }
     }
     return nil
 }
 
-// function to check if data entries differ
-func(syncer *Syncer) DataEntriesDiffer(entries []connectors.BusinessUpdate) bool {
-
-    mappedValues := map[string]ReducedBusinessData{}
+// function used to filter out entries from sources that have not
+// collected data within their freshness SLA
+func(syncer *Syncer) FreshEntries(entries []connectors.BusinessUpdate,
+    now time.Time) []connectors.BusinessUpdate {
+    fresh := []connectors.BusinessUpdate{}
     for _, entry := range(entries) {
-        // get source of data and add to map
-        source := entry.Data.Source
-        mappedValues[source] = ReducedBusinessData{
-            BusinessPhones: entry.Data.BusinessPhones,
-            WebsiteLive: entry.Data.WebsiteLive,
+        if syncer.FreshnessSLAs.IsStale(entry.Data.Source, entry.Data.CollectedAt, now) {
+            log.Debug(fmt.Sprintf("ignoring stale data from source %s collected at %s",
+                entry.Data.Source, entry.Data.CollectedAt))
+            continue
         }
+        fresh = append(fresh, entry)
     }
+    return fresh
+}
 
-    // compare values by source
-    for source, data  := range(mappedValues) {
-        for subSource, subData  := range(mappedValues) {
-            if source == subSource {
-                continue
-            }
-            if !reflect.DeepEqual(data, subData) {
-                return true
-            }
+// function used to send a notification for a business that has
+// no fresh sources. notifications are hashed against the latest
+// collection time to ensure only one notification is sent until
+// new data is collected
+func(syncer *Syncer) NotifyStaleData(business connectors.BusinessMetadata,
+    entries []connectors.BusinessUpdate) error {
+    log.Info(fmt.Sprintf("found stale data for '%s'. sending notification...", business.BusinessName))
+
+    var latest time.Time
+    collected := map[string]time.Time{}
+    for _, entry := range(entries) {
+        collected[entry.Data.Source] = entry.Data.CollectedAt
+        if entry.Data.CollectedAt.After(latest) {
+            latest = entry.Data.CollectedAt
         }
     }
-    return false
+    hashed, err := HashMap(map[string]interface{}{
+        "business_id": business.BusinessId,
+        "stale_since": latest,
+    })
+    if err != nil {
+        log.Error(fmt.Errorf("unable to generate hash from business update(s): %+v", err))
+        return err
+    }
+
+    message := fmt.Sprintf("stale data for business %s: no source has collected data since %s",
+        business.BusinessName, latest.Format(time.RFC3339))
+    payload := notifications.ChangeNotification{
+        BusinessId: business.BusinessId,
+        BusinessName: business.BusinessName,
+        EventTimestamp: time.Now(),
+        NotificationHash: hashed,
+        Notification: message,
+        Metadata: map[string]interface{}{
+            "source": "syncer",
+            "type": "stale_data",
+            "collected_at": collected,
+        },
+    }
+    return syncer.Notifications.SendNotification(payload)
+}
+
+// function used to send a notification for a given conflict
+func(syncer *Syncer) NotifyConflict(conflict BusinessConflict) error {
+    business, result := conflict.Business, conflict.Result
+    log.Info(fmt.Sprintf("found data differences for '%s'. sending notification...", business.BusinessName))
+
+    // hash differences found between sources. notifications are all
+    // stored against a hash of the differences that generated the
+    // notifications to ensure that notifications from a given set of
+    // differences are only generated once
+    hashed, err := HashMap(map[string]interface{}{
+        "business_id": business.BusinessId,
+        "differences": result.Differences,
+    })
+    if err != nil {
+        log.Error(fmt.Errorf("unable to generate hash from business update(s): %+v", err))
+        return err
+    }
+    log.Debug(fmt.Sprintf("successfully hashed business update(s) into '%s'", hashed))
+
+    // construt message for notification and send
+    message := fmt.Sprintf("found differences in data sources for business %s: %s",
+        business.BusinessName, result.Summary())
+    return syncer.SendNotification(message, hashed, business, result)
+}
+
+// function used to compare data entries across sources using
+// the field rules configured on the syncer
+func(syncer *Syncer) CompareEntries(entries []connectors.BusinessUpdate) ComparisonResult {
+    return CompareEntries(entries, syncer.FieldRules, syncer.PhoneRegion)
 }
 
 func(syncer *Syncer) SendNotification(message, hashed string,
-    business connectors.BusinessMetadata) error {
+    business connectors.BusinessMetadata, result ComparisonResult) error {
 
     log.Debug(fmt.Sprintf("sending new message '%s'", message))
     payload := notifications.ChangeNotification{
@@ -133,6 +270,10 @@ func(syncer *Syncer) SendNotification(message, hashed string,
         EventTimestamp: time.Now(),
         NotificationHash: hashed,
         Notification: message,
+        Metadata: map[string]interface{}{
+            "source": "syncer",
+            "differences": result.Differences,
+        },
     }
     return syncer.Notifications.SendNotification(payload)
 }
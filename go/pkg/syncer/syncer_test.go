@@ -0,0 +1,49 @@
+package syncer
+
+import (
+    "time"
+    "testing"
+
+    "texas_real_foods/pkg/connectors"
+)
+
+func TestFreshEntries(t *testing.T) {
+    now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    syncer := &Syncer{FreshnessSLAs: connectors.FreshnessSLAs{"web-scraper": 24 * time.Hour}}
+    entry := func(source string, age time.Duration) connectors.BusinessUpdate {
+        return connectors.BusinessUpdate{Data: connectors.BusinessData{Source: source,
+            CollectedAt: now.Add(-age)}}
+    }
+
+    tests := []struct {
+        name     string
+        entries  []connectors.BusinessUpdate
+        expected []string
+    }{
+        {"no entries", []connectors.BusinessUpdate{}, []string{}},
+        {"all fresh", []connectors.BusinessUpdate{entry("web-scraper", time.Hour),
+            entry("yelp-api-connector", 48 * time.Hour)}, []string{"web-scraper", "yelp-api-connector"}},
+        {"stale by source SLA", []connectors.BusinessUpdate{entry("web-scraper", 25 * time.Hour),
+            entry("yelp-api-connector", 25 * time.Hour)}, []string{"yelp-api-connector"}},
+        {"stale by default SLA", []connectors.BusinessUpdate{entry("web-scraper", time.Hour),
+            entry("yelp-api-connector", 73 * time.Hour)}, []string{"web-scraper"}},
+        {"all stale", []connectors.BusinessUpdate{entry("web-scraper", 30 * time.Hour)}, []string{}},
+    }
+    for _, test := range(tests) {
+        fresh := syncer.FreshEntries(test.entries, now)
+        sources := []string{}
+        for _, entry := range(fresh) {
+            sources = append(sources, entry.Data.Source)
+        }
+        if len(sources) != len(test.expected) {
+            t.Errorf("%s: expected sources %v, got %v", test.name, test.expected, sources)
+            continue
+        }
+        for i := range(sources) {
+            if sources[i] != test.expected[i] {
+                t.Errorf("%s: expected sources %v, got %v", test.name, test.expected, sources)
+                break
+            }
+        }
+    }
+}
@@ -7,6 +7,7 @@ import (
     "encoding/json"
     "encoding/hex"
 
+    "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
 )
 
@@ -25,4 +26,21 @@ func HashMap(values interface{}) (string, error) {
     // hash JSON string and return
     sum := sha256.Sum256(jsonString)
     return hex.EncodeToString(sum[0:]), nil
-}
\ No newline at end of file
+}
+
+// function used to split a list of business IDs into batches of
+// a given size. a single batch is returned if the size is not set
+func batchIds(ids []uuid.UUID, size int) [][]uuid.UUID {
+    if size < 1 {
+        size = len(ids)
+    }
+    batches := [][]uuid.UUID{}
+    for start := 0; start < len(ids); start += size {
+        end := start + size
+        if end > len(ids) {
+            end = len(ids)
+        }
+        batches = append(batches, ids[start:end])
+    }
+    return batches
+}
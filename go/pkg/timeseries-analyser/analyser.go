@@ -12,24 +12,54 @@ import (
     "texas_real_foods/pkg/utils"
     api "texas_real_foods/pkg/utils/api_accessors"
     "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/alert-rules"
 )
 
 type TimeseriesAnalyser struct {
     TRFAPIConfig  utils.APIDependencyConfig
     NotifyAPIConfig  utils.APIDependencyConfig
     AnalysisIntervalMinutes int
+    PhoneRegion string
+    Config      AnalysisConfig
+    // alert rules loaded from configuration. rules stored in the
+    // notifications API are merged with these on every analysis
+    Rules       []alert_rules.Rule
 }
 
 func NewAnalyser(apiConfig utils.APIDependencyConfig,
     notifyApiConfig utils.APIDependencyConfig,
-    interval int) *TimeseriesAnalyser {
+    interval int, phoneRegion string, config AnalysisConfig,
+    rules []alert_rules.Rule) *TimeseriesAnalyser {
     return &TimeseriesAnalyser{
         TRFAPIConfig: apiConfig,
         NotifyAPIConfig: notifyApiConfig,
         AnalysisIntervalMinutes: interval,
+        PhoneRegion: phoneRegion,
+        Config: config,
+        Rules: rules,
     }
 }
 
+// function used to retrieve the current set of alert rules. rules
+// stored in the notifications API replace configured rules with the
+// same ID, and configured rules are used alone if the API is down
+func(analyser *TimeseriesAnalyser) GetAlertRules(accessor *api.NotificationsAPIAccessor) []alert_rules.Rule {
+    stored, err := accessor.GetAlertRules()
+    if err != nil {
+        log.Error(fmt.Errorf("unable to retrieve alert rules from API: %+v", err))
+        return analyser.Rules
+    }
+    valid := []alert_rules.Rule{}
+    for _, rule := range(stored) {
+        if err := rule.Validate(); err != nil {
+            log.Warn(fmt.Sprintf("skipping invalid alert rule %s: %+v", rule.RuleId, err))
+            continue
+        }
+        valid = append(valid, rule)
+    }
+    return alert_rules.MergeRules(analyser.Rules, valid)
+}
+
 // function used to retrieve business metadata for all stored
 // businesses
 func(analyser *TimeseriesAnalyser) GetCurrentBusinesses(config utils.APIDependencyConfig) (
@@ -44,13 +74,13 @@ func(analyser *TimeseriesAnalyser) GetCurrentBusinesses(config utils.APIDependen
     return payload.Data, nil
 }
 
-// function used to retrieve business metadata for all stored
-// businesses
+// function used to retrieve timeseries data for a given business
+// within the given time window
 func(analyser *TimeseriesAnalyser) GetTimeseriesData(config utils.APIDependencyConfig,
     businessId uuid.UUID, start, end time.Time) (map[string][]api.TimeseriesDataEntry, error) {
     // establish new connection to postgres persistence
     access := api.NewTRFApiAccessorFromConfig(config)
-    payload, err := access.GetTimeseriesDataCounted(businessId, 5)
+    payload, err := access.GetTimeseriesData(businessId, start, end)
     if err != nil {
         log.Error(fmt.Errorf("unable to retrieve businesses from API: %+v", err))
         return payload.Data, err
@@ -59,77 +89,220 @@ func(analyser *TimeseriesAnalyser) GetTimeseriesData(config utils.APIDependencyC
 }
 
 // function used to analyse business data with a given
-// business ID, start and end timestamps
+// business ID, start and end timestamps. data is retrieved for
+// the longest window of the given rules if it exceeds the
+// analysis window, but only data within the analysis window
+// is used to detect changes
 func(analyser *TimeseriesAnalyser) AnalyseBusinessData(business connectors.BusinessMetadata,
-    start, end time.Time) error {
+    start, end time.Time, rules []alert_rules.Rule) error {
 
+    fetchStart := start
+    if lookback := end.Add(-alert_rules.Lookback(rules)); lookback.Before(fetchStart) {
+        fetchStart = lookback
+    }
     // get timeseries data from texas real foods API
     data, err := analyser.GetTimeseriesData(analyser.TRFAPIConfig,
-        business.BusinessId, start, end)
+        business.BusinessId, fetchStart, end)
     if err != nil {
         log.Error(fmt.Errorf("unable to retrieve timeseries data for business %s: %+v",
             business.BusinessId, err))
         return err
     }
 
-    notify := []notifications.ChangeNotification{}
-    // iterate over source data and compare values
-    for source, values := range(data) {
-        log.Debug(fmt.Sprintf("analysing source %s with values %+v", source, values))
-        for i, entry := range(values) {
-            if i == 0 {
-                continue
+    accessor := api.NewNotificationsApiAccessorFromConfig(analyser.NotifyAPIConfig)
+    analyser.EvaluateAlertRules(accessor, business, data, rules, end)
+
+    // iterate over source data and analyse values within window
+    for source, entries := range(data) {
+        values := []api.TimeseriesDataEntry{}
+        for _, entry := range(entries) {
+            if !entry.EventTimestamp.Before(start) {
+                values = append(values, entry)
             }
-            // compare timeseries entry to previous entry
-            changed, fields := timeSeriesEntriesDiffer(entry, values[i - 1])
-            if changed {
-                log.Info(fmt.Sprintf("found differences in timeseries entries for %+v:%s",
-                    business.BusinessName, source))
-                // generate notification message and hash
-                notificationString := fmt.Sprintf("Found change in timeseries business data for %s in source %s",
-                    business.BusinessName, source)
-                notificationString = fmt.Sprintf("%s: the following fields have changed %+v",
-                    notificationString, fields)
-
-                // generate new notification
-                notification := notifications.ChangeNotification{
-                    BusinessId: business.BusinessId,
-                    BusinessName: business.BusinessName,
-                    EventTimestamp: time.Now(),
-                    Notification: notificationString,
-                    NotificationHash: generateNotificationHash(business.BusinessId,
-                        source, entry.EventTimestamp),
-                    Metadata: map[string]interface{}{
-                        "source": source,
-                    },
-                }
-                notify = append(notify, notification)
-                break
+        }
+        log.Debug(fmt.Sprintf("analysing source %s with %d values", source, len(values)))
+        analysis := analyseSource(values, analyser.Config, analyser.PhoneRegion)
+
+        // changes are suppressed while a source is flapping, with a
+        // single unstable notification sent for the source instead
+        if len(analysis.Flapping) > 0 {
+            log.Info(fmt.Sprintf("found flapping fields %+v for %s:%s", analysis.Flapping,
+                business.BusinessName, source))
+            if err := analyser.NotifyUnstable(accessor, business, source, analysis.Flapping); err != nil {
+                log.Error(fmt.Errorf("unable to send unstable notification: %+v", err))
+            }
+            continue
+        }
+
+        for _, notification := range(analyser.ChangeNotifications(business, source, analysis.Changes)) {
+            // send notification for business change
+            if _, err := accessor.CreateNotification(notification); err != nil {
+                log.Error(fmt.Errorf("unable to send new notification: %+v", err))
+            }
+        }
+
+        // resolve any outstanding unstable notifications once values settle
+        if analysis.Settled {
+            if err := analyser.ResolveUnstable(accessor, business, source); err != nil {
+                log.Error(fmt.Errorf("unable to resolve unstable notifications: %+v", err))
             }
         }
     }
+    return nil
+}
 
-    accessor := api.NewNotificationsApiAccessorFromConfig(analyser.NotifyAPIConfig)
-    for _, msg := range(notify) {
-        // send notification for business change
-        if _, err := accessor.CreateNotification(msg); err != nil {
-            log.Error(fmt.Errorf("unable to send new notification: %+v", err))
+// function used to evaluate alert rules against the timeseries data
+// of a business and send a notification for each triggered rule.
+// notifications are hashed against the event that triggered the
+// rule to ensure that each event is only reported once
+func(analyser *TimeseriesAnalyser) EvaluateAlertRules(accessor *api.NotificationsAPIAccessor,
+    business connectors.BusinessMetadata, data map[string][]api.TimeseriesDataEntry,
+    rules []alert_rules.Rule, now time.Time) {
+
+    observations := toObservations(data)
+    for _, rule := range(rules) {
+        match, ok := alert_rules.Evaluate(rule, observations, analyser.PhoneRegion, now)
+        if !ok {
+            continue
+        }
+        log.Info(fmt.Sprintf("alert rule %s triggered for %s", rule.RuleId, business.BusinessName))
+        message, err := rule.Render(business, match)
+        if err != nil {
+            log.Error(fmt.Errorf("unable to render message for alert rule %s: %+v", rule.RuleId, err))
+            continue
+        }
+
+        notification := notifications.ChangeNotification{
+            BusinessId: business.BusinessId,
+            BusinessName: business.BusinessName,
+            EventTimestamp: time.Now(),
+            Notification: message,
+            NotificationHash: generateNotificationHash(business.BusinessId,
+                fmt.Sprintf("rule:%s:%s", rule.RuleId, match.Source), match.Timestamp),
+            Metadata: map[string]interface{}{
+                "source": match.Source,
+                "sources": match.Sources,
+                "type": "rule",
+                "rule_id": rule.RuleId,
+                "severity": rule.Severity,
+                "business_id": business.BusinessId.String(),
+            },
+        }
+        if _, err := accessor.CreateNotification(notification); err != nil &&
+            err != api.ErrNotificationAlreadyExists {
+            log.Error(fmt.Errorf("unable to send alert rule notification: %+v", err))
+        }
+    }
+}
+
+// function used to generate notifications for confirmed changes.
+// changes confirmed from the same observation are grouped into a
+// single notification, and notifications are hashed against the
+// timestamp of the observation to ensure that changes are only
+// reported once while they remain in the analysis window
+func(analyser *TimeseriesAnalyser) ChangeNotifications(business connectors.BusinessMetadata,
+    source string, changes []FieldChange) []notifications.ChangeNotification {
+
+    grouped := map[time.Time][]string{}
+    timestamps := []time.Time{}
+    for _, change := range(changes) {
+        if _, ok := grouped[change.Timestamp]; !ok {
+            timestamps = append(timestamps, change.Timestamp)
+        }
+        grouped[change.Timestamp] = append(grouped[change.Timestamp], change.Field)
+    }
+
+    notify := []notifications.ChangeNotification{}
+    for _, ts := range(timestamps) {
+        fields := grouped[ts]
+        log.Info(fmt.Sprintf("found confirmed changes in timeseries entries for %+v:%s",
+            business.BusinessName, source))
+        // generate notification message and hash
+        notificationString := fmt.Sprintf("Found change in timeseries business data for %s in source %s",
+            business.BusinessName, source)
+        notificationString = fmt.Sprintf("%s: the following fields have changed %+v",
+            notificationString, fields)
+
+        notify = append(notify, notifications.ChangeNotification{
+            BusinessId: business.BusinessId,
+            BusinessName: business.BusinessName,
+            EventTimestamp: time.Now(),
+            Notification: notificationString,
+            NotificationHash: generateNotificationHash(business.BusinessId, source, ts),
+            Metadata: map[string]interface{}{
+                "source": source,
+                "type": "change",
+                "business_id": business.BusinessId.String(),
+            },
+        })
+    }
+    return notify
+}
+
+// function used to send an unstable notification for a source. only
+// a single notification is sent for each source until the existing
+// notification has been resolved
+func(analyser *TimeseriesAnalyser) NotifyUnstable(accessor *api.NotificationsAPIAccessor,
+    business connectors.BusinessMetadata, source string, fields []string) error {
+
+    existing, err := accessor.GetUnreadNotificationsByFilter(unstableFilter(business, source))
+    if err != nil {
+        return err
+    }
+    if len(existing) > 0 {
+        log.Debug(fmt.Sprintf("unstable notification already exists for %s:%s", business.BusinessName, source))
+        return nil
+    }
+
+    now := time.Now()
+    notification := notifications.ChangeNotification{
+        BusinessId: business.BusinessId,
+        BusinessName: business.BusinessName,
+        EventTimestamp: now,
+        Notification: fmt.Sprintf("Found unstable timeseries business data for %s in source %s: the following fields are flapping %+v",
+            business.BusinessName, source, fields),
+        NotificationHash: generateNotificationHash(business.BusinessId, source + ":unstable", now),
+        Metadata: map[string]interface{}{
+            "source": source,
+            "type": "unstable",
+            "business_id": business.BusinessId.String(),
+        },
+    }
+    _, err = accessor.CreateNotification(notification)
+    return err
+}
+
+// function used to resolve unstable notifications for a source by
+// marking any unread notifications as read
+func(analyser *TimeseriesAnalyser) ResolveUnstable(accessor *api.NotificationsAPIAccessor,
+    business connectors.BusinessMetadata, source string) error {
+
+    existing, err := accessor.GetUnreadNotificationsByFilter(unstableFilter(business, source))
+    if err != nil {
+        return err
+    }
+    for _, notification := range(existing) {
+        log.Info(fmt.Sprintf("resolving unstable notification %s for %s:%s", notification.NotificationId,
+            business.BusinessName, source))
+        if _, err := accessor.UpdateNotification(notification.NotificationId); err != nil {
+            return err
         }
     }
     return nil
 }
 
 // function to retrieve analysis timewindow based on current
-// timestamp and collection interface specified in connector
+// timestamp and the configured analysis window
 func(analyser *TimeseriesAnalyser) GetAnalysisWindow() (time.Time, time.Time) {
     now := time.Now().Round(time.Minute * 1)
-    start := now.Add(time.Minute * time.Duration(-analyser.AnalysisIntervalMinutes))
+    start := now.Add(time.Minute * time.Duration(-analyser.Config.WindowMinutes))
     return start, now
 }
 
 // function used to analyse timeseries data
 func(analyser *TimeseriesAnalyser) Analyse() error {
     start, end := analyser.GetAnalysisWindow()
+    rules := analyser.GetAlertRules(api.NewNotificationsApiAccessorFromConfig(analyser.NotifyAPIConfig))
     // retrieve list of current businesses from API
     businesses, err := analyser.GetCurrentBusinesses(analyser.TRFAPIConfig)
     if err != nil {
@@ -139,7 +312,7 @@ func(analyser *TimeseriesAnalyser) Analyse() error {
 
     // iterate over businesses and analyse timeseries data for each
     for _, business := range(businesses) {
-        if err := analyser.AnalyseBusinessData(business, start, end); err != nil {
+        if err := analyser.AnalyseBusinessData(business, start, end, rules); err != nil {
             log.Error(fmt.Errorf("unable to analyse business data for %s: %+v", business.BusinessName, err))
             continue
         }
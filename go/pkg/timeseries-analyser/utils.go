@@ -2,56 +2,18 @@ package timeseries_analyser
 
 import (
     "fmt"
+    "sort"
     "time"
     "crypto/sha256"
     "encoding/hex"
 
     "github.com/google/uuid"
 
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/alert-rules"
     api "texas_real_foods/pkg/utils/api_accessors"
 )
 
-func stringSliceEqual(a, b []string) bool {
-    if len(a) != len(b) {
-        return false
-    }
-    for _, entry := range(a) {
-        if !stringSliceContains(b, entry) {
-            return false
-        }
-    }
-    return true
-}
-
-func stringSliceContains(slice []string, element string) bool {
-    for _, entry := range(slice) {
-        if entry == element {
-            return true
-        }
-    }
-    return false
-}
-
-// function used to determine if timeseries entries differ.
-// both a boolean value indicating if fields have changes
-// is returned as well as a list of changed fields
-func timeSeriesEntriesDiffer(a, b api.TimeseriesDataEntry) (bool, []string) {
-    changedFields := []string{}
-    // check if website live entries match
-    if a.WebsiteLive != b.WebsiteLive {
-        changedFields = append(changedFields, "website_live")
-    }
-    // check if phone numbers have changed
-    if !stringSliceEqual(a.BusinessPhones, b.BusinessPhones) {
-        changedFields = append(changedFields, "phonenumber")
-    }
-    // check if business open has changed
-    if a.BusinessOpen != b.BusinessOpen {
-        changedFields = append(changedFields, "business_open")
-    }
-    return len(changedFields) > 0, changedFields
-}
-
 // function used to generate notification hash. notifications hashes are
 // generate as a combination of business ID, source and the current date
 // to ensure that one unique notification is sent per business, per source
@@ -60,4 +22,30 @@ func generateNotificationHash(businessId uuid.UUID, source string, ts time.Time)
     notifyString := fmt.Sprintf("%s:%s:%s", businessId, source, ts)
     notificationHash := sha256.Sum256([]byte(notifyString))
     return hex.EncodeToString(notificationHash[0:])
-}
\ No newline at end of file
+}
+
+// function used to generate the metadata filter used to retrieve
+// unstable notifications for a given business and source
+func unstableFilter(business connectors.BusinessMetadata, source string) string {
+    return fmt.Sprintf("type:unstable,business_id:%s,source:%s", business.BusinessId, source)
+}
+
+// function used to convert timeseries data into observations used to
+// evaluate alert rules. observations are sorted by timestamp
+func toObservations(data map[string][]api.TimeseriesDataEntry) map[string][]alert_rules.Observation {
+    observations := map[string][]alert_rules.Observation{}
+    for source, entries := range(data) {
+        values := []alert_rules.Observation{}
+        for _, entry := range(entries) {
+            values = append(values, alert_rules.Observation{
+                Timestamp: entry.EventTimestamp,
+                Data: entry.BusinessData,
+            })
+        }
+        sort.Slice(values, func(i, j int) bool {
+            return values[i].Timestamp.Before(values[j].Timestamp)
+        })
+        observations[source] = values
+    }
+    return observations
+}
@@ -0,0 +1,113 @@
+package timeseries_analyser
+
+import (
+    "fmt"
+    "sort"
+    "time"
+    "strings"
+
+    "texas_real_foods/pkg/phone"
+    api "texas_real_foods/pkg/utils/api_accessors"
+)
+
+// struct used to store settings for windowed analysis. changes
+// are only reported once confirmed by the given number of
+// consecutive observations, and fields are considered to be
+// flapping if the number of transitions within the window
+// reaches the flap threshold before a value is confirmed
+type AnalysisConfig struct {
+    WindowMinutes     int
+    ConfirmationCount int
+    FlapThreshold     int
+}
+
+// struct used to store a confirmed change in a single field
+type FieldChange struct {
+    Field     string
+    From      string
+    To        string
+    Timestamp time.Time
+}
+
+// struct used to store the results of analysing the observations
+// of a single source within the analysis window
+type SourceAnalysis struct {
+    Changes  []FieldChange
+    Flapping []string
+    Settled  bool
+}
+
+// function used to extract the values of all analysed fields from
+// a timeseries entry. values are converted to strings to allow
+// comparisons, with phone numbers normalized and sorted
+func fieldValues(entry api.TimeseriesDataEntry, region string) map[string]string {
+    phones := phone.NormalizeAll(entry.BusinessPhones, region)
+    sort.Strings(phones)
+    return map[string]string{
+        "website_live": fmt.Sprintf("%t", entry.WebsiteLive),
+        "phonenumber": strings.Join(phones, ","),
+        "business_open": fmt.Sprintf("%t", entry.BusinessOpen),
+    }
+}
+
+// function used to analyse the observations of a single source.
+// observations are sorted by timestamp before being analysed, and
+// the first observation in the window is used as the baseline
+func analyseSource(values []api.TimeseriesDataEntry, config AnalysisConfig,
+    region string) SourceAnalysis {
+
+    analysis := SourceAnalysis{Changes: []FieldChange{}, Flapping: []string{}, Settled: true}
+    if len(values) == 0 {
+        return analysis
+    }
+    sort.Slice(values, func(i, j int) bool {
+        return values[i].EventTimestamp.Before(values[j].EventTimestamp)
+    })
+
+    observations := []map[string]string{}
+    for _, entry := range(values) {
+        observations = append(observations, fieldValues(entry, region))
+    }
+
+    fields := []string{}
+    for field := range(observations[0]) {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+
+    for _, field := range(fields) {
+        stable := observations[0][field]
+        runValue, runCount, runStart := stable, 1, values[0].EventTimestamp
+        transitions := 0
+        for i := 1; i < len(observations); i++ {
+            value := observations[i][field]
+            if value != observations[i - 1][field] {
+                transitions++
+            }
+            if value == runValue {
+                runCount++
+            } else {
+                runValue, runCount, runStart = value, 1, values[i].EventTimestamp
+            }
+            // report change once new value has been confirmed
+            if runValue != stable && runCount >= config.ConfirmationCount {
+                analysis.Changes = append(analysis.Changes, FieldChange{
+                    Field: field,
+                    From: stable,
+                    To: runValue,
+                    Timestamp: runStart,
+                })
+                stable = runValue
+            }
+        }
+
+        settled := runCount >= config.ConfirmationCount
+        if !settled {
+            analysis.Settled = false
+            if config.FlapThreshold > 0 && transitions >= config.FlapThreshold {
+                analysis.Flapping = append(analysis.Flapping, field)
+            }
+        }
+    }
+    return analysis
+}
@@ -0,0 +1,82 @@
+package timeseries_analyser
+
+import (
+    "fmt"
+    "time"
+    "strings"
+    "testing"
+
+    "texas_real_foods/pkg/connectors"
+    "texas_real_foods/pkg/phone"
+    api "texas_real_foods/pkg/utils/api_accessors"
+)
+
+// function used to generate observations of a website from a string of
+// 'T' (live) and 'F' (down) states, one minute apart. other fields keep
+// the same value in all observations
+func testObservations(states string) []api.TimeseriesDataEntry {
+    start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+    values := []api.TimeseriesDataEntry{}
+    for i, state := range(states) {
+        values = append(values, api.TimeseriesDataEntry{
+            EventTimestamp: start.Add(time.Duration(i) * time.Minute),
+            BusinessData: connectors.BusinessData{WebsiteLive: state == 'T', BusinessOpen: true,
+                BusinessPhones: []string{"512-555-0100"}},
+        })
+    }
+    return values
+}
+
+func TestAnalyseSource(t *testing.T) {
+    tests := []struct {
+        states       string
+        confirmation int
+        reversed     bool
+        changes      string
+        flapping     bool
+        settled      bool
+    }{
+        {"", 2, false, "", false, true},
+        {"TTTT", 2, false, "", false, true},
+        {"TFF", 2, false, "true>false@1", false, true},
+        {"TF", 1, false, "true>false@1", false, true},
+        {"TF", 2, false, "", false, false},
+        {"TFT", 2, false, "", false, false},
+        {"TFTF", 2, false, "", true, false},
+        {"TFTFF", 2, false, "true>false@3", false, true},
+        {"TFFTT", 2, false, "true>false@1,false>true@3", false, true},
+        {"TFFTT", 2, true, "true>false@1,false>true@3", false, true},
+        {"TFFF", 3, false, "true>false@1", false, true},
+    }
+    config := AnalysisConfig{WindowMinutes: 60, FlapThreshold: 3}
+    for _, test := range(tests) {
+        values := testObservations(test.states)
+        if test.reversed {
+            for i, j := 0, len(values) - 1; i < j; i, j = i + 1, j - 1 {
+                values[i], values[j] = values[j], values[i]
+            }
+        }
+        config.ConfirmationCount = test.confirmation
+        analysis := analyseSource(values, config, phone.DefaultRegion)
+
+        changes := []string{}
+        start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+        for _, change := range(analysis.Changes) {
+            if change.Field != "website_live" {
+                t.Errorf("%q: unexpected change of %s", test.states, change.Field)
+            }
+            changes = append(changes, fmt.Sprintf("%s>%s@%d", change.From, change.To,
+                int(change.Timestamp.Sub(start).Minutes())))
+        }
+        if strings.Join(changes, ",") != test.changes {
+            t.Errorf("%q: expected changes %q, got %q", test.states, test.changes, strings.Join(changes, ","))
+        }
+        flapping := len(analysis.Flapping) == 1 && analysis.Flapping[0] == "website_live"
+        if flapping != test.flapping || (!flapping && len(analysis.Flapping) > 0) {
+            t.Errorf("%q: expected flapping %v, got %v", test.states, test.flapping, analysis.Flapping)
+        }
+        if analysis.Settled != test.settled {
+            t.Errorf("%q: expected settled %v, got %v", test.states, test.settled, analysis.Settled)
+        }
+    }
+}
@@ -5,12 +5,14 @@ import (
     "bytes"
     "errors"
     "encoding/json"
+    neturl "net/url"
 
     "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
 
     "texas_real_foods/pkg/utils"
     "texas_real_foods/pkg/notifications"
+    "texas_real_foods/pkg/alert-rules"
 )
 
 var (
@@ -124,6 +126,45 @@ func(accessor *NotificationsAPIAccessor) GetUnreadNotifications() ([]Notificatio
     }
 }
 
+// function to retrieve unread notifications from API that match
+// a given metadata filter i.e. 'source:syncer,type:stale_data'
+func(accessor *NotificationsAPIAccessor) GetUnreadNotificationsByFilter(filter string) (
+    []Notification, error) {
+    log.Debug(fmt.Sprintf("retrieving unread notifications matching filter %s...", filter))
+    var response NotificationsResponse
+    url := fmt.Sprintf("%s?filter=%s", accessor.FormatURL("notifications/unread"),
+        neturl.QueryEscape(filter))
+
+    // generate new JSON request and execute
+    req, err := accessor.NewJSONRequest("GET", url, nil, nil)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to create request: %+v", err))
+        return response.Notifications, err
+    }
+    resp, err := accessor.ExecuteRequest(req)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to execute API request: %+v", err))
+        return response.Notifications, err
+    }
+    defer resp.Body.Close()
+
+    // handle response based on code
+    switch resp.StatusCode {
+    case 200:
+        log.Debug(fmt.Sprintf("successfully retrieved notifications"))
+        // decode JSON response from API and return
+        if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+            log.Error(fmt.Errorf("unable to parse JSON response from API: %+v", err))
+            return response.Notifications, err
+        }
+        return response.Notifications, nil
+    default:
+        log.Error(fmt.Sprintf("failed retrieve notifications: received invalid %d response from API",
+            resp.StatusCode))
+        return response.Notifications, utils.ErrInvalidAPIResponse
+    }
+}
+
 type HTTPMessageResponse struct {
     HTTPCode int    `json:"http_code"`
     Message  string `json:"message"`
@@ -212,3 +253,45 @@ func(accessor *NotificationsAPIAccessor) CreateNotification(notification notific
         return response, utils.ErrInvalidAPIResponse
     }
 }
+
+type AlertRulesResponse struct {
+    HTTPCode int                `json:"http_code"`
+    Count    int                `json:"count"`
+    Rules    []alert_rules.Rule `json:"rules"`
+}
+
+// function to retrieve all alert rules from API
+func(accessor *NotificationsAPIAccessor) GetAlertRules() ([]alert_rules.Rule, error) {
+    log.Debug("retrieving alert rules from notifications API...")
+    var response AlertRulesResponse
+    url := accessor.FormatURL("notifications/rules")
+
+    // generate new JSON request and execute
+    req, err := accessor.NewJSONRequest("GET", url, nil, nil)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to create request: %+v", err))
+        return response.Rules, err
+    }
+    resp, err := accessor.ExecuteRequest(req)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to execute API request: %+v", err))
+        return response.Rules, err
+    }
+    defer resp.Body.Close()
+
+    // handle response based on code
+    switch resp.StatusCode {
+    case 200:
+        log.Debug(fmt.Sprintf("successfully retrieved alert rules"))
+        // decode JSON response from API and return
+        if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+            log.Error(fmt.Errorf("unable to parse JSON response from API: %+v", err))
+            return response.Rules, err
+        }
+        return response.Rules, nil
+    default:
+        log.Error(fmt.Sprintf("failed retrieve alert rules: received invalid %d response from API",
+            resp.StatusCode))
+        return response.Rules, utils.ErrInvalidAPIResponse
+    }
+}
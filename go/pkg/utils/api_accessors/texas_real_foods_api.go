@@ -1,9 +1,14 @@
 package utils
 
 import (
+    "io"
     "fmt"
     "time"
+    "bytes"
+    "errors"
+    "strconv"
     "encoding/json"
+    neturl "net/url"
 
     "github.com/google/uuid"
     log "github.com/sirupsen/logrus"
@@ -36,15 +41,66 @@ func NewTRFApiAccessorFromConfig(config utils.APIDependencyConfig) *TexasRealFoo
     }
 }
 
+var (
+    // define custom errors
+    ErrBusinessModified = errors.New("Business has been modified")
+
+    // define number of businesses retrieved per page when iterating
+    // over businesses
+    BusinessPageSize = 500
+
+    // define number of times an update is retried with the latest
+    // version of a business when the business is modified concurrently
+    ConflictRetries = 3
+)
+
 type ListBusinessResponse struct {
-    HTTPCode int 			               `json:"http_code"`
-    Data     []connectors.BusinessMetadata `json:"data"`
+    HTTPCode   int                           `json:"http_code"`
+    Count      int                           `json:"count"`
+    Data       []connectors.BusinessMetadata `json:"data"`
+    NextCursor string                        `json:"next_cursor"`
 }
 
-// function to get businesses from texas real foods API
+// function to get all businesses from texas real foods API. pages
+// are retrieved until the API returns no further cursor
 func(accessor *TexasRealFoodsAPIAccessor) GetBusinesses() (ListBusinessResponse, error) {
+    return accessor.GetFilteredBusinesses(neturl.Values{})
+}
+
+// function to get all businesses matching the given filters from texas
+// real foods API i.e. filters={"has": ["yelp_business_id"]} to retrieve
+// all businesses with a yelp ID
+func(accessor *TexasRealFoodsAPIAccessor) GetFilteredBusinesses(filters neturl.Values) (ListBusinessResponse, error) {
     log.Debug("fetching businesses from texas real foods api...")
+    params := neturl.Values{}
+    for key, values := range(filters) {
+        params[key] = values
+    }
+    params.Set("limit", strconv.Itoa(BusinessPageSize))
+
+    response := ListBusinessResponse{Data: []connectors.BusinessMetadata{}}
+    for {
+        page, err := accessor.GetBusinessesPage(params)
+        if err != nil {
+            return response, err
+        }
+        response.HTTPCode = page.HTTPCode
+        response.Data = append(response.Data, page.Data...)
+        response.Count = len(response.Data)
+        if len(page.NextCursor) == 0 {
+            return response, nil
+        }
+        params.Set("cursor", page.NextCursor)
+    }
+}
+
+// function to get a single page of businesses from texas real foods API.
+// the next cursor of the response is used to retrieve the next page
+func(accessor *TexasRealFoodsAPIAccessor) GetBusinessesPage(params neturl.Values) (ListBusinessResponse, error) {
     url := accessor.FormatURL("texas-real-foods/businesses")
+    if encoded := params.Encode(); len(encoded) > 0 {
+        url = fmt.Sprintf("%s?%s", url, encoded)
+    }
 
     var response ListBusinessResponse
     // generate new JSON request and execute
@@ -77,6 +133,157 @@ func(accessor *TexasRealFoodsAPIAccessor) GetBusinesses() (ListBusinessResponse,
     }
 }
 
+type BusinessResponse struct {
+    HTTPCode int                         `json:"http_code"`
+    Data     connectors.BusinessMetadata `json:"data"`
+}
+
+// function to get a single business from texas real foods API along with
+// its entity tag. the entity tag is used as the If-Match header of updates
+// so that updates fail if the business has been modified
+func(accessor *TexasRealFoodsAPIAccessor) GetBusiness(businessId uuid.UUID) (BusinessResponse, string, error) {
+    log.Debug(fmt.Sprintf("fetching business %s from texas real foods api...", businessId))
+    url := accessor.FormatURL(fmt.Sprintf("texas-real-foods/business/info/%s", businessId))
+
+    var response BusinessResponse
+    // generate new JSON request and execute
+    req, err := accessor.NewJSONRequest("GET", url, nil, nil)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to create request: %+v", err))
+        return response, "", err
+    }
+    resp, err := accessor.ExecuteRequest(req)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to execute API request: %+v", err))
+        return response, "", err
+    }
+    defer resp.Body.Close()
+
+    // handle response based on code
+    switch resp.StatusCode {
+    case 200:
+        log.Debug(fmt.Sprintf("successfully retrieved business from API"))
+        // decode JSON response from API and return
+        if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+            log.Error(fmt.Errorf("unable to parse JSON response from API: %+v", err))
+            return response, "", err
+        }
+        return response, resp.Header.Get("ETag"), nil
+    case 404:
+        log.Warn(fmt.Sprintf("unable to retrieve business: cannot find business %s", businessId))
+        return response, "", utils.ErrBusinessNotFound
+    default:
+        log.Error(fmt.Sprintf("failed to retrieve business: received invalid %d response from API",
+            resp.StatusCode))
+        return response, "", utils.ErrInvalidAPIResponse
+    }
+}
+
+// function to apply a JSON patch operation to the metadata of a business.
+// the patch is only applied if the business matches the given entity tag,
+// and ErrBusinessModified is returned if the business has been modified.
+// the entity tag of the patched business is returned so that further
+// updates can be made without retrieving the business again
+func(accessor *TexasRealFoodsAPIAccessor) PatchBusinessMetadata(businessId uuid.UUID, etag string,
+    operation []map[string]interface{}) (HTTPMessageResponse, string, error) {
+    log.Debug(fmt.Sprintf("patching metadata of business %s...", businessId))
+    var response HTTPMessageResponse
+
+    jsonBody, err := json.Marshal(map[string]interface{}{"operation": operation})
+    if err != nil {
+        log.Error(fmt.Errorf("unable to convert patch operation to JSON format"))
+        return response, "", utils.ErrInvalidRequestBodyJSON
+    }
+    url := accessor.FormatURL(fmt.Sprintf("texas-real-foods/business/meta/%s", businessId))
+    return accessor.executeBusinessUpdate("PATCH", url, bytes.NewBuffer(jsonBody), etag)
+}
+
+// function to update the metadata of a business with a JSON patch operation
+// generated from the latest version of the business. if the business is
+// modified before the patch is applied, the business is retrieved again
+// and the patch is regenerated up to the configured number of retries.
+// the entity tag of the updated business is returned
+func(accessor *TexasRealFoodsAPIAccessor) UpdateBusinessMetadata(businessId uuid.UUID,
+    generate func(business connectors.BusinessMetadata) ([]map[string]interface{}, error)) (
+    HTTPMessageResponse, string, error) {
+
+    for attempt := 0; ; attempt++ {
+        business, etag, err := accessor.GetBusiness(businessId)
+        if err != nil {
+            return HTTPMessageResponse{}, "", err
+        }
+        operation, err := generate(business.Data)
+        if err != nil {
+            return HTTPMessageResponse{}, "", err
+        }
+        response, updated, err := accessor.PatchBusinessMetadata(businessId, etag, operation)
+        if err == ErrBusinessModified && attempt < ConflictRetries {
+            log.Warn(fmt.Sprintf("business %s modified during update. retrying...", businessId))
+            continue
+        }
+        return response, updated, err
+    }
+}
+
+// function to delete a business. the business is only deleted if it
+// matches the given entity tag, and ErrBusinessModified is returned if
+// the business has been modified. businesses are deleted regardless of
+// version if no entity tag is given
+func(accessor *TexasRealFoodsAPIAccessor) DeleteBusiness(businessId uuid.UUID, etag string) (
+    HTTPMessageResponse, error) {
+    log.Debug(fmt.Sprintf("deleting business %s...", businessId))
+    url := accessor.FormatURL(fmt.Sprintf("texas-real-foods/business/%s", businessId))
+    response, _, err := accessor.executeBusinessUpdate("DELETE", url, nil, etag)
+    return response, err
+}
+
+// function used to execute a request that modifies a business with the
+// given entity tag as the If-Match header. the entity tag of the modified
+// business is returned if set by the API
+func(accessor *TexasRealFoodsAPIAccessor) executeBusinessUpdate(method, url string, body io.Reader,
+    etag string) (HTTPMessageResponse, string, error) {
+    var response HTTPMessageResponse
+
+    headers := map[string]string{}
+    if len(etag) > 0 {
+        headers["If-Match"] = etag
+    }
+    // generate new JSON request and execute
+    req, err := accessor.NewJSONRequest(method, url, body, headers)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to create request: %+v", err))
+        return response, "", err
+    }
+    resp, err := accessor.ExecuteRequest(req)
+    if err != nil {
+        log.Error(fmt.Errorf("unable to execute API request: %+v", err))
+        return response, "", err
+    }
+    defer resp.Body.Close()
+
+    // handle response based on code
+    switch resp.StatusCode {
+    case 200:
+        log.Debug(fmt.Sprintf("successfully updated business"))
+        // decode JSON response from API and return
+        if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+            log.Error(fmt.Errorf("unable to parse JSON response from API: %+v", err))
+            return response, "", err
+        }
+        return response, resp.Header.Get("ETag"), nil
+    case 404:
+        log.Warn("unable to update business: cannot find business")
+        return response, "", utils.ErrBusinessNotFound
+    case 412:
+        log.Warn("unable to update business: business has been modified")
+        return response, "", ErrBusinessModified
+    default:
+        log.Error(fmt.Sprintf("failed to update business: received invalid %d response from API",
+            resp.StatusCode))
+        return response, "", utils.ErrInvalidAPIResponse
+    }
+}
+
 type TimeseriesDataEntry struct {
     EventTimestamp time.Time
     connectors.BusinessData
@@ -176,9 +383,15 @@ func(accessor *TexasRealFoodsAPIAccessor) GetTimeseriesDataCounted(businessId uu
     }
 }
 
+type StaticBusinessData struct {
+    connectors.BusinessData
+    Stale bool `json:"stale"`
+}
+
 type StaticDataResponse struct {
     HTTPCode int 					   `json:"http_code"`
-    Data     []connectors.BusinessData `json:"data"`
+    Stale    bool                      `json:"stale"`
+    Data     []StaticBusinessData      `json:"data"`
 }
 
 // function to get static data from texas real foods API for
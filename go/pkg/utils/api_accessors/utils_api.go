@@ -57,8 +57,9 @@ type PhoneNumberValidationRequest struct {
 }
 
 // function used to validate phone numbers against phone
-// validation API
-func(accessor *UtilsAPIAccessor) ValidatePhoneNumbers(numbers []string) (
+// validation API. numbers are validated against the region
+// with the given country code i.e. 'US' or 'GB'
+func(accessor *UtilsAPIAccessor) ValidatePhoneNumbers(numbers []string, countryCode string) (
     PhoneNumberValidationResults, error) {
     log.Debug(fmt.Sprintf("validating %d numbers against utils API", len(numbers)))
     url := accessor.FormatURL("validate")
@@ -66,7 +67,7 @@ func(accessor *UtilsAPIAccessor) ValidatePhoneNumbers(numbers []string) (
     var response PhoneNumberValidationResponse
     // serialize request body into JSON string
     jsonBody, err := json.Marshal(PhoneNumberValidationRequest{
-        CountryCode: "US",
+        CountryCode: countryCode,
         Numbers: numbers})
     if err != nil {
         return response.Data, utils.ErrInvalidRequestBodyJSON
@@ -2,12 +2,26 @@ package utils
 
 import (
     "fmt"
+    "time"
+    "strconv"
     "context"
 
     "github.com/jackc/pgx/v4/pgxpool"
     log "github.com/sirupsen/logrus"
 )
 
+var (
+    // define default settings of shared connection pools. settings
+    // can be overridden with the config keys of the same name
+    poolDefaults = map[string]string{
+        "postgres_pool_max_conns": "10",
+        "postgres_pool_min_conns": "1",
+        "postgres_pool_max_conn_lifetime_minutes": "60",
+        "postgres_pool_max_conn_idle_minutes": "30",
+        "postgres_pool_health_check_seconds": "60",
+    }
+)
+
 type BasePostgresPersistence struct {
     DatabaseURL string
     Session     *pgxpool.Pool
@@ -32,4 +46,108 @@ func NewPersistence(url string) *BasePostgresPersistence {
     return &BasePostgresPersistence{
         DatabaseURL: url,
     }
-}
\ No newline at end of file
+}
+
+// function used to generate a new persistence using a shared connection
+// pool. the pool is owned by the service and should not be closed by
+// users of the persistence
+func NewPersistenceWithPool(pool *pgxpool.Pool) *BasePostgresPersistence {
+    return &BasePostgresPersistence{
+        DatabaseURL: pool.Config().ConnString(),
+        Session: pool,
+    }
+}
+
+// struct used to configure the size and health checks of a shared
+// connection pool
+type PoolConfig struct {
+    MaxConns          int32
+    MinConns          int32
+    MaxConnLifetime   time.Duration
+    MaxConnIdleTime   time.Duration
+    HealthCheckPeriod time.Duration
+}
+
+// function used to parse pool settings from a config map. defaults are
+// used for any settings that are not set
+func NewPoolConfig(cfg *ConfigMap) (PoolConfig, error) {
+    values := map[string]int{}
+    for key, defaultVal := range(poolDefaults) {
+        value := cfg.Get(key)
+        if len(value) == 0 {
+            value = defaultVal
+        }
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed < 0 {
+            return PoolConfig{}, fmt.Errorf("invalid value '%s' for %s", value, key)
+        }
+        values[key] = parsed
+    }
+    config := PoolConfig{
+        MaxConns: int32(values["postgres_pool_max_conns"]),
+        MinConns: int32(values["postgres_pool_min_conns"]),
+        MaxConnLifetime: time.Duration(values["postgres_pool_max_conn_lifetime_minutes"]) * time.Minute,
+        MaxConnIdleTime: time.Duration(values["postgres_pool_max_conn_idle_minutes"]) * time.Minute,
+        HealthCheckPeriod: time.Duration(values["postgres_pool_health_check_seconds"]) * time.Second,
+    }
+    if config.MaxConns < 1 || config.MinConns > config.MaxConns {
+        return config, fmt.Errorf("invalid pool size %d-%d", config.MinConns, config.MaxConns)
+    }
+    return config, nil
+}
+
+// function used to create a long-lived connection pool shared by all
+// requests of a service. idle connections are checked periodically
+// and the pool is pinged once created so that services fail on start
+// if postgres is unreachable
+func NewPool(url string, config PoolConfig) (*pgxpool.Pool, error) {
+    poolConfig, err := pgxpool.ParseConfig(url)
+    if err != nil {
+        return nil, err
+    }
+    poolConfig.MaxConns = config.MaxConns
+    poolConfig.MinConns = config.MinConns
+    if config.MaxConnLifetime > 0 {
+        poolConfig.MaxConnLifetime = config.MaxConnLifetime
+    }
+    if config.MaxConnIdleTime > 0 {
+        poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+    }
+    if config.HealthCheckPeriod > 0 {
+        poolConfig.HealthCheckPeriod = config.HealthCheckPeriod
+    }
+
+    log.Info(fmt.Sprintf("creating postgres connection pool with %d-%d connections", config.MinConns,
+        config.MaxConns))
+    pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+    if err != nil {
+        log.Error(fmt.Errorf("error connecting to postgres service: %+v", err))
+        return nil, err
+    }
+    if err := ping(pool); err != nil {
+        log.Error(fmt.Errorf("unable to ping postgres service: %+v", err))
+        pool.Close()
+        return nil, err
+    }
+    return pool, nil
+}
+
+// function used to ensure that a connection can be acquired from a pool
+func ping(pool *pgxpool.Pool) error {
+    conn, err := pool.Acquire(context.Background())
+    if err != nil {
+        return err
+    }
+    defer conn.Release()
+    return conn.Conn().Ping(context.Background())
+}
+
+// function used to create a shared connection pool using the pool
+// settings of a config map
+func NewPoolFromConfig(cfg *ConfigMap) (*pgxpool.Pool, error) {
+    config, err := NewPoolConfig(cfg)
+    if err != nil {
+        return nil, err
+    }
+    return NewPool(cfg.Get("postgres_url"), config)
+}